@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GenericWireguardConfigSpec defines the desired state of GenericWireguardConfig.
+// +kubebuilder:validation:XValidation:rule="self.url.startsWith('https://') || (has(self.allowHTTP) && self.allowHTTP)",message="url must use https unless allowHTTP is set"
+type GenericWireguardConfigSpec struct {
+	// The URL a freshly generated public key is POSTed to, e.g.
+	// "https://wg-control-plane.vpn.svc/peers". See the internal/generic
+	// package for the request and response contract.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// Allow an http URL. The response is written into the generated config
+	// and is not authenticated over plain http.
+	// +optional
+	AllowHTTP bool `json:"allowHTTP,omitempty"`
+
+	// A reference to a secret key containing a bearer token sent with the request
+	// +optional
+	TokenSecretRef *corev1.SecretKeySelector `json:"tokenSecretRef,omitempty"`
+
+	// A reference to a config map key containing PEM encoded certificate
+	// authorities used to verify an https URL instead of the system roots
+	// +optional
+	CACert *corev1.ConfigMapKeySelector `json:"caCert,omitempty"`
+}
+
+// GenericWireguardConfigStatus defines the observed state of GenericWireguardConfig.
+type GenericWireguardConfigStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// The value of the regenerate annotation that was most recently handled
+	// +optional
+	ObservedRegenerate string `json:"observedRegenerate,omitempty"`
+
+	// The name of the secret containing the generated config
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// The name of the server reported by the control plane, or the URL host
+	// +optional
+	Server string `json:"server,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// GenericWireguardConfig is the Schema for the genericwireguardconfigs API.
+type GenericWireguardConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GenericWireguardConfigSpec   `json:"spec,omitempty"`
+	Status GenericWireguardConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GenericWireguardConfigList contains a list of GenericWireguardConfig.
+type GenericWireguardConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GenericWireguardConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GenericWireguardConfig{}, &GenericWireguardConfigList{})
+}
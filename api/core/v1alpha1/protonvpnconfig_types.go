@@ -0,0 +1,106 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProtonVPNConfigSecretRef references the secret containing a downloaded
+// ProtonVPN WireGuard configuration
+type ProtonVPNConfigSecretRef struct {
+	// The name of the secret in the same namespace
+	Name string `json:"name"`
+
+	// The key of the wg-quick configuration in the secret
+	// +kubebuilder:default="wg0.conf"
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// ProtonVPNPortForwarding configures NAT-PMP port forwarding through the tunnel
+type ProtonVPNPortForwarding struct {
+	// The in-tunnel address of the NAT-PMP gateway. The port is requested by a
+	// sidecar of the WireguardClient whose inbound rules follow the config, which
+	// reaches the gateway through its tunnel.
+	// +kubebuilder:default="10.2.0.1"
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+
+	// The requested lifetime of the port mapping in seconds.
+	// The gateway may grant a shorter lifetime, the mapping is renewed before
+	// the granted lifetime expires.
+	// +kubebuilder:default=60
+	// +kubebuilder:validation:Minimum=10
+	// +optional
+	LifetimeSeconds int32 `json:"lifetimeSeconds,omitempty"`
+}
+
+// ProtonVPNConfigSpec defines the desired state of ProtonVPNConfig.
+type ProtonVPNConfigSpec struct {
+	// The secret containing the WireGuard configuration downloaded from ProtonVPN
+	SecretRef ProtonVPNConfigSecretRef `json:"secretRef"`
+
+	// Enables NAT-PMP port forwarding. The configuration must be
+	// generated with NAT-PMP enabled for the gateway to accept requests.
+	// +optional
+	PortForwarding *ProtonVPNPortForwarding `json:"portForwarding,omitempty"`
+}
+
+// ProtonVPNConfigStatus defines the observed state of ProtonVPNConfig.
+type ProtonVPNConfigStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// The endpoint of the server in the configuration
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// The port forwarded to the tunnel by the gateway
+	// +optional
+	ForwardedPort int32 `json:"forwardedPort,omitempty"`
+
+	// When the forwarded port expires unless renewed
+	// +optional
+	PortExpiresAt *metav1.Time `json:"portExpiresAt,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.status.endpoint`
+// +kubebuilder:printcolumn:name="Port",type=integer,JSONPath=`.status.forwardedPort`
+
+// ProtonVPNConfig is the Schema for the protonvpnconfigs API.
+type ProtonVPNConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProtonVPNConfigSpec   `json:"spec,omitempty"`
+	Status ProtonVPNConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ProtonVPNConfigList contains a list of ProtonVPNConfig.
+type ProtonVPNConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProtonVPNConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProtonVPNConfig{}, &ProtonVPNConfigList{})
+}
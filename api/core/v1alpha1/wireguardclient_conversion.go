@@ -0,0 +1,147 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	corev1beta1 "github.com/unmango/thecluster-operator/api/core/v1beta1"
+)
+
+// ConvertTo converts this WireguardClient (v1alpha1) to the Hub version (v1beta1).
+func (src *WireguardClient) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*corev1beta1.WireguardClient)
+	if !ok {
+		return fmt.Errorf("expected a v1beta1 WireguardClient but got %T", dstRaw)
+	}
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = corev1beta1.WireguardClientSpec{
+		Tunnel: corev1beta1.WireguardClientTunnel{
+			AllowedIPs: src.Spec.AllowedIPs,
+		},
+		Runtime: corev1beta1.WireguardClientRuntime{
+			PUID:             src.Spec.PUID,
+			PGID:             src.Spec.PGID,
+			TZ:               src.Spec.TZ,
+			LogConfs:         src.Spec.LogConfs,
+			ReadOnly:         src.Spec.ReadOnly,
+			Image:            src.Spec.Image,
+			ImagePullPolicy:  src.Spec.ImagePullPolicy,
+			ImagePullSecrets: src.Spec.ImagePullSecrets,
+			PodTemplate:      src.Spec.PodTemplate,
+		},
+		SidecarOnly: src.Spec.SidecarOnly,
+		Gateway:     (*corev1beta1.WireguardClientGateway)(src.Spec.Gateway),
+		Proxy:       (*corev1beta1.WireguardClientProxy)(src.Spec.Proxy),
+	}
+	for _, c := range src.Spec.Configs {
+		dst.Spec.Tunnel.Configs = append(dst.Spec.Tunnel.Configs, corev1beta1.WireguardClientConfig{
+			Name:      c.Name,
+			ValueFrom: (*corev1beta1.WireguardClientConfigSource)(c.ValueFrom),
+			Inline:    inlineConfigTo(c.Inline),
+		})
+	}
+	for _, i := range src.Spec.Inbound {
+		dst.Spec.Inbound = append(dst.Spec.Inbound, corev1beta1.WireguardClientInbound{
+			Name:               i.Name,
+			Port:               i.Port,
+			ProtonVPNConfigRef: i.ProtonVPNConfigRef,
+			Protocol:           i.Protocol,
+			Service:            corev1beta1.WireguardClientInboundService(i.Service),
+		})
+	}
+	dst.Status = corev1beta1.WireguardClientStatus(src.Status)
+
+	return nil
+}
+
+// ConvertFrom converts the Hub version (v1beta1) to this version (v1alpha1).
+func (dst *WireguardClient) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*corev1beta1.WireguardClient)
+	if !ok {
+		return fmt.Errorf("expected a v1beta1 WireguardClient but got %T", srcRaw)
+	}
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = WireguardClientSpec{
+		PUID:             src.Spec.Runtime.PUID,
+		PGID:             src.Spec.Runtime.PGID,
+		TZ:               src.Spec.Runtime.TZ,
+		AllowedIPs:       src.Spec.Tunnel.AllowedIPs,
+		LogConfs:         src.Spec.Runtime.LogConfs,
+		ReadOnly:         src.Spec.Runtime.ReadOnly,
+		Image:            src.Spec.Runtime.Image,
+		ImagePullPolicy:  src.Spec.Runtime.ImagePullPolicy,
+		ImagePullSecrets: src.Spec.Runtime.ImagePullSecrets,
+		SidecarOnly:      src.Spec.SidecarOnly,
+		Gateway:          (*WireguardClientGateway)(src.Spec.Gateway),
+		Proxy:            (*WireguardClientProxy)(src.Spec.Proxy),
+		PodTemplate:      src.Spec.Runtime.PodTemplate,
+	}
+	for _, c := range src.Spec.Tunnel.Configs {
+		dst.Spec.Configs = append(dst.Spec.Configs, WireguardClientConfig{
+			Name:      c.Name,
+			ValueFrom: (*WireguardClientConfigSource)(c.ValueFrom),
+			Inline:    inlineConfigFrom(c.Inline),
+		})
+	}
+	for _, i := range src.Spec.Inbound {
+		dst.Spec.Inbound = append(dst.Spec.Inbound, WireguardClientInbound{
+			Name:               i.Name,
+			Port:               i.Port,
+			ProtonVPNConfigRef: i.ProtonVPNConfigRef,
+			Protocol:           i.Protocol,
+			Service:            WireguardClientInboundService(i.Service),
+		})
+	}
+	dst.Status = WireguardClientStatus(src.Status)
+
+	return nil
+}
+
+func inlineConfigTo(c *WireguardInlineConfig) *corev1beta1.WireguardInlineConfig {
+	if c == nil {
+		return nil
+	}
+
+	dst := &corev1beta1.WireguardInlineConfig{
+		Interface: corev1beta1.WireguardInterface(c.Interface),
+	}
+	for _, p := range c.Peers {
+		dst.Peers = append(dst.Peers, corev1beta1.WireguardInlinePeer(p))
+	}
+
+	return dst
+}
+
+func inlineConfigFrom(c *corev1beta1.WireguardInlineConfig) *WireguardInlineConfig {
+	if c == nil {
+		return nil
+	}
+
+	dst := &WireguardInlineConfig{
+		Interface: WireguardInterface(c.Interface),
+	}
+	for _, p := range c.Peers {
+		dst.Peers = append(dst.Peers, WireguardInlinePeer(p))
+	}
+
+	return dst
+}
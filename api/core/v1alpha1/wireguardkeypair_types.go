@@ -0,0 +1,91 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// WireguardKeyPairPrivateKey is the key the private key is stored under in the keypair secret
+	WireguardKeyPairPrivateKey = "privateKey"
+
+	// WireguardKeyPairPublicKey is the key the public key is stored under in the keypair secret
+	WireguardKeyPairPublicKey = "publicKey"
+)
+
+// WireguardKeyPairSpec defines the desired state of WireguardKeyPair.
+type WireguardKeyPairSpec struct {
+	// How often the keypair is rotated, e.g. "720h". Must be at least 1h.
+	// If not specified the keypair is only rotated on demand.
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('1h')",message="rotationInterval must be at least 1h"
+	// +optional
+	RotationInterval *metav1.Duration `json:"rotationInterval,omitempty"`
+}
+
+// WireguardKeyPairStatus defines the observed state of WireguardKeyPair.
+type WireguardKeyPairStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// The base64 encoded public key of the current keypair
+	// +optional
+	PublicKey string `json:"publicKey,omitempty"`
+
+	// The name of the secret containing the keypair.
+	// The secret has the same name as the WireguardKeyPair.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// When the current keypair was generated
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+
+	// The value of the rotate annotation that was most recently handled
+	// +optional
+	ObservedRotate string `json:"observedRotate,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Public Key",type=string,JSONPath=`.status.publicKey`
+// +kubebuilder:printcolumn:name="Last Rotation",type=date,JSONPath=`.status.lastRotationTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// WireguardKeyPair is the Schema for the wireguardkeypairs API.
+// The operator generates a Curve25519 keypair and stores it in a secret
+// with the same name, under the "privateKey" and "publicKey" keys.
+// An existing secret with that name is only used if the keypair controls it.
+type WireguardKeyPair struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WireguardKeyPairSpec   `json:"spec,omitempty"`
+	Status WireguardKeyPairStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WireguardKeyPairList contains a list of WireguardKeyPair.
+type WireguardKeyPairList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WireguardKeyPair `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WireguardKeyPair{}, &WireguardKeyPairList{})
+}
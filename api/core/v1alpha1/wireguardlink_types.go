@@ -0,0 +1,157 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// WireguardLinkEndpoint is the key the endpoint is stored under in the public link secret
+	WireguardLinkEndpoint = "endpoint"
+
+	// WireguardLinkCIDRs is the key the comma separated local CIDRs are stored under in the public link secret
+	WireguardLinkCIDRs = "cidrs"
+)
+
+// WireguardLinkRemote describes the other side of a WireguardLink
+// +kubebuilder:validation:XValidation:rule="has(self.publicKey) != has(self.publicKeySecretRef)",message="exactly one of publicKey or publicKeySecretRef must be set"
+type WireguardLinkRemote struct {
+	// The host:port of the remote side. It can be left out when the remote side
+	// is the one that dials, e.g. when this side is behind NAT.
+	// +kubebuilder:validation:MaxLength=261
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// The remote side's base64 encoded public key, copied from the status of the remote link
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9+/]{42}[AEIMQUYcgkosw480]=$`
+	// +optional
+	PublicKey string `json:"publicKey,omitempty"`
+
+	// A secret holding the remote side's public key, e.g. the remote link's
+	// public secret synced into this cluster
+	// +optional
+	PublicKeySecretRef *corev1.SecretKeySelector `json:"publicKeySecretRef,omitempty"`
+
+	// The remote cluster's subnets. Routes for them are installed in the tunnel pod.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=64
+	// +kubebuilder:validation:items:MaxLength=64
+	// +kubebuilder:validation:XValidation:rule="self.all(a, isCIDR(a))",message="cidrs must be in CIDR notation"
+	CIDRs []string `json:"cidrs"`
+
+	// Interval in seconds between keepalive packets, 0 disables keepalives
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	PersistentKeepalive *int32 `json:"persistentKeepalive,omitempty"`
+}
+
+// WireguardLinkSpec defines the desired state of WireguardLink.
+type WireguardLinkSpec struct {
+	// This side's tunnel address in CIDR notation, e.g. "10.100.0.1/30".
+	// Both sides of a link should use addresses in the same subnet.
+	// +kubebuilder:validation:MaxLength=64
+	// +kubebuilder:validation:XValidation:rule="isCIDR(self)",message="address must be in CIDR notation"
+	Address string `json:"address"`
+
+	// The UDP port this side listens on
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=51820
+	// +optional
+	ListenPort int32 `json:"listenPort,omitempty"`
+
+	// This cluster's subnets, published for the remote side to route
+	// +kubebuilder:validation:MaxItems=64
+	// +kubebuilder:validation:items:MaxLength=64
+	// +kubebuilder:validation:XValidation:rule="self.all(a, isCIDR(a))",message="localCIDRs must be in CIDR notation"
+	// +optional
+	LocalCIDRs []string `json:"localCIDRs,omitempty"`
+
+	// The other side of the link
+	Remote WireguardLinkRemote `json:"remote"`
+
+	// Expose this side of the link through a Service.
+	// At least one side of a link has to be reachable by the other.
+	// +optional
+	Service *WireguardServerService `json:"service,omitempty"`
+
+	// The host the remote side connects to. Defaults to the load balancer's
+	// address, or the external address of a node for NodePort services.
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// The wireguard image to use
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// +kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+}
+
+// WireguardLinkStatus defines the observed state of WireguardLink.
+type WireguardLinkStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// The base64 encoded public key of this side
+	// +optional
+	PublicKey string `json:"publicKey,omitempty"`
+
+	// The host:port the remote side can connect to, if this side is exposed
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// The name of the secret holding this side's public key, endpoint and CIDRs,
+	// meant to be copied or synced to the remote cluster
+	// +optional
+	PublicSecretName string `json:"publicSecretName,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Address",type=string,JSONPath=`.spec.address`
+// +kubebuilder:printcolumn:name="Remote",type=string,JSONPath=`.spec.remote.endpoint`
+// +kubebuilder:printcolumn:name="Connected",type=string,JSONPath=`.status.conditions[?(@.type=="Connected")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// WireguardLink is the Schema for the wireguardlinks API.
+// It describes one side of a point-to-point link between two clusters,
+// so each cluster has a WireguardLink pointing at the other.
+type WireguardLink struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WireguardLinkSpec   `json:"spec,omitempty"`
+	Status WireguardLinkStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WireguardLinkList contains a list of WireguardLink.
+type WireguardLinkList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WireguardLink `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WireguardLink{}, &WireguardLinkList{})
+}
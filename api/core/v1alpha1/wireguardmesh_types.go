@@ -0,0 +1,142 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WireguardMeshSpec defines the desired state of WireguardMesh.
+type WireguardMeshSpec struct {
+	// The CIDR node addresses are allocated from, e.g. "10.200.0.0/24"
+	// +kubebuilder:validation:MaxLength=64
+	// +kubebuilder:validation:XValidation:rule="isCIDR(self)",message="addressPool must be in CIDR notation"
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="addressPool is immutable"
+	AddressPool string `json:"addressPool"`
+
+	// The UDP port the agents listen on, on each node's host network
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=51821
+	// +optional
+	ListenPort int32 `json:"listenPort,omitempty"`
+
+	// The name of the interface the agents create on each node.
+	// Meshes sharing nodes need distinct interfaces and listen ports.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_=+.-]{1,15}$`
+	// +kubebuilder:default=wgmesh0
+	// +optional
+	Interface string `json:"interface,omitempty"`
+
+	// Selects the nodes that join the mesh. All nodes join if not specified.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the agent pods, e.g. to join control plane nodes
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Also route each node's pod CIDRs through the mesh. This only works with
+	// CNIs that route pod traffic through the host, such as host-gw or kubenet.
+	// +optional
+	RoutePodCIDRs bool `json:"routePodCIDRs,omitempty"`
+
+	// Interval in seconds between keepalive packets, 0 disables keepalives
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	PersistentKeepalive *int32 `json:"persistentKeepalive,omitempty"`
+
+	// The namespace the agents run in
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:default=kube-system
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="agentNamespace is immutable"
+	// +optional
+	AgentNamespace string `json:"agentNamespace,omitempty"`
+
+	// The wireguard image the agents run
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// +kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+}
+
+// WireguardMeshNode is a node that joined a WireguardMesh
+type WireguardMeshNode struct {
+	// The name of the node
+	Name string `json:"name"`
+
+	// The mesh address allocated to the node
+	Address string `json:"address"`
+
+	// The base64 encoded public key the node's agent published
+	// +optional
+	PublicKey string `json:"publicKey,omitempty"`
+
+	// The host:port the node's agent listens on
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// WireguardMeshStatus defines the observed state of WireguardMesh.
+type WireguardMeshStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// The nodes in the mesh. Nodes are allocated an address when selected,
+	// and become peers once their agent has published a public key.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Nodes []WireguardMeshNode `json:"nodes,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Pool",type=string,JSONPath=`.spec.addressPool`
+// +kubebuilder:printcolumn:name="Available",type=string,JSONPath=`.status.conditions[?(@.type=="Available")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// WireguardMesh is the Schema for the wireguardmeshes API.
+// It runs an agent on each selected node that connects the node to every other
+// node in the mesh. Agents generate their keys on the node and publish the public
+// key and endpoint in a "<mesh>-node-<node>" config map in the agent namespace, so
+// private keys never leave the node. An admission policy limits each agent to the
+// config map of its own node, which needs Kubernetes 1.30 or later.
+type WireguardMesh struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WireguardMeshSpec   `json:"spec,omitempty"`
+	Status WireguardMeshStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WireguardMeshList contains a list of WireguardMesh.
+type WireguardMeshList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WireguardMesh `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WireguardMesh{}, &WireguardMeshList{})
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WireguardPeerSpec defines the desired state of WireguardPeer.
+type WireguardPeerSpec struct {
+	// The WireguardServer in the same namespace the peer connects to
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="serverRef is immutable"
+	ServerRef corev1.LocalObjectReference `json:"serverRef"`
+
+	// The peer's base64 encoded public key. If not specified the operator generates
+	// a keypair, otherwise the rendered config leaves out the private key.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9+/]{42}[AEIMQUYcgkosw480]=$`
+	// +optional
+	PublicKey string `json:"publicKey,omitempty"`
+
+	// A specific address to request from the server's pool.
+	// If not specified the next free address is allocated.
+	// +kubebuilder:validation:MaxLength=64
+	// +kubebuilder:validation:XValidation:rule="isIP(self)",message="address must be an IP address"
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="address is immutable"
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// The IPs the peer routes through the tunnel
+	// +kubebuilder:validation:MaxItems=64
+	// +kubebuilder:validation:items:MaxLength=64
+	// +kubebuilder:validation:XValidation:rule="self.all(a, isCIDR(a))",message="allowedIPs must be in CIDR notation"
+	// +kubebuilder:default={"0.0.0.0/0"}
+	// +optional
+	AllowedIPs []string `json:"allowedIPs,omitempty"`
+
+	// Interval in seconds between keepalive packets, 0 disables keepalives
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	PersistentKeepalive *int32 `json:"persistentKeepalive,omitempty"`
+}
+
+// WireguardPeerStatus defines the observed state of WireguardPeer.
+type WireguardPeerStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// The tunnel address allocated to the peer
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// The base64 encoded public key of the peer
+	// +optional
+	PublicKey string `json:"publicKey,omitempty"`
+
+	// The name of the secret holding the peer's config under the "wg0.conf" key.
+	// The secret has the same name as the WireguardPeer.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Server",type=string,JSONPath=`.spec.serverRef.name`
+// +kubebuilder:printcolumn:name="Address",type=string,JSONPath=`.status.address`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// WireguardPeer is the Schema for the wireguardpeers API.
+// The operator allocates the peer an address from its server's pool, adds it
+// to the server, and renders a config the peer can import.
+type WireguardPeer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WireguardPeerSpec   `json:"spec,omitempty"`
+	Status WireguardPeerStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WireguardPeerList contains a list of WireguardPeer.
+type WireguardPeerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WireguardPeer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WireguardPeer{}, &WireguardPeerList{})
+}
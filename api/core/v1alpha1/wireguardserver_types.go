@@ -0,0 +1,149 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WireguardServerService configures the Service peers connect through
+// +kubebuilder:validation:XValidation:rule="!has(self.nodePort) || self.type == 'NodePort'",message="nodePort requires a NodePort service"
+type WireguardServerService struct {
+	// The type of the service
+	// +kubebuilder:validation:Enum=LoadBalancer;NodePort
+	// +kubebuilder:default=LoadBalancer
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// The node port to listen on, allocated by the cluster if not specified
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	NodePort int32 `json:"nodePort,omitempty"`
+
+	// Annotations added to the service, e.g. to configure the load balancer
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// WireguardServerSpec defines the desired state of WireguardServer.
+type WireguardServerSpec struct {
+	// The UDP port the server listens on
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=51820
+	// +optional
+	ListenPort int32 `json:"listenPort,omitempty"`
+
+	// The CIDR tunnel addresses are allocated from, e.g. "10.13.13.0/24".
+	// The server takes the first address of the pool.
+	// +kubebuilder:validation:MaxLength=64
+	// +kubebuilder:validation:XValidation:rule="isCIDR(self)",message="addressPool must be in CIDR notation"
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="addressPool is immutable"
+	AddressPool string `json:"addressPool"`
+
+	// DNS servers written to the configs of the server's peers
+	// +kubebuilder:validation:MaxItems=8
+	// +kubebuilder:validation:items:MaxLength=64
+	// +kubebuilder:validation:XValidation:rule="self.all(a, isIP(a))",message="dns must be IP addresses"
+	// +optional
+	DNS []string `json:"dns,omitempty"`
+
+	// The host peers connect to. Defaults to the load balancer's address,
+	// or the external address of a node for NodePort services.
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// The Service the server is exposed through
+	// +kubebuilder:default={}
+	// +optional
+	Service WireguardServerService `json:"service,omitempty"`
+
+	// The wireguard image to use
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// +kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+}
+
+// WireguardServerAllocation records a tunnel address allocated to a WireguardPeer
+type WireguardServerAllocation struct {
+	// The allocated address
+	Address string `json:"address"`
+
+	// The name of the WireguardPeer the address is allocated to
+	Peer string `json:"peer"`
+}
+
+// WireguardServerStatus defines the observed state of WireguardServer.
+type WireguardServerStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// The base64 encoded public key of the server
+	// +optional
+	PublicKey string `json:"publicKey,omitempty"`
+
+	// The host:port peers connect to
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// The tunnel address of the server
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// The addresses allocated to the server's peers. Peers allocate addresses by
+	// updating this list, so concurrent allocations conflict instead of overlapping.
+	// +listType=map
+	// +listMapKey=address
+	// +optional
+	Allocations []WireguardServerAllocation `json:"allocations,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.status.endpoint`
+// +kubebuilder:printcolumn:name="Address",type=string,JSONPath=`.status.address`
+// +kubebuilder:printcolumn:name="Available",type=string,JSONPath=`.status.conditions[?(@.type=="Available")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// WireguardServer is the Schema for the wireguardservers API.
+// The operator generates the server's keys and runs it as a Deployment,
+// exposed through a LoadBalancer or NodePort Service.
+type WireguardServer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WireguardServerSpec   `json:"spec,omitempty"`
+	Status WireguardServerStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WireguardServerList contains a list of WireguardServer.
+type WireguardServerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WireguardServer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WireguardServer{}, &WireguardServerList{})
+}
@@ -0,0 +1,417 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WireguardClientConfigSource defines an external source for
+// the WireguardClientConfig
+type WireguardClientConfigSource struct {
+	// A reference to a config map key that contains a wireguard client configuration
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+
+	// A reference to a secret key that contains a wireguard client configuration
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// WireguardInterface defines the [Interface] section of an inline configuration
+// +kubebuilder:validation:XValidation:rule="has(self.privateKeySecretRef) != has(self.keyPairRef)",message="exactly one of privateKeySecretRef or keyPairRef must be set"
+type WireguardInterface struct {
+	// The addresses assigned to the interface, in CIDR notation
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:items:MaxLength=64
+	// +kubebuilder:validation:XValidation:rule="self.all(a, isCIDR(a))",message="addresses must be in CIDR notation"
+	Address []string `json:"address"`
+
+	// DNS servers to configure while the interface is up
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:items:MaxLength=64
+	// +kubebuilder:validation:XValidation:rule="self.all(a, isIP(a))",message="dns servers must be IP addresses"
+	// +optional
+	DNS []string `json:"dns,omitempty"`
+
+	// The MTU of the interface
+	// +kubebuilder:validation:Minimum=1280
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	MTU *int32 `json:"mtu,omitempty"`
+
+	// A reference to a secret key that contains the interface's private key
+	// +optional
+	PrivateKeySecretRef *corev1.SecretKeySelector `json:"privateKeySecretRef,omitempty"`
+
+	// A reference to a WireguardKeyPair whose private key is used for the interface
+	// +optional
+	KeyPairRef *corev1.LocalObjectReference `json:"keyPairRef,omitempty"`
+}
+
+// WireguardInlinePeer defines a [Peer] section of an inline configuration
+// +kubebuilder:validation:XValidation:rule="!(has(self.presharedKeySecretRef) && has(self.generatePresharedKey) && self.generatePresharedKey)",message="presharedKeySecretRef and generatePresharedKey are mutually exclusive"
+type WireguardInlinePeer struct {
+	// The peer's base64 encoded public key
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9+/]{42}[AEIMQUYcgkosw480]=$`
+	PublicKey string `json:"publicKey"`
+
+	// The peer's endpoint as host:port
+	// +kubebuilder:validation:MaxLength=261
+	// +kubebuilder:validation:Pattern=`^.+:[0-9]{1,5}$`
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// The IPs routed to the peer, in CIDR notation
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=64
+	// +kubebuilder:validation:items:MaxLength=64
+	// +kubebuilder:validation:XValidation:rule="self.all(a, isCIDR(a))",message="allowedIPs must be in CIDR notation"
+	AllowedIPs []string `json:"allowedIPs"`
+
+	// Interval in seconds between keepalive packets, 0 disables keepalives
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	PersistentKeepalive *int32 `json:"persistentKeepalive,omitempty"`
+
+	// A reference to a secret key that contains the peer's preshared key
+	// +optional
+	PresharedKeySecretRef *corev1.SecretKeySelector `json:"presharedKeySecretRef,omitempty"`
+
+	// Generate a preshared key for the peer. Generated keys are stored in the
+	// "<client>-<config>-psk-<hash>" secret so they can be shared with the peer,
+	// where <hash> is derived from the client and config names. They are rotated
+	// when the core.thecluster.io/rotate annotation changes.
+	// +optional
+	GeneratePresharedKey bool `json:"generatePresharedKey,omitempty"`
+}
+
+// WireguardInlineConfig is a structured wireguard configuration.
+// The operator renders it into a Secret owned by the WireguardClient.
+type WireguardInlineConfig struct {
+	// The local interface
+	Interface WireguardInterface `json:"interface"`
+
+	// The peers of the interface
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=16
+	Peers []WireguardInlinePeer `json:"peers"`
+}
+
+// WireguardClientConfig defines a wireguard configuration file to be
+// mounted in the /config directory of the container
+// +kubebuilder:validation:XValidation:rule="has(self.valueFrom) != has(self.inline)",message="exactly one of valueFrom or inline must be set"
+type WireguardClientConfig struct {
+	// The name of the configuration, used as the configuration file name
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// An external source for the client configuration values
+	// +optional
+	ValueFrom *WireguardClientConfigSource `json:"valueFrom,omitempty"`
+
+	// A structured configuration rendered by the operator
+	// +optional
+	Inline *WireguardInlineConfig `json:"inline,omitempty"`
+}
+
+// WireguardClientGateway configures a WireguardClient as an egress gateway for the
+// pods of other namespaces, over a VXLAN overlay like [pod-gateway].
+//
+// [pod-gateway]: https://github.com/angelnu/pod-gateway
+type WireguardClientGateway struct {
+	// The namespaces whose pods may be routed through the gateway. An empty selector
+	// selects no namespaces. A selected namespace is only routed when it opts in to
+	// the pod webhook with the core.thecluster.io/wireguard-injection=enabled label
+	// and names the gateway in its core.thecluster.io/wireguard-gateway annotation,
+	// as "<namespace>/<name>".
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+
+	// CIDRs that stay local instead of going through the gateway,
+	// e.g. the pod and service CIDRs of the cluster
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=32
+	// +kubebuilder:validation:items:MaxLength=64
+	// +kubebuilder:validation:XValidation:rule="self.all(a, isCIDR(a))",message="localCIDRs must be in CIDR notation"
+	LocalCIDRs []string `json:"localCIDRs"`
+
+	// The pod-gateway image. If not specified the operator's default image is used.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// The VXLAN ID of the overlay
+	// +kubebuilder:default=42
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=16777215
+	// +optional
+	VXLANID int32 `json:"vxlanID,omitempty"`
+
+	// The /24 network of the overlay, as its first three octets.
+	// It must not overlap the networks of the cluster.
+	// +kubebuilder:default="172.16.0"
+	// +kubebuilder:validation:Pattern=`^[0-9]{1,3}\.[0-9]{1,3}\.[0-9]{1,3}$`
+	// +optional
+	VXLANNetwork string `json:"vxlanNetwork,omitempty"`
+
+	// The DNS domain of the cluster, used by routed pods to resolve the gateway
+	// +kubebuilder:default="cluster.local"
+	// +optional
+	ClusterDomain string `json:"clusterDomain,omitempty"`
+}
+
+// WireguardClientProxy configures a proxy that sends its traffic through the tunnel
+type WireguardClientProxy struct {
+	// The proxy image, it must be compatible with [gost] v3.
+	// If not specified the operator's default image is used.
+	//
+	// [gost]: https://gost.run
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// The port of the SOCKS5 proxy
+	// +kubebuilder:default=1080
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	SOCKSPort int32 `json:"socksPort,omitempty"`
+
+	// The port of the HTTP CONNECT proxy
+	// +kubebuilder:default=8080
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	HTTPPort int32 `json:"httpPort,omitempty"`
+
+	// A reference to a kubernetes.io/basic-auth secret with the "username" and
+	// "password" proxy clients must authenticate with. The values are used in
+	// URLs, so they must not contain characters that need escaping.
+	// +optional
+	AuthSecretRef *corev1.LocalObjectReference `json:"authSecretRef,omitempty"`
+}
+
+// WireguardClientInboundService is the service inbound traffic is forwarded to
+type WireguardClientInboundService struct {
+	// The name of the service, in the namespace of the WireguardClient
+	Name string `json:"name"`
+
+	// The port of the service
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+}
+
+// WireguardClientInbound forwards a port of the tunnel to a service in the cluster
+// +kubebuilder:validation:XValidation:rule="has(self.port) != has(self.protonVPNConfigRef)",message="exactly one of port or protonVPNConfigRef must be set"
+type WireguardClientInbound struct {
+	// The name of the rule
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// The port of the tunnel to forward
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// A ProtonVPNConfig whose forwarded port is forwarded. The rule follows the
+	// port as the gateway assigns new ones. A NAT-PMP sidecar in the tunnel pod
+	// requests and renews the port, and reports it in the status of the config.
+	// +optional
+	ProtonVPNConfigRef *corev1.LocalObjectReference `json:"protonVPNConfigRef,omitempty"`
+
+	// The protocol to forward
+	// +kubebuilder:validation:Enum=TCP;UDP
+	// +kubebuilder:default=TCP
+	// +optional
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+
+	// The service the port is forwarded to
+	Service WireguardClientInboundService `json:"service"`
+}
+
+// WireguardClientTunnel defines the tunnel of a WireguardClient
+type WireguardClientTunnel struct {
+	// Wireguard client configurations to mount in the container.
+	// Config names must be unique, they are used as volume names.
+	// A WireGuard peer is identified by its key, so only one tunnel can use a
+	// config at a time. Pods injected with the core.thecluster.io/wireguard-client
+	// annotation connect with these configs instead of the client's own pod, see
+	// sidecarOnly. The server only routes to whichever connected most recently,
+	// so only one injected pod should run at a time.
+	// +kubebuilder:validation:MinItems=1
+	Configs []WireguardClientConfig `json:"configs"`
+
+	// The IPs/Ranges that the peers will be able to reach using the VPN connection.
+	// If not specified the default value is: '0.0.0.0/0, ::0/0'
+	// This will cause ALL traffic to route through the VPN, if you want split tunneling,
+	// set this to only the IPs you would like to use the tunnel AND the ip of the server's WG ip, such as 10.13.13.1/32.
+	// Entries must be in CIDR notation.
+	// +optional
+	AllowedIPs []string `json:"allowedIPs,omitempty"`
+}
+
+// WireguardClientRuntime defines the container the tunnel runs in
+type WireguardClientRuntime struct {
+	// For UserID, see the [linuxserver explanation]
+	//
+	// [linuxserver explanation]: https://github.com/linuxserver/docker-wireguard#user--group-identifiers
+	// +kubebuilder:validation:Minimum=0
+	PUID int64 `json:"puid"`
+
+	// For GroupID, see the [linuxserver explanation]
+	//
+	// [linuxserver explanation]: https://github.com/linuxserver/docker-wireguard#user--group-identifiers
+	// +kubebuilder:validation:Minimum=0
+	PGID int64 `json:"pgid"`
+
+	// TZ specifies a timezone to use, see this [list of time zones].
+	// Defaults to Etc/UTC.
+	//
+	// [list of time zones]: https://en.wikipedia.org/wiki/List_of_tz_database_time_zones#List
+	// +optional
+	TZ string `json:"tz,omitempty"`
+
+	// Generated QR codes will be displayed in the docker log.
+	// Set to false to skip log output. Defaults to false for new clients,
+	// since the QR codes contain the private keys of the configs.
+	// +optional
+	LogConfs *bool `json:"logConfs,omitempty"`
+
+	// Run container with a read-only filesystem. More info in the linuxserver.io [docs]
+	//
+	// [docs]: https://docs.linuxserver.io/misc/read-only/
+	//
+	// +optional
+	ReadOnly *bool `json:"readOnly,omitempty"`
+
+	// The wireguard container image. Pin a tag or digest to make upgrades deliberate.
+	// If not specified the operator's default image is used.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// The pull policy of the wireguard image
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// Secrets used to pull the wireguard image
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// Overrides for the generated pod template, strategic-merged onto it.
+	// Use this to set resources, node placement, annotations, or extra env and volumes.
+	// The wireguard container is named "wireguard". Selector labels can't be overridden.
+	//
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	PodTemplate *corev1.PodTemplateSpec `json:"podTemplate,omitempty"`
+}
+
+// WireguardClientSpec defines the desired state of WireguardClient
+// +kubebuilder:validation:XValidation:rule="!has(self.sidecarOnly) || !self.sidecarOnly || (!has(self.gateway) && !has(self.proxy) && !has(self.inbound))",message="gateway, proxy and inbound can't be used with sidecarOnly"
+type WireguardClientSpec struct {
+	// The tunnel configs and the routes through them
+	Tunnel WireguardClientTunnel `json:"tunnel"`
+
+	// The container the tunnel runs in
+	Runtime WireguardClientRuntime `json:"runtime"`
+
+	// Don't run the client's own pod, the configs are only used by sidecars
+	// injected with the core.thecluster.io/wireguard-client annotation. Injection
+	// is refused for clients that run their own pod, since both would connect
+	// with the same keys. Gateway, proxy and inbound need the client's own pod.
+	// +optional
+	SidecarOnly bool `json:"sidecarOnly,omitempty"`
+
+	// Route the pods of other namespaces through the tunnel
+	// +optional
+	Gateway *WireguardClientGateway `json:"gateway,omitempty"`
+
+	// Expose the tunnel as a SOCKS5 and HTTP proxy service named "<client>-proxy".
+	// The configs' AllowedIPs should exclude the cluster's CIDRs, so that replies
+	// to proxy clients aren't sent through the tunnel.
+	// +optional
+	Proxy *WireguardClientProxy `json:"proxy,omitempty"`
+
+	// Forward ports of the tunnel to services in the cluster, so that workloads
+	// behind the VPN can accept connections
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=32
+	// +optional
+	Inbound []WireguardClientInbound `json:"inbound,omitempty"`
+}
+
+// WireguardClientStatus defines the observed state of WireguardClient
+type WireguardClientStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// The generation of the spec most recently reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The names of the client pods that are ready
+	// +optional
+	ReadyPods []string `json:"readyPods,omitempty"`
+
+	// The most recent reason a client pod failed, e.g. ImagePullBackOff or CrashLoopBackOff
+	// +optional
+	LastFailureReason string `json:"lastFailureReason,omitempty"`
+
+	// The resolved image, including its digest, the wireguard container is running
+	// +optional
+	ImageID string `json:"imageID,omitempty"`
+
+	// The value of the rotate annotation that was most recently handled
+	// +optional
+	ObservedRotate string `json:"observedRotate,omitempty"`
+
+	// The inbound rules currently forwarded, as "<name>: <protocol>/<port> -> <service>:<port>"
+	// +optional
+	Inbound []string `json:"inbound,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+
+// WireguardClient is the Schema for the wireguardclients API.
+type WireguardClient struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WireguardClientSpec   `json:"spec,omitempty"`
+	Status WireguardClientStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WireguardClientList contains a list of WireguardClient.
+type WireguardClientList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WireguardClient `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WireguardClient{}, &WireguardClientList{})
+}
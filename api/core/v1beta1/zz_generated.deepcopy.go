@@ -0,0 +1,434 @@
+//go:build !ignore_autogenerated
+
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardClient) DeepCopyInto(out *WireguardClient) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardClient.
+func (in *WireguardClient) DeepCopy() *WireguardClient {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardClient)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WireguardClient) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardClientConfig) DeepCopyInto(out *WireguardClientConfig) {
+	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		*out = new(WireguardClientConfigSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Inline != nil {
+		in, out := &in.Inline, &out.Inline
+		*out = new(WireguardInlineConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardClientConfig.
+func (in *WireguardClientConfig) DeepCopy() *WireguardClientConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardClientConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardClientConfigSource) DeepCopyInto(out *WireguardClientConfigSource) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardClientConfigSource.
+func (in *WireguardClientConfigSource) DeepCopy() *WireguardClientConfigSource {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardClientConfigSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardClientGateway) DeepCopyInto(out *WireguardClientGateway) {
+	*out = *in
+	in.NamespaceSelector.DeepCopyInto(&out.NamespaceSelector)
+	if in.LocalCIDRs != nil {
+		in, out := &in.LocalCIDRs, &out.LocalCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardClientGateway.
+func (in *WireguardClientGateway) DeepCopy() *WireguardClientGateway {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardClientGateway)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardClientInbound) DeepCopyInto(out *WireguardClientInbound) {
+	*out = *in
+	if in.ProtonVPNConfigRef != nil {
+		in, out := &in.ProtonVPNConfigRef, &out.ProtonVPNConfigRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	out.Service = in.Service
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardClientInbound.
+func (in *WireguardClientInbound) DeepCopy() *WireguardClientInbound {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardClientInbound)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardClientInboundService) DeepCopyInto(out *WireguardClientInboundService) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardClientInboundService.
+func (in *WireguardClientInboundService) DeepCopy() *WireguardClientInboundService {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardClientInboundService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardClientList) DeepCopyInto(out *WireguardClientList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WireguardClient, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardClientList.
+func (in *WireguardClientList) DeepCopy() *WireguardClientList {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardClientList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WireguardClientList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardClientProxy) DeepCopyInto(out *WireguardClientProxy) {
+	*out = *in
+	if in.AuthSecretRef != nil {
+		in, out := &in.AuthSecretRef, &out.AuthSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardClientProxy.
+func (in *WireguardClientProxy) DeepCopy() *WireguardClientProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardClientProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardClientRuntime) DeepCopyInto(out *WireguardClientRuntime) {
+	*out = *in
+	if in.LogConfs != nil {
+		in, out := &in.LogConfs, &out.LogConfs
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ReadOnly != nil {
+		in, out := &in.ReadOnly, &out.ReadOnly
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(v1.PodTemplateSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardClientRuntime.
+func (in *WireguardClientRuntime) DeepCopy() *WireguardClientRuntime {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardClientRuntime)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardClientSpec) DeepCopyInto(out *WireguardClientSpec) {
+	*out = *in
+	in.Tunnel.DeepCopyInto(&out.Tunnel)
+	in.Runtime.DeepCopyInto(&out.Runtime)
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(WireguardClientGateway)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(WireguardClientProxy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Inbound != nil {
+		in, out := &in.Inbound, &out.Inbound
+		*out = make([]WireguardClientInbound, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardClientSpec.
+func (in *WireguardClientSpec) DeepCopy() *WireguardClientSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardClientSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardClientStatus) DeepCopyInto(out *WireguardClientStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReadyPods != nil {
+		in, out := &in.ReadyPods, &out.ReadyPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Inbound != nil {
+		in, out := &in.Inbound, &out.Inbound
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardClientStatus.
+func (in *WireguardClientStatus) DeepCopy() *WireguardClientStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardClientStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardClientTunnel) DeepCopyInto(out *WireguardClientTunnel) {
+	*out = *in
+	if in.Configs != nil {
+		in, out := &in.Configs, &out.Configs
+		*out = make([]WireguardClientConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllowedIPs != nil {
+		in, out := &in.AllowedIPs, &out.AllowedIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardClientTunnel.
+func (in *WireguardClientTunnel) DeepCopy() *WireguardClientTunnel {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardClientTunnel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardInlineConfig) DeepCopyInto(out *WireguardInlineConfig) {
+	*out = *in
+	in.Interface.DeepCopyInto(&out.Interface)
+	if in.Peers != nil {
+		in, out := &in.Peers, &out.Peers
+		*out = make([]WireguardInlinePeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardInlineConfig.
+func (in *WireguardInlineConfig) DeepCopy() *WireguardInlineConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardInlineConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardInlinePeer) DeepCopyInto(out *WireguardInlinePeer) {
+	*out = *in
+	if in.AllowedIPs != nil {
+		in, out := &in.AllowedIPs, &out.AllowedIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PersistentKeepalive != nil {
+		in, out := &in.PersistentKeepalive, &out.PersistentKeepalive
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PresharedKeySecretRef != nil {
+		in, out := &in.PresharedKeySecretRef, &out.PresharedKeySecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardInlinePeer.
+func (in *WireguardInlinePeer) DeepCopy() *WireguardInlinePeer {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardInlinePeer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WireguardInterface) DeepCopyInto(out *WireguardInterface) {
+	*out = *in
+	if in.Address != nil {
+		in, out := &in.Address, &out.Address
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MTU != nil {
+		in, out := &in.MTU, &out.MTU
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PrivateKeySecretRef != nil {
+		in, out := &in.PrivateKeySecretRef, &out.PrivateKeySecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KeyPairRef != nil {
+		in, out := &in.KeyPairRef, &out.KeyPairRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardInterface.
+func (in *WireguardInterface) DeepCopy() *WireguardInterface {
+	if in == nil {
+		return nil
+	}
+	out := new(WireguardInterface)
+	in.DeepCopyInto(out)
+	return out
+}
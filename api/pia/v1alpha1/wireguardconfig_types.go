@@ -31,17 +31,43 @@ type WireguardClientConfigValue struct {
 type WireguardConfigSpec struct {
 	Username WireguardClientConfigValue `json:"username"`
 	Password WireguardClientConfigValue `json:"password"`
+
+	// A reference to a config map key containing the PEM encoded PIA certificate authority.
+	// WireGuard servers are only trusted when their certificate is signed by this CA
+	// and issued for the server's common name. Defaults to PIA's ca.rsa.4096.crt,
+	// which is bundled with the operator.
+	// +optional
+	CACert *corev1.ConfigMapKeySelector `json:"caCert,omitempty"`
+
+	// The PIA region id to generate a config for, e.g. "us_chicago".
+	// If not specified the first available region is used.
+	// +optional
+	Region string `json:"region,omitempty"`
 }
 
 // WireguardConfigStatus defines the observed state of WireguardConfig.
 type WireguardConfigStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// The value of the regenerate annotation that was most recently handled
+	// +optional
+	ObservedRegenerate string `json:"observedRegenerate,omitempty"`
+
+	// The name of the secret containing the generated config
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// The common name of the server the config was generated for
+	// +optional
+	Server string `json:"server,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 
 // WireguardConfig is the Schema for the wireguardconfigs API.
+// The generated config is written to the wg0.conf key of a secret with the same name.
+// Config maps with the same name are no longer read or written.
 type WireguardConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
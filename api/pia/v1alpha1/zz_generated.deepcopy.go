@@ -115,6 +115,11 @@ func (in *WireguardConfigSpec) DeepCopyInto(out *WireguardConfigSpec) {
 	*out = *in
 	in.Username.DeepCopyInto(&out.Username)
 	in.Password.DeepCopyInto(&out.Password)
+	if in.CACert != nil {
+		in, out := &in.CACert, &out.CACert
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WireguardConfigSpec.
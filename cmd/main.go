@@ -26,21 +26,35 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
 
 	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	corev1beta1 "github.com/unmango/thecluster-operator/api/core/v1beta1"
+	mullvadv1alpha1 "github.com/unmango/thecluster-operator/api/mullvad/v1alpha1"
 	piav1alpha1 "github.com/unmango/thecluster-operator/api/pia/v1alpha1"
 	corecontroller "github.com/unmango/thecluster-operator/internal/controller/core"
+	mullvadcontroller "github.com/unmango/thecluster-operator/internal/controller/mullvad"
 	piacontroller "github.com/unmango/thecluster-operator/internal/controller/pia"
+	"github.com/unmango/thecluster-operator/internal/storageversion"
+	webhookcorev1 "github.com/unmango/thecluster-operator/internal/webhook/core/v1"
+	webhookcorev1alpha1 "github.com/unmango/thecluster-operator/internal/webhook/core/v1alpha1"
+	webhookcorev1beta1 "github.com/unmango/thecluster-operator/internal/webhook/core/v1beta1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -54,6 +68,9 @@ func init() {
 
 	utilruntime.Must(corev1alpha1.AddToScheme(scheme))
 	utilruntime.Must(piav1alpha1.AddToScheme(scheme))
+	utilruntime.Must(mullvadv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(corev1beta1.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -66,6 +83,8 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var wireguardImage string
+	var natpmpImage string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -84,6 +103,10 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&wireguardImage, "wireguard-image", corecontroller.DefaultWireguardImage,
+		"The wireguard image used by WireguardClients that don't specify one.")
+	flag.StringVar(&natpmpImage, "natpmp-image", os.Getenv("NATPMP_IMAGE"),
+		"The image of the NAT-PMP sidecar that forwards ProtonVPN ports, usually the operator's own image.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -188,6 +211,7 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "81c89c94.thecluster.io",
+		Cache:                  cacheOptions(),
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -206,19 +230,116 @@ func main() {
 	}
 
 	if err = (&corecontroller.WireguardClientReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		DefaultImage: wireguardImage,
+		NATPMPImage:  natpmpImage,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "WireguardClient")
 		os.Exit(1)
 	}
 	if err = (&piacontroller.WireguardConfigReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		APIReader: mgr.GetAPIReader(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "WireguardConfig")
+		os.Exit(1)
+	}
+	if err = (&mullvadcontroller.WireguardConfigReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "WireguardConfig")
 		os.Exit(1)
 	}
+	if err = (&corecontroller.ProtonVPNConfigReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ProtonVPNConfig")
+		os.Exit(1)
+	}
+	if err = (&corecontroller.GenericWireguardConfigReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GenericWireguardConfig")
+		os.Exit(1)
+	}
+	if err = (&corecontroller.WireguardKeyPairReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "WireguardKeyPair")
+		os.Exit(1)
+	}
+	if err = (&corecontroller.WireguardServerReconciler{
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		DefaultImage: wireguardImage,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "WireguardServer")
+		os.Exit(1)
+	}
+	if err = (&corecontroller.WireguardPeerReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "WireguardPeer")
+		os.Exit(1)
+	}
+	if err = (&corecontroller.WireguardLinkReconciler{
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		DefaultImage: wireguardImage,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "WireguardLink")
+		os.Exit(1)
+	}
+	if err = (&corecontroller.WireguardMeshReconciler{
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		DefaultImage: wireguardImage,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "WireguardMesh")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookcorev1.SetupPodWebhookWithManager(mgr, wireguardImage); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Pod")
+			os.Exit(1)
+		}
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookcorev1alpha1.SetupWireguardClientWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "WireguardClient")
+			os.Exit(1)
+		}
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookcorev1beta1.SetupWireguardClientWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "WireguardClient")
+			os.Exit(1)
+		}
+	} else {
+		// The CRD serves v1beta1 through the conversion webhook, so it's needed
+		// even when the admission webhooks are disabled
+		mgr.GetWebhookServer().Register("/convert", conversion.NewWebhookHandler(mgr.GetScheme()))
+	}
+
+	// Rewriting v1alpha1 objects as v1beta1 needs the conversion webhook
+	if err = mgr.Add(&storageversion.Migrator{
+		Client: mgr.GetClient(),
+		Reader: mgr.GetAPIReader(),
+		CRD:    "wireguardclients.core.thecluster.io",
+	}); err != nil {
+		setupLog.Error(err, "unable to add storage version migrator", "crd", "wireguardclients.core.thecluster.io")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -252,3 +373,16 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// cacheOptions restricts the cached pods to those of WireguardClients,
+// the only pods the controllers watch. Other kinds are cached in full.
+func cacheOptions() cache.Options {
+	hasClient, err := labels.NewRequirement(corecontroller.WireguardClientUIDLabel, selection.Exists, nil)
+	utilruntime.Must(err)
+
+	return cache.Options{
+		ByObject: map[client.Object]cache.ByObject{
+			&corev1.Pod{}: {Label: labels.NewSelector().Add(*hasClient)},
+		},
+	}
+}
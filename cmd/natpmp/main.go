@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command natpmp keeps the ports of ProtonVPNConfigs forwarded. It runs as a sidecar
+// of the WireguardClient whose inbound rules follow the configs, so that it shares the
+// tunnel the NAT-PMP gateway is reachable through, and reports the forwarded ports in
+// the status of the configs.
+//
+//	natpmp --namespace default my-config [other-config...]
+package main
+
+import (
+	"flag"
+	"os"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/portforward"
+)
+
+var (
+	scheme   = runtime.NewScheme()
+	setupLog = ctrl.Log.WithName("setup")
+)
+
+func init() {
+	utilruntime.Must(corev1alpha1.AddToScheme(scheme))
+}
+
+func main() {
+	var namespace string
+	flag.StringVar(&namespace, "namespace", os.Getenv("NAMESPACE"), "The namespace of the ProtonVPNConfigs.")
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	if namespace == "" || flag.NArg() == 0 {
+		setupLog.Info("usage: natpmp --namespace NAMESPACE CONFIG...")
+		os.Exit(2)
+	}
+
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
+		os.Exit(1)
+	}
+
+	ctx := ctrl.SetupSignalHandler()
+	wg := sync.WaitGroup{}
+	for _, name := range flag.Args() {
+		forwarder := &portforward.Forwarder{
+			Client: c,
+			Key:    client.ObjectKey{Namespace: namespace, Name: name},
+		}
+
+		setupLog.Info("forwarding port", "protonvpnconfig", forwarder.Key)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = forwarder.Start(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
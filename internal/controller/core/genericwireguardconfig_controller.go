@@ -0,0 +1,126 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	"github.com/unmango/thecluster-operator/internal/generic"
+	"github.com/unmango/thecluster-operator/internal/provider"
+)
+
+var (
+	TypeAvailableGenericWireguardConfig = vpn.TypeAvailable
+	TypeErrorGenericWireguardConfig     = vpn.TypeError
+	GenericRegenerateAnnotation         = "core.thecluster.io/regenerate"
+)
+
+// GenericWireguardConfigReconciler reconciles a GenericWireguardConfig object
+type GenericWireguardConfigReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=genericwireguardconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=genericwireguardconfigs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=genericwireguardconfigs/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core,resources=configmaps;secrets,verbs=get;list;watch;create;update;patch;delete
+
+func (r *GenericWireguardConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	wg := &corev1alpha1.GenericWireguardConfig{}
+	if err := r.Get(ctx, req.NamespacedName, wg); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	configReconciler := &vpn.ConfigReconciler{
+		Client:               r.Client,
+		Scheme:               r.Scheme,
+		RegenerateAnnotation: GenericRegenerateAnnotation,
+	}
+
+	return configReconciler.Reconcile(ctx, wg,
+		vpn.Status{
+			Conditions:         &wg.Status.Conditions,
+			ObservedRegenerate: &wg.Status.ObservedRegenerate,
+			SecretName:         &wg.Status.SecretName,
+			Server:             &wg.Status.Server,
+		},
+		func(ctx context.Context) (*provider.Result, error) {
+			return r.generate(ctx, wg)
+		},
+	)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GenericWireguardConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1alpha1.GenericWireguardConfig{}).
+		Named("core-genericwireguardconfig").
+		Owns(&corev1.Secret{}).
+		Complete(r)
+}
+
+func (r *GenericWireguardConfigReconciler) generate(ctx context.Context, c *corev1alpha1.GenericWireguardConfig) (*provider.Result, error) {
+	token, err := vpn.ResolveValue(ctx, r, c.Namespace, "", nil, c.Spec.TokenSecretRef)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &generic.Client{URL: c.Spec.URL, AllowHTTP: c.Spec.AllowHTTP}
+	if ref := c.Spec.CACert; ref != nil {
+		value, err := vpn.ResolveValue(ctx, r, c.Namespace, "", ref, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM([]byte(value)) {
+			return nil, vpn.Invalid("Configuration CA certificate is invalid: no valid PEM certificates found")
+		}
+
+		p.HTTPClient = &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					RootCAs:    roots,
+					MinVersion: tls.VersionTLS12,
+				},
+			},
+		}
+	}
+
+	res, err := provider.Generate(ctx, p, provider.Credentials{Password: token}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Config.Validate(); err != nil {
+		return nil, vpn.Invalid("Control plane returned an invalid config: %s", err)
+	}
+
+	return res, nil
+}
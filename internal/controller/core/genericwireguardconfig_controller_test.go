@@ -0,0 +1,225 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	"github.com/unmango/thecluster-operator/internal/generic"
+)
+
+var _ = Describe("GenericWireguardConfig Controller", func() {
+	Context("When reconciling a resource", func() {
+		const (
+			resourceName = "test-generic"
+			serverKey    = "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="
+		)
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		var (
+			genericconfig        *corev1alpha1.GenericWireguardConfig
+			api                  *httptest.Server
+			authorization        string
+			registered           []string
+			controllerReconciler *GenericWireguardConfigReconciler
+		)
+
+		BeforeEach(func() {
+			By("Starting the control plane stand-in")
+			authorization, registered = "", nil
+			api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+
+				req := generic.RegisterRequest{}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				registered = append(registered, req.PublicKey)
+
+				_ = json.NewEncoder(w).Encode(generic.RegisterResponse{
+					Server:          "wg-1",
+					Endpoint:        "203.0.113.1:51820",
+					ServerPublicKey: serverKey,
+					Addresses:       []string{"10.8.0.2/32"},
+					AllowedIPs:      []string{"10.8.0.0/24"},
+				})
+			}))
+
+			genericconfig = &corev1alpha1.GenericWireguardConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: corev1alpha1.GenericWireguardConfigSpec{
+					URL:       api.URL + "/peers",
+					AllowHTTP: true,
+				},
+			}
+
+			controllerReconciler = &GenericWireguardConfigReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+		})
+
+		JustBeforeEach(func(ctx context.Context) {
+			By("Creating the custom resource for the Kind GenericWireguardConfig")
+			err := k8sClient.Get(ctx, typeNamespacedName, genericconfig)
+			if err != nil && errors.IsNotFound(err) {
+				Expect(k8sClient.Create(ctx, genericconfig)).To(Succeed())
+			}
+		})
+
+		AfterEach(func(ctx context.Context) {
+			api.Close()
+
+			resource := &corev1alpha1.GenericWireguardConfig{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance GenericWireguardConfig")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			By("Deleting any generated config secrets")
+			secret := &corev1.Secret{}
+			if err := k8sClient.Get(ctx, typeNamespacedName, secret); err == nil {
+				Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+			}
+		})
+
+		It("should render the registered peer into a secret", func(ctx context.Context) {
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(registered).To(HaveLen(1))
+			Expect(authorization).To(BeEmpty())
+
+			By("Fetching the config secret")
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			config := string(secret.Data[vpn.ConfigKey])
+			Expect(config).To(ContainSubstring("Address = 10.8.0.2/32\n"))
+			Expect(config).To(ContainSubstring("PublicKey = " + serverKey + "\n"))
+			Expect(config).To(ContainSubstring("Endpoint = 203.0.113.1:51820\n"))
+			Expect(config).To(ContainSubstring("AllowedIPs = 10.8.0.0/24\n"))
+
+			By("Fetching the config resource")
+			resource := &corev1alpha1.GenericWireguardConfig{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Server).To(Equal("wg-1"))
+			Expect(resource.Status.SecretName).To(Equal(resourceName))
+
+			available := meta.IsStatusConditionTrue(
+				resource.Status.Conditions,
+				TypeAvailableGenericWireguardConfig,
+			)
+			Expect(available).To(BeTrueBecause("The config is available"))
+		})
+
+		When("a token is configured", func() {
+			tokenName := types.NamespacedName{
+				Name:      "control-plane-token",
+				Namespace: typeNamespacedName.Namespace,
+			}
+
+			BeforeEach(func(ctx context.Context) {
+				By("Creating the token secret")
+				Expect(k8sClient.Create(ctx, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      tokenName.Name,
+						Namespace: tokenName.Namespace,
+					},
+					StringData: map[string]string{"token": "test-token"},
+				})).To(Succeed())
+
+				genericconfig.Spec.TokenSecretRef = &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: tokenName.Name,
+					},
+					Key: "token",
+				}
+			})
+
+			AfterEach(func(ctx context.Context) {
+				By("Cleaning up the token secret")
+				sec := &corev1.Secret{}
+				if err := k8sClient.Get(ctx, tokenName, sec); err == nil {
+					Expect(k8sClient.Delete(ctx, sec)).To(Succeed())
+				}
+			})
+
+			It("should send the token", func(ctx context.Context) {
+				By("Reconciling the created resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(authorization).To(Equal("Bearer test-token"))
+			})
+		})
+
+		When("the token secret does not exist", func() {
+			BeforeEach(func() {
+				genericconfig.Spec.TokenSecretRef = &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: "does-not-exist",
+					},
+					Key: "token",
+				}
+			})
+
+			It("Should error", func(ctx context.Context) {
+				By("Reconciling the created resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(registered).To(BeEmpty())
+
+				By("Fetching the config resource")
+				resource := &corev1alpha1.GenericWireguardConfig{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+				errored := meta.IsStatusConditionTrue(
+					resource.Status.Conditions,
+					TypeErrorGenericWireguardConfig,
+				)
+				Expect(errored).To(BeTrueBecause("The token secret is missing"))
+			})
+		})
+	})
+})
@@ -0,0 +1,236 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+var (
+	TypeAvailableProtonVPNConfig     = "Available"
+	TypePortForwardedProtonVPNConfig = "PortForwarded"
+)
+
+const defaultProtonVPNConfigKey = "wg0.conf"
+
+// ProtonVPNConfigReconciler reconciles a ProtonVPNConfig object. The forwarded
+// port is requested by the NAT-PMP sidecar of the WireguardClient following the
+// config, which reports it in the status, the reconciler only checks that it is current.
+type ProtonVPNConfigReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=protonvpnconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=protonvpnconfigs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=protonvpnconfigs/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
+func (r *ProtonVPNConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	pc := &corev1alpha1.ProtonVPNConfig{}
+	if err := r.Get(ctx, req.NamespacedName, pc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if len(pc.Status.Conditions) == 0 {
+		_ = meta.SetStatusCondition(
+			&pc.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableProtonVPNConfig,
+				Status:  metav1.ConditionUnknown,
+				Reason:  "Reconciling",
+				Message: "Starting reconciliation",
+			},
+		)
+		if err := r.Status().Update(ctx, pc); err != nil {
+			log.Error(err, "Failed to update ProtonVPN config status")
+			return ctrl.Result{}, err
+		}
+		if err := r.Get(ctx, req.NamespacedName, pc); err != nil {
+			log.Error(err, "Failed to re-fetch ProtonVPN config")
+			return ctrl.Result{}, err
+		}
+	}
+
+	config, err := r.loadConfig(ctx, pc)
+	var invalid *vpn.InvalidError
+	if errors.As(err, &invalid) {
+		log.Info("Config is invalid", "reason", err.Error())
+		_ = meta.SetStatusCondition(
+			&pc.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableProtonVPNConfig,
+				Status:  metav1.ConditionFalse,
+				Reason:  "InvalidConfig",
+				Message: err.Error(),
+			},
+		)
+		if err := r.Status().Update(ctx, pc); err != nil {
+			log.Error(err, "Failed to update ProtonVPN config status")
+			return ctrl.Result{}, err
+		}
+
+		// The config secret is watched, so there's no need to requeue
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		log.Error(err, "Failed to load config")
+		return ctrl.Result{}, err
+	}
+
+	pc.Status.Endpoint = config.Peers[0].Endpoint
+	_ = meta.SetStatusCondition(
+		&pc.Status.Conditions,
+		metav1.Condition{
+			Type:    TypeAvailableProtonVPNConfig,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Reconciling",
+			Message: "Config is valid",
+		},
+	)
+
+	if pc.Spec.PortForwarding == nil {
+		pc.Status.ForwardedPort = 0
+		pc.Status.PortExpiresAt = nil
+		_ = meta.RemoveStatusCondition(&pc.Status.Conditions, TypePortForwardedProtonVPNConfig)
+		if err := r.Status().Update(ctx, pc); err != nil {
+			log.Error(err, "Failed to update ProtonVPN config status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if pc.Status.ForwardedPort == 0 || pc.Status.PortExpiresAt == nil || !pc.Status.PortExpiresAt.After(time.Now()) {
+		log.Info("Waiting for the forwarded port")
+		_ = meta.SetStatusCondition(
+			&pc.Status.Conditions,
+			metav1.Condition{
+				Type:    TypePortForwardedProtonVPNConfig,
+				Status:  metav1.ConditionFalse,
+				Reason:  "WaitingForSidecar",
+				Message: "Waiting for the NAT-PMP sidecar of a WireguardClient following the config to forward a port",
+			},
+		)
+		if err := r.Status().Update(ctx, pc); err != nil {
+			log.Error(err, "Failed to update ProtonVPN config status")
+			return ctrl.Result{}, err
+		}
+
+		// The sidecar writes the status, which triggers another reconcile
+		return ctrl.Result{}, nil
+	}
+
+	_ = meta.SetStatusCondition(
+		&pc.Status.Conditions,
+		metav1.Condition{
+			Type:    TypePortForwardedProtonVPNConfig,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Reconciling",
+			Message: fmt.Sprintf("Port %d is forwarded", pc.Status.ForwardedPort),
+		},
+	)
+	if err := r.Status().Update(ctx, pc); err != nil {
+		log.Error(err, "Failed to update ProtonVPN config status")
+		return ctrl.Result{}, err
+	}
+
+	// Check the port again when it expires, in case the sidecar stopped renewing it
+	return ctrl.Result{RequeueAfter: time.Until(pc.Status.PortExpiresAt.Time)}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ProtonVPNConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1alpha1.ProtonVPNConfig{}).
+		Named("core-protonvpnconfig").
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.configsForSecret)).
+		Complete(r)
+}
+
+// loadConfig reads and validates the WireGuard config referenced by pc. Only
+// a missing secret or key and an invalid config are [vpn.InvalidError]s,
+// other errors are transient.
+func (r *ProtonVPNConfigReconciler) loadConfig(ctx context.Context, pc *corev1alpha1.ProtonVPNConfig) (*wireguard.Config, error) {
+	ref := pc.Spec.SecretRef
+	key := ref.Key
+	if key == "" {
+		key = defaultProtonVPNConfigKey
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: pc.Namespace, Name: ref.Name}, secret); apierrors.IsNotFound(err) {
+		return nil, vpn.Invalid("secret %s not found", ref.Name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, vpn.Invalid("key %s not found in secret %s", key, ref.Name)
+	}
+
+	config, err := wireguard.Parse(string(data))
+	if err != nil {
+		return nil, vpn.Invalid("parsing config: %s", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, vpn.Invalid("validating config: %s", err)
+	}
+
+	return config, nil
+}
+
+// configsForSecret enqueues the ProtonVPNConfigs referencing obj
+func (r *ProtonVPNConfigReconciler) configsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := &corev1alpha1.ProtonVPNConfigList{}
+	if err := r.List(ctx, list, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list ProtonVPN configs")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, pc := range list.Items {
+		if pc.Spec.SecretRef.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(&pc),
+			})
+		}
+	}
+
+	return requests
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+)
+
+const protonVPNConfig = `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+Address = 10.2.0.2/32
+DNS = 10.2.0.1
+
+[Peer]
+PublicKey = xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=
+AllowedIPs = 0.0.0.0/0
+Endpoint = 192.0.2.1:51820
+`
+
+// unavailableSecrets fails every Secret Get as if the API server was unavailable
+type unavailableSecrets struct {
+	client.Client
+}
+
+func (c unavailableSecrets) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if _, ok := obj.(*corev1.Secret); ok {
+		return errors.NewServiceUnavailable("try again later")
+	}
+
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+var _ = Describe("ProtonVPNConfig Controller", func() {
+	Context("When reconciling a resource", func() {
+		const resourceName = "test-protonvpn"
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		var (
+			protonvpnconfig      *corev1alpha1.ProtonVPNConfig
+			config               string
+			controllerReconciler *ProtonVPNConfigReconciler
+		)
+
+		BeforeEach(func() {
+			protonvpnconfig = &corev1alpha1.ProtonVPNConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: corev1alpha1.ProtonVPNConfigSpec{
+					SecretRef: corev1alpha1.ProtonVPNConfigSecretRef{
+						Name: resourceName,
+					},
+				},
+			}
+			config = protonVPNConfig
+
+			controllerReconciler = &ProtonVPNConfigReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+		})
+
+		JustBeforeEach(func(ctx context.Context) {
+			By("Creating the config secret")
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				StringData: map[string]string{
+					"wg0.conf": config,
+				},
+			})).To(Succeed())
+
+			By("Creating the custom resource for the Kind ProtonVPNConfig")
+			err := k8sClient.Get(ctx, typeNamespacedName, protonvpnconfig)
+			if err != nil && errors.IsNotFound(err) {
+				Expect(k8sClient.Create(ctx, protonvpnconfig)).To(Succeed())
+			}
+		})
+
+		AfterEach(func(ctx context.Context) {
+			resource := &corev1alpha1.ProtonVPNConfig{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance ProtonVPNConfig")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			By("Deleting the config secret")
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+		})
+
+		It("should validate the config", func(ctx context.Context) {
+			By("Reconciling the created resource")
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeZero())
+
+			By("Fetching the config resource")
+			resource := &corev1alpha1.ProtonVPNConfig{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Endpoint).To(Equal("192.0.2.1:51820"))
+			Expect(resource.Status.ForwardedPort).To(BeZero())
+
+			available := meta.IsStatusConditionTrue(
+				resource.Status.Conditions,
+				TypeAvailableProtonVPNConfig,
+			)
+			Expect(available).To(BeTrueBecause("The config is valid"))
+		})
+
+		When("the config is invalid", func() {
+			BeforeEach(func() {
+				config = "[Interface]\nPrivateKey = nope\n"
+			})
+
+			It("should not be available", func(ctx context.Context) {
+				By("Reconciling the created resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				By("Fetching the config resource")
+				resource := &corev1alpha1.ProtonVPNConfig{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+				cond := meta.FindStatusCondition(
+					resource.Status.Conditions,
+					TypeAvailableProtonVPNConfig,
+				)
+				Expect(cond).NotTo(BeNil())
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal("InvalidConfig"))
+			})
+		})
+
+		When("the config secret can't be read", func() {
+			BeforeEach(func() {
+				controllerReconciler.Client = unavailableSecrets{k8sClient}
+			})
+
+			It("should return the error to be retried", func(ctx context.Context) {
+				By("Reconciling the created resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(errors.IsServiceUnavailable(err)).To(BeTrue())
+
+				By("Fetching the config resource")
+				resource := &corev1alpha1.ProtonVPNConfig{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+				cond := meta.FindStatusCondition(
+					resource.Status.Conditions,
+					TypeAvailableProtonVPNConfig,
+				)
+				Expect(cond).NotTo(BeNil())
+				Expect(cond.Reason).NotTo(Equal("InvalidConfig"))
+			})
+		})
+
+		When("port forwarding is enabled", func() {
+			BeforeEach(func() {
+				protonvpnconfig.Spec.PortForwarding = &corev1alpha1.ProtonVPNPortForwarding{
+					Gateway:         "127.0.0.1",
+					LifetimeSeconds: 60,
+				}
+			})
+
+			reportPort := func(ctx context.Context, expiresIn time.Duration) {
+				GinkgoHelper()
+				Expect(k8sClient.Get(ctx, typeNamespacedName, protonvpnconfig)).To(Succeed())
+				protonvpnconfig.Status.ForwardedPort = 41234
+				protonvpnconfig.Status.PortExpiresAt = &metav1.Time{Time: time.Now().Add(expiresIn)}
+				Expect(k8sClient.Status().Update(ctx, protonvpnconfig)).To(Succeed())
+			}
+
+			It("should wait for the sidecar to forward a port", func(ctx context.Context) {
+				By("Reconciling the created resource")
+				result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeZero())
+
+				By("Fetching the config resource")
+				resource := &corev1alpha1.ProtonVPNConfig{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+				cond := meta.FindStatusCondition(
+					resource.Status.Conditions,
+					TypePortForwardedProtonVPNConfig,
+				)
+				Expect(cond).NotTo(BeNil())
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal("WaitingForSidecar"))
+			})
+
+			It("should report the port forwarded by the sidecar", func(ctx context.Context) {
+				reportPort(ctx, time.Minute)
+
+				By("Reconciling the resource")
+				result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeNumerically("~", time.Minute, 5*time.Second))
+
+				By("Fetching the config resource")
+				resource := &corev1alpha1.ProtonVPNConfig{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.ForwardedPort).To(Equal(int32(41234)))
+
+				forwarded := meta.IsStatusConditionTrue(
+					resource.Status.Conditions,
+					TypePortForwardedProtonVPNConfig,
+				)
+				Expect(forwarded).To(BeTrueBecause("The port is forwarded"))
+			})
+
+			It("should notice when the port expires", func(ctx context.Context) {
+				reportPort(ctx, -time.Second)
+
+				By("Reconciling the resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				By("Fetching the config resource")
+				resource := &corev1alpha1.ProtonVPNConfig{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+				forwarded := meta.IsStatusConditionFalse(
+					resource.Status.Conditions,
+					TypePortForwardedProtonVPNConfig,
+				)
+				Expect(forwarded).To(BeTrueBecause("The sidecar stopped renewing the port"))
+			})
+		})
+	})
+})
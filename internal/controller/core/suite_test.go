@@ -31,8 +31,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
 
 	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	corev1beta1 "github.com/unmango/thecluster-operator/api/core/v1beta1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -62,6 +65,9 @@ var _ = BeforeSuite(func() {
 	err = corev1alpha1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = corev1beta1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
 	// +kubebuilder:scaffold:scheme
 
 	By("bootstrapping test environment")
@@ -83,6 +89,22 @@ var _ = BeforeSuite(func() {
 	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
 	Expect(err).NotTo(HaveOccurred())
 	Expect(k8sClient).NotTo(BeNil())
+
+	// envtest points convertible CRDs at the local webhook server, so
+	// objects of older versions are converted to the storage version
+	By("starting the conversion webhook")
+	webhookInstallOptions := &testEnv.WebhookInstallOptions
+	server := webhook.NewServer(webhook.Options{
+		Host:    webhookInstallOptions.LocalServingHost,
+		Port:    webhookInstallOptions.LocalServingPort,
+		CertDir: webhookInstallOptions.LocalServingCertDir,
+	})
+	server.Register("/convert", conversion.NewWebhookHandler(scheme.Scheme))
+	go func() {
+		defer GinkgoRecover()
+		Expect(server.Start(ctx)).To(Succeed())
+	}()
+	Eventually(server.StartedChecker()).WithArguments(nil).Should(Succeed())
 })
 
 var _ = AfterSuite(func() {
@@ -0,0 +1,276 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+// natInitScript lets traffic from the source CIDRs reach the cluster through the
+// tunnel pod. Replies are masqueraded so the rest of the cluster doesn't need
+// routes back to the source CIDRs.
+const natInitScript = `set -eu
+sysctl -w net.ipv4.ip_forward=1
+sysctl -w net.ipv6.conf.all.forwarding=1 || true
+for cidr in $SOURCE_CIDRS; do
+  case "$cidr" in *:*) ipt=ip6tables ;; *) ipt=iptables ;; esac
+  $ipt -t nat -C POSTROUTING -s "$cidr" ! -o wg0 -j MASQUERADE 2>/dev/null ||
+    $ipt -t nat -A POSTROUTING -s "$cidr" ! -o wg0 -j MASQUERADE
+done
+`
+
+// tunnelImage returns image, falling back to defaultImage and then [DefaultWireguardImage]
+func tunnelImage(image, defaultImage string) string {
+	if image != "" {
+		return image
+	}
+	if defaultImage != "" {
+		return defaultImage
+	}
+
+	return DefaultWireguardImage
+}
+
+// rootSecurityContext runs wg-quick, which needs to configure the pod's network
+func rootSecurityContext() *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		Capabilities: &corev1.Capabilities{
+			Add: []corev1.Capability{"NET_ADMIN"},
+		},
+		RunAsUser:    ptr.To[int64](0),
+		RunAsNonRoot: ptr.To(false),
+	}
+}
+
+// newNATInitContainer builds the privileged init container that enables forwarding
+// and masquerades traffic from cidrs
+func newNATInitContainer(name, image string, cidrs []string) corev1.Container {
+	return corev1.Container{
+		Name:    name,
+		Image:   image,
+		Command: []string{"/bin/sh", "-c", natInitScript},
+		Env: []corev1.EnvVar{{
+			Name:  "SOURCE_CIDRS",
+			Value: strings.Join(cidrs, " "),
+		}},
+		SecurityContext: &corev1.SecurityContext{
+			Privileged:   ptr.To(true),
+			RunAsUser:    ptr.To[int64](0),
+			RunAsNonRoot: ptr.To(false),
+		},
+	}
+}
+
+// newTunnelContainer builds a wireguard container listening on port, which brings
+// up the config in the "config" volume
+func newTunnelContainer(image string, pullPolicy corev1.PullPolicy, port int32) corev1.Container {
+	return corev1.Container{
+		Name:            "wireguard",
+		Image:           image,
+		ImagePullPolicy: pullPolicy,
+		Ports: []corev1.ContainerPort{{
+			Name:          "wireguard",
+			ContainerPort: port,
+			Protocol:      corev1.ProtocolUDP,
+		}},
+		VolumeMounts: []corev1.VolumeMount{{
+			Name:      "config",
+			MountPath: "/config/" + vpn.ConfigKey,
+			SubPath:   vpn.ConfigKey,
+			ReadOnly:  true,
+		}},
+		SecurityContext: rootSecurityContext(),
+	}
+}
+
+// newTunnelService builds a service exposing port on the pods matching selector
+func newTunnelService(owner client.Object, scheme *runtime.Scheme, selector map[string]string, port int32, spec corev1alpha1.WireguardServerService) (*corev1.Service, error) {
+	svcType := spec.Type
+	if svcType == "" {
+		svcType = corev1.ServiceTypeLoadBalancer
+	}
+
+	svc := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        owner.GetName(),
+			Namespace:   owner.GetNamespace(),
+			Annotations: spec.Annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     svcType,
+			Selector: selector,
+			Ports: []corev1.ServicePort{{
+				Name:       "wireguard",
+				Port:       port,
+				TargetPort: intstr.FromString("wireguard"),
+				Protocol:   corev1.ProtocolUDP,
+				NodePort:   spec.NodePort,
+			}},
+		},
+	}
+
+	if err := ctrl.SetControllerReference(owner, svc, scheme); err != nil {
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+// serviceEndpoint returns the host:port svc is reachable on, or an empty string
+// when it hasn't been assigned an address yet. host overrides the service's address.
+func serviceEndpoint(ctx context.Context, c client.Reader, host string, svc *corev1.Service) (string, error) {
+	if len(svc.Spec.Ports) == 0 {
+		return "", nil
+	}
+
+	port := svc.Spec.Ports[0].Port
+	if svc.Spec.Type == corev1.ServiceTypeNodePort {
+		port = svc.Spec.Ports[0].NodePort
+	}
+
+	if host == "" && svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if host = ingress.IP; host == "" {
+				host = ingress.Hostname
+			}
+			if host != "" {
+				break
+			}
+		}
+	}
+	if host == "" && svc.Spec.Type == corev1.ServiceTypeNodePort {
+		nodes := &corev1.NodeList{}
+		if err := c.List(ctx, nodes); err != nil {
+			return "", err
+		}
+
+		host = nodeAddress(nodes.Items)
+	}
+	if host == "" || port == 0 {
+		return "", nil
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(int(port))), nil
+}
+
+// nodeAddress returns the first external address of nodes
+func nodeAddress(nodes []corev1.Node) string {
+	for _, node := range nodes {
+		for _, addr := range node.Status.Addresses {
+			if addr.Type == corev1.NodeExternalIP || addr.Type == corev1.NodeExternalDNS {
+				return addr.Address
+			}
+		}
+	}
+
+	return ""
+}
+
+// ensurePrivateKey returns the private key in the named secret, generating
+// one owned by owner when the secret doesn't hold a valid key
+func ensurePrivateKey(ctx context.Context, c client.Client, scheme *runtime.Scheme, owner client.Object, name string) (wireguard.Key, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, client.ObjectKey{Namespace: owner.GetNamespace(), Name: name}, secret)
+	if client.IgnoreNotFound(err) != nil {
+		return wireguard.Key{}, err
+	}
+	if err := vpn.CheckSecretOwner(owner, secret); err != nil {
+		return wireguard.Key{}, err
+	}
+
+	data := strings.TrimSpace(string(secret.Data[corev1alpha1.WireguardKeyPairPrivateKey]))
+	if key, err := wireguard.ParseKey(data); err == nil {
+		return key, nil
+	}
+
+	log.FromContext(ctx).Info("Generating private key", "secret", name)
+	key, err := wireguard.GeneratePrivateKey()
+	if err != nil {
+		return wireguard.Key{}, err
+	}
+
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: owner.GetNamespace(),
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, c, secret, func() error {
+		if err := vpn.CheckSecretOwner(owner, secret); err != nil {
+			return err
+		}
+		secret.Data = map[string][]byte{
+			corev1alpha1.WireguardKeyPairPrivateKey: []byte(key.String()),
+			corev1alpha1.WireguardKeyPairPublicKey:  []byte(key.PublicKey().String()),
+		}
+
+		return ctrl.SetControllerReference(owner, secret, scheme)
+	})
+	if err != nil {
+		return wireguard.Key{}, err
+	}
+
+	return key, nil
+}
+
+// applyAs applies obj with server-side apply as fieldOwner, and updates it with the result
+func applyAs(ctx context.Context, c client.Client, fieldOwner string, obj client.Object) error {
+	return c.Patch(ctx, obj, client.Apply,
+		client.FieldOwner(fieldOwner),
+		client.ForceOwnership,
+	)
+}
+
+// deleteControlled deletes the objects that exist and are controlled by owner
+func deleteControlled(ctx context.Context, c client.Client, owner metav1.Object, objs ...client.Object) error {
+	for _, obj := range objs {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			continue
+		}
+		if metav1.IsControlledBy(obj, owner) {
+			if err := c.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
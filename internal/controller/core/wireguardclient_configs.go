@@ -0,0 +1,176 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+)
+
+const (
+	// ConfigHashAnnotation is set on the pod template to a hash of the referenced
+	// configs, so that editing a config rolls the client pods
+	ConfigHashAnnotation = "core.thecluster.io/config-hash"
+
+	// ConfigSecretIndex indexes WireguardClients by the secrets their configs reference
+	ConfigSecretIndex = "spec.configs.valueFrom.secretKeyRef.name"
+
+	// ConfigMapIndex indexes WireguardClients by the config maps their configs reference
+	ConfigMapIndex = "spec.configs.valueFrom.configMapKeyRef.name"
+)
+
+// ConfigHash hashes the values of the configs referenced by wg, its proxy
+// credentials and its gateway settings. Missing objects and keys are hashed
+// as absent so that creating them later also rolls the client pods.
+func (r *WireguardClientReconciler) ConfigHash(ctx context.Context, wg *corev1alpha1.WireguardClient) (string, error) {
+	h := sha256.New()
+	for _, c := range wg.Spec.Configs {
+		source := configSource(wg, c)
+		if source == nil {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(h, "%s\x00", c.Name)
+		if ref := source.SecretKeyRef; ref != nil {
+			secret := &corev1.Secret{}
+			err := r.Get(ctx, client.ObjectKey{Namespace: wg.Namespace, Name: ref.Name}, secret)
+			if client.IgnoreNotFound(err) != nil {
+				return "", err
+			}
+
+			writeValue(h, "secret", ref.Name, ref.Key, secret.Data[ref.Key], !apierrors.IsNotFound(err))
+		}
+		if ref := source.ConfigMapKeyRef; ref != nil {
+			cm := &corev1.ConfigMap{}
+			err := r.Get(ctx, client.ObjectKey{Namespace: wg.Namespace, Name: ref.Name}, cm)
+			if client.IgnoreNotFound(err) != nil {
+				return "", err
+			}
+
+			writeValue(h, "configmap", ref.Name, ref.Key, []byte(cm.Data[ref.Key]), !apierrors.IsNotFound(err))
+		}
+	}
+
+	if p := wg.Spec.Proxy; p != nil && p.AuthSecretRef != nil {
+		// The credentials are only read when the proxy starts
+		secret := &corev1.Secret{}
+		err := r.Get(ctx, client.ObjectKey{Namespace: wg.Namespace, Name: p.AuthSecretRef.Name}, secret)
+		if client.IgnoreNotFound(err) != nil {
+			return "", err
+		}
+
+		found := !apierrors.IsNotFound(err)
+		for _, key := range []string{corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey} {
+			writeValue(h, "secret", p.AuthSecretRef.Name, key, secret.Data[key], found)
+		}
+	}
+	if wg.Spec.Gateway != nil {
+		writeValue(h, "configmap", GatewayName(wg), GatewaySettingsKey, []byte(GatewaySettings(wg)), true)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func writeValue(w io.Writer, kind, name, key string, value []byte, found bool) {
+	_, _ = fmt.Fprintf(w, "%s/%s/%s\x00%t\x00%d\x00", kind, name, key, found, len(value))
+	_, _ = w.Write(value)
+}
+
+// indexConfigSecrets is an indexer for [ConfigSecretIndex]
+func indexConfigSecrets(obj client.Object) []string {
+	wg, ok := obj.(*corev1alpha1.WireguardClient)
+	if !ok {
+		return nil
+	}
+
+	names := []string{}
+	for _, c := range wg.Spec.Configs {
+		if c.ValueFrom != nil && c.ValueFrom.SecretKeyRef != nil {
+			names = append(names, c.ValueFrom.SecretKeyRef.Name)
+		}
+		if c.Inline != nil {
+			// The rendered secrets are included so removing a conflicting secret triggers a reconcile
+			names = append(names, InlineConfigSecretName(wg, c), PresharedKeySecretName(wg, c))
+			if ref := privateKeyRef(c.Inline.Interface); ref != nil {
+				names = append(names, ref.Name)
+			}
+			for _, p := range c.Inline.Peers {
+				if p.PresharedKeySecretRef != nil {
+					names = append(names, p.PresharedKeySecretRef.Name)
+				}
+			}
+		}
+	}
+
+	if p := wg.Spec.Proxy; p != nil && p.AuthSecretRef != nil {
+		names = append(names, p.AuthSecretRef.Name)
+	}
+
+	return names
+}
+
+// indexConfigMaps is an indexer for [ConfigMapIndex]
+func indexConfigMaps(obj client.Object) []string {
+	wg, ok := obj.(*corev1alpha1.WireguardClient)
+	if !ok {
+		return nil
+	}
+
+	names := []string{}
+	for _, c := range wg.Spec.Configs {
+		if c.ValueFrom != nil && c.ValueFrom.ConfigMapKeyRef != nil {
+			names = append(names, c.ValueFrom.ConfigMapKeyRef.Name)
+		}
+	}
+
+	return names
+}
+
+// clientsReferencing returns a map function enqueueing the WireguardClients
+// whose configs reference an object through index
+func (r *WireguardClientReconciler) clientsReferencing(index string) func(context.Context, client.Object) []reconcile.Request {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		list := &corev1alpha1.WireguardClientList{}
+		if err := r.List(ctx, list,
+			client.InNamespace(obj.GetNamespace()),
+			client.MatchingFields{index: obj.GetName()},
+		); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to list wireguard clients", "index", index)
+			return nil
+		}
+
+		requests := make([]reconcile.Request, len(list.Items))
+		for i, wg := range list.Items {
+			requests[i] = reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(&wg),
+			}
+		}
+
+		return requests
+	}
+}
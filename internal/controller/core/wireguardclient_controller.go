@@ -18,42 +18,88 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"strconv"
 	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
 )
 
 var (
-	TypeAvailableWireguardClient = "Available"
-	TypeDegradedWireguardClient  = "Degraded"
-	WireguardClientFinalizer     = "wireguardclient.core.thecluster.io/finalizer"
+	TypeAvailableWireguardClient   = "Available"
+	TypeDegradedWireguardClient    = "Degraded"
+	TypeProgressingWireguardClient = "Progressing"
+	WireguardClientFinalizer       = "wireguardclient.core.thecluster.io/finalizer"
+
+	// WireguardClientFieldOwner is the field manager used to apply owned resources
+	WireguardClientFieldOwner = "wireguardclient-controller"
+
+	// DefaultWireguardImage is the image used when neither the client nor the
+	// reconciler specify one
+	DefaultWireguardImage = "lscr.io/linuxserver/wireguard:latest"
+
+	// WireguardClientUIDLabel is the pod label holding the UID of the owning WireguardClient
+	WireguardClientUIDLabel = "core.thecluster.io/wireguardclient-uid"
+
+	errRecreatingDeployment = errors.New("recreating deployment")
 )
 
+// SelectorLabels returns the labels selecting the pods of wg. They are unique
+// to wg so that clients in the same namespace never select each other's pods.
+func SelectorLabels(wg *corev1alpha1.WireguardClient) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":     "wireguard",
+		"app.kubernetes.io/instance": wg.Name,
+		WireguardClientUIDLabel:      string(wg.UID),
+	}
+}
+
 // WireguardClientReconciler reconciles a WireguardClient object
 type WireguardClientReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// The image used for clients that don't specify one, defaults to [DefaultWireguardImage]
+	DefaultImage string
+
+	// The image of the NAT-PMP sidecar that forwards the ports followed by inbound
+	// rules. Forwarded ports aren't renewed when it is empty.
+	NATPMPImage string
 }
 
 // +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardclients,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardclients/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardclients/finalizers,verbs=update
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=configmaps;secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=create;update;patch
+// +kubebuilder:rbac:groups=core,resources=configmaps;services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=protonvpnconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=protonvpnconfigs/status,verbs=get;patch
+// +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
 
 func (r *WireguardClientReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
@@ -152,41 +198,194 @@ func (r *WireguardClientReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, nil
 	}
 
-	deployment := &appsv1.Deployment{}
-	if err := r.Get(ctx, req.NamespacedName, deployment); errors.IsNotFound(err) {
-		log.Info("Creating a new deployment", "ns", req.Namespace, "name", req.Name)
-		if err := r.CreateDeployment(ctx, wg); err != nil {
-			log.Error(err, "Failed to create deployment for wireguard client")
-			_ = meta.SetStatusCondition(
-				&wg.Status.Conditions,
-				metav1.Condition{
-					Type:    TypeAvailableWireguardClient,
-					Status:  metav1.ConditionFalse,
-					Reason:  "Reconciling",
-					Message: fmt.Sprintf("Failed to create deployment for %s: %s", wg.Name, err),
-				},
-			)
-			if err = r.Status().Update(ctx, wg); err != nil {
-				log.Error(err, "Failed to update status")
-				return ctrl.Result{}, err
-			}
+	if err := r.RenderInlineConfigs(ctx, wg); err != nil {
+		log.Error(err, "Failed to render inline configs")
+		_ = meta.SetStatusCondition(
+			&wg.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardClient,
+				Status:  metav1.ConditionFalse,
+				Reason:  "InvalidConfig",
+				Message: fmt.Sprintf("Failed to render inline configs for %s: %s", wg.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, wg); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
 
-			return ctrl.Result{RequeueAfter: time.Minute}, err
+		// Referenced secrets are watched, so fixing them triggers a reconcile
+		var invalid *vpn.InvalidError
+		if errors.As(err, &invalid) {
+			return ctrl.Result{}, nil
 		}
+
+		return ctrl.Result{}, err
+	}
+
+	if err := r.ApplyGateway(ctx, wg); err != nil {
+		log.Error(err, "Failed to apply gateway")
+		_ = meta.SetStatusCondition(
+			&wg.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardClient,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Reconciling",
+				Message: fmt.Sprintf("Failed to apply gateway for %s: %s", wg.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, wg); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	if err := r.ApplyProxy(ctx, wg); err != nil {
+		log.Error(err, "Failed to apply proxy")
+		_ = meta.SetStatusCondition(
+			&wg.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardClient,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Reconciling",
+				Message: fmt.Sprintf("Failed to apply proxy for %s: %s", wg.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, wg); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	if err := r.ApplyInbound(ctx, wg); err != nil {
+		log.Error(err, "Failed to apply inbound rules")
+		_ = meta.SetStatusCondition(
+			&wg.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardClient,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Reconciling",
+				Message: fmt.Sprintf("Failed to apply inbound rules for %s: %s", wg.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, wg); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	if err := r.ApplyNATPMP(ctx, wg); err != nil {
+		log.Error(err, "Failed to apply NAT-PMP access")
+		_ = meta.SetStatusCondition(
+			&wg.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardClient,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Reconciling",
+				Message: fmt.Sprintf("Failed to apply NAT-PMP access for %s: %s", wg.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, wg); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	if wg.Spec.SidecarOnly {
+		// Injected sidecars connect with the client's keys, its own pod would compete with them
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      wg.Name,
+				Namespace: wg.Namespace,
+			},
+		}
+		if err := deleteControlled(ctx, r.Client, wg, deployment); err != nil {
+			log.Error(err, "Failed to delete deployment of sidecar only client")
+			return ctrl.Result{}, err
+		}
+
+		wg.Status.ObservedGeneration = wg.Generation
+		wg.Status.ReadyPods = []string{}
+		_ = meta.SetStatusCondition(
+			&wg.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardClient,
+				Status:  metav1.ConditionTrue,
+				Reason:  "SidecarOnly",
+				Message: "Configs are ready for injected sidecars",
+			},
+		)
+		_ = meta.RemoveStatusCondition(&wg.Status.Conditions, TypeProgressingWireguardClient)
+		_ = meta.RemoveStatusCondition(&wg.Status.Conditions, TypeDegradedWireguardClient)
+		if err := r.Status().Update(ctx, wg); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	log.Info("Applying deployment", "ns", req.Namespace, "name", req.Name)
+	deployment, err := r.ApplyDeployment(ctx, wg)
+	if errors.Is(err, errRecreatingDeployment) {
+		log.Info("Waiting for deployment to be recreated", "ns", req.Namespace, "name", req.Name)
+		_ = meta.SetStatusCondition(
+			&wg.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardClient,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Recreating",
+				Message: fmt.Sprintf("Recreating deployment for %s with new selector labels", wg.Name),
+			},
+		)
+		if err = r.Status().Update(ctx, wg); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	} else if err != nil {
-		log.Error(err, "Failed to get deployment")
+		log.Error(err, "Failed to apply deployment for wireguard client")
+		reason := "Reconciling"
+		var invalid *vpn.InvalidError
+		if errors.As(err, &invalid) {
+			reason = "InvalidConfig"
+		}
+		_ = meta.SetStatusCondition(
+			&wg.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardClient,
+				Status:  metav1.ConditionFalse,
+				Reason:  reason,
+				Message: fmt.Sprintf("Failed to apply deployment for %s: %s", wg.Name, err),
+			},
+		)
+		if err = r.Status().Update(ctx, wg); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{RequeueAfter: time.Minute}, err
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods,
+		client.InNamespace(wg.Namespace),
+		client.MatchingLabels(SelectorLabels(wg)),
+	); err != nil {
+		log.Error(err, "Failed to list pods")
 		return ctrl.Result{}, err
 	}
 
-	meta.SetStatusCondition(
-		&wg.Status.Conditions,
-		metav1.Condition{
-			Type:    TypeAvailableWireguardClient,
-			Status:  metav1.ConditionTrue,
-			Reason:  "Reconciling",
-			Message: fmt.Sprintf("Deployment for %s created successfully", wg.Name),
-		},
-	)
+	SetWireguardClientStatus(wg, deployment, pods.Items)
 	if err := r.Status().Update(ctx, wg); err != nil {
 		log.Error(err, "Failed to update status")
 		return ctrl.Result{}, err
@@ -195,48 +394,55 @@ func (r *WireguardClientReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	return ctrl.Result{}, nil
 }
 
-func (r *WireguardClientReconciler) CreateDeployment(ctx context.Context, wg *corev1alpha1.WireguardClient) error {
-	volumes := []corev1.Volume{}
-	mounts := []corev1.VolumeMount{}
-	for _, c := range wg.Spec.Configs {
-		if v, err := r.CreateVolume(ctx, c); err != nil {
-			return err
-		} else {
-			volumes = append(volumes, v)
-			mounts = append(mounts, corev1.VolumeMount{
-				Name:      c.Name,
-				MountPath: fmt.Sprintf("/config/%s.conf", c.Name),
-			})
-		}
+// ApplyDeployment applies the desired deployment for wg with server-side apply,
+// rolling out spec changes and reverting drift in the fields the controller owns.
+// It returns the deployment as observed after the apply.
+func (r *WireguardClientReconciler) ApplyDeployment(ctx context.Context, wg *corev1alpha1.WireguardClient) (*appsv1.Deployment, error) {
+	deployment, err := r.NewDeployment(ctx, wg)
+	if err != nil {
+		return nil, err
 	}
 
-	env := []corev1.EnvVar{
-		{Name: "PUID", Value: strconv.FormatInt(wg.Spec.PUID, 10)},
-		{Name: "PGID", Value: strconv.FormatInt(wg.Spec.PGID, 10)},
-		{Name: "TZ", Value: wg.Spec.TZ},
-		{Name: "S6_READ_ONLY_ROOT", Value: "1"},
+	if err := r.migrateSelector(ctx, wg, deployment); err != nil {
+		return nil, err
 	}
 
-	if len(wg.Spec.AllowedIPs) > 0 {
-		env = append(env, corev1.EnvVar{
-			Name:  "ALLOWEDIPS",
-			Value: strings.Join(wg.Spec.AllowedIPs, ","),
-		})
+	err = r.Patch(ctx, deployment, client.Apply,
+		client.FieldOwner(WireguardClientFieldOwner),
+		client.ForceOwnership,
+	)
+	if err != nil {
+		return nil, err
 	}
-	if wg.Spec.LogConfs != nil {
-		env = append(env, corev1.EnvVar{
-			Name:  "LOG_CONFS",
-			Value: strconv.FormatBool(*wg.Spec.LogConfs),
-		})
+
+	return deployment, nil
+}
+
+// NewDeployment builds the desired deployment for wg
+func (r *WireguardClientReconciler) NewDeployment(ctx context.Context, wg *corev1alpha1.WireguardClient) (*appsv1.Deployment, error) {
+	hash, err := r.ConfigHash(ctx, wg)
+	if err != nil {
+		return nil, fmt.Errorf("hashing configs: %w", err)
+	}
+
+	image := r.image(wg)
+	container, volumes, err := NewWireguardContainer(wg, image)
+	if err != nil {
+		return nil, err
 	}
 
+	selector := SelectorLabels(wg)
 	labels := map[string]string{
-		"app.kubernetes.io/name":       "wireguard",
-		"app.kubernetes.io/version":    "latest",
+		"app.kubernetes.io/version":    imageVersion(image),
 		"app.kubernetes.io/managed-by": "WireguardClientController",
 	}
+	maps.Copy(labels, selector)
 
 	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+			Kind:       "Deployment",
+		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      wg.GetName(),
 			Namespace: wg.GetNamespace(),
@@ -244,37 +450,19 @@ func (r *WireguardClientReconciler) CreateDeployment(ctx context.Context, wg *co
 		Spec: appsv1.DeploymentSpec{
 			Replicas: ptr.To[int32](1),
 			Selector: &metav1.LabelSelector{
-				MatchLabels: labels,
+				MatchLabels: selector,
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: labels,
+					Annotations: map[string]string{
+						ConfigHashAnnotation: hash,
+					},
 				},
 				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{{
-						Name:  "wireguard",
-						Image: "lscr.io/linuxserver/wireguard:latest",
-						Env:   env,
-						Ports: []corev1.ContainerPort{{
-							ContainerPort: 51820,
-							Protocol:      corev1.ProtocolUDP,
-						}},
-						VolumeMounts: mounts,
-						// TODO: Resources
-						SecurityContext: &corev1.SecurityContext{
-							Capabilities: &corev1.Capabilities{
-								Add: []corev1.Capability{
-									"NET_ADMIN",
-								},
-							},
-							RunAsUser:                &wg.Spec.PUID,
-							RunAsGroup:               &wg.Spec.PGID,
-							RunAsNonRoot:             ptr.To(true),
-							AllowPrivilegeEscalation: ptr.To(false),
-							ReadOnlyRootFilesystem:   wg.Spec.ReadOnly,
-						},
-					}},
-					Volumes: volumes,
+					Containers:       []corev1.Container{container},
+					Volumes:          volumes,
+					ImagePullSecrets: wg.Spec.ImagePullSecrets,
 					SecurityContext: &corev1.PodSecurityContext{
 						RunAsNonRoot: ptr.To(true),
 						SeccompProfile: &corev1.SeccompProfile{
@@ -286,14 +474,254 @@ func (r *WireguardClientReconciler) CreateDeployment(ctx context.Context, wg *co
 		},
 	}
 
+	if wg.Spec.Gateway != nil {
+		init, sidecar, volume := NewGatewayContainers(wg)
+		spec := &deployment.Spec.Template.Spec
+		spec.InitContainers = append(spec.InitContainers, init)
+		spec.Containers = append(spec.Containers, sidecar)
+		spec.Volumes = append(spec.Volumes, volume)
+	}
+
+	if wg.Spec.Proxy != nil {
+		spec := &deployment.Spec.Template.Spec
+		spec.Containers = append(spec.Containers, NewProxyContainer(wg))
+	}
+
+	if len(wg.Spec.Inbound) > 0 {
+		init, sidecar, volume := NewInboundContainers(wg, image)
+		spec := &deployment.Spec.Template.Spec
+		spec.InitContainers = append(spec.InitContainers, init)
+		spec.Containers = append(spec.Containers, sidecar)
+		spec.Volumes = append(spec.Volumes, volume)
+	}
+
+	if configs := portForwardedConfigs(wg); len(configs) > 0 && r.NATPMPImage != "" {
+		spec := &deployment.Spec.Template.Spec
+		spec.Containers = append(spec.Containers, NewNATPMPContainer(r.NATPMPImage, configs))
+		spec.ServiceAccountName = NATPMPName(wg)
+	}
+
+	if wg.Spec.PodTemplate != nil {
+		template, err := MergePodTemplate(deployment.Spec.Template, *wg.Spec.PodTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("merging pod template: %w", err)
+		}
+
+		maps.Copy(template.Labels, selector)
+		template.Annotations[ConfigHashAnnotation] = hash
+		deployment.Spec.Template = template
+	}
+
 	if err := ctrl.SetControllerReference(wg, deployment, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	return deployment, nil
+}
+
+// NewWireguardContainer builds the wireguard container for wg, and the volumes
+// its configs are mounted from
+func NewWireguardContainer(wg *corev1alpha1.WireguardClient, image string) (corev1.Container, []corev1.Volume, error) {
+	volumes := []corev1.Volume{}
+	mounts := []corev1.VolumeMount{}
+	for _, c := range wg.Spec.Configs {
+		c.ValueFrom = configSource(wg, c)
+		if v, err := configVolume(c); err != nil {
+			return corev1.Container{}, nil, err
+		} else {
+			volumes = append(volumes, v)
+			mounts = append(mounts, corev1.VolumeMount{
+				Name:      c.Name,
+				MountPath: fmt.Sprintf("/config/%s.conf", c.Name),
+			})
+		}
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "PUID", Value: strconv.FormatInt(wg.Spec.PUID, 10)},
+		{Name: "PGID", Value: strconv.FormatInt(wg.Spec.PGID, 10)},
+		{Name: "TZ", Value: wg.Spec.TZ},
+		{Name: "S6_READ_ONLY_ROOT", Value: "1"},
+	}
+
+	if len(wg.Spec.AllowedIPs) > 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  "ALLOWEDIPS",
+			Value: strings.Join(wg.Spec.AllowedIPs, ","),
+		})
+	}
+	if wg.Spec.LogConfs != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "LOG_CONFS",
+			Value: strconv.FormatBool(*wg.Spec.LogConfs),
+		})
+	}
+
+	container := corev1.Container{
+		Name:            "wireguard",
+		Image:           image,
+		ImagePullPolicy: wg.Spec.ImagePullPolicy,
+		Env:             env,
+		Ports: []corev1.ContainerPort{{
+			ContainerPort: 51820,
+			Protocol:      corev1.ProtocolUDP,
+		}},
+		VolumeMounts: mounts,
+		SecurityContext: &corev1.SecurityContext{
+			Capabilities: &corev1.Capabilities{
+				Add: []corev1.Capability{
+					"NET_ADMIN",
+				},
+			},
+			RunAsUser:                &wg.Spec.PUID,
+			RunAsGroup:               &wg.Spec.PGID,
+			RunAsNonRoot:             ptr.To(true),
+			AllowPrivilegeEscalation: ptr.To(false),
+			ReadOnlyRootFilesystem:   wg.Spec.ReadOnly,
+		},
+	}
+
+	return container, volumes, nil
+}
+
+func (r *WireguardClientReconciler) image(wg *corev1alpha1.WireguardClient) string {
+	return WireguardImage(wg, r.DefaultImage)
+}
+
+// WireguardImage returns the image wg runs, falling back to defaultImage
+// and then [DefaultWireguardImage]
+func WireguardImage(wg *corev1alpha1.WireguardClient, defaultImage string) string {
+	if wg.Spec.Image != "" {
+		return wg.Spec.Image
+	}
+	if defaultImage != "" {
+		return defaultImage
+	}
+
+	return DefaultWireguardImage
+}
+
+// imageVersion returns a label value for the tag of image, e.g. "1.0.20250521"
+// for "lscr.io/linuxserver/wireguard:1.0.20250521". Digest-only references use
+// a prefix of the digest.
+func imageVersion(image string) string {
+	name, digest, _ := strings.Cut(image, "@")
+
+	version := "latest"
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		version = name[i+1:]
+	} else if _, hex, ok := strings.Cut(digest, ":"); ok {
+		version = hex[:min(len(hex), 12)]
+	}
+
+	version = version[:min(len(version), validation.LabelValueMaxLength)]
+	return strings.TrimRight(version, "-_.")
+}
+
+// MergePodTemplate strategic-merges override onto base. Unset fields in
+// override are left alone rather than cleared.
+func MergePodTemplate(base, override corev1.PodTemplateSpec) (corev1.PodTemplateSpec, error) {
+	original, err := json.Marshal(base)
+	if err != nil {
+		return base, err
+	}
+
+	// Required fields like containers marshal as null, which would delete them
+	patch := map[string]any{}
+	data, err := json.Marshal(override)
+	if err != nil {
+		return base, err
+	}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return base, err
+	}
+	if data, err = json.Marshal(dropNulls(patch)); err != nil {
+		return base, err
+	}
+
+	merged, err := strategicpatch.StrategicMergePatch(original, data, corev1.PodTemplateSpec{})
+	if err != nil {
+		return base, err
+	}
+
+	result := corev1.PodTemplateSpec{}
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return base, err
+	}
+	if result.Labels == nil {
+		result.Labels = map[string]string{}
+	}
+	if result.Annotations == nil {
+		result.Annotations = map[string]string{}
+	}
+
+	return result, nil
+}
+
+func dropNulls(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			if e == nil {
+				delete(v, k)
+			} else {
+				v[k] = dropNulls(e)
+			}
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = dropNulls(e)
+		}
+	}
+
+	return v
+}
+
+// migrateSelector deletes the existing deployment for wg when its selector differs
+// from desired. Selectors are immutable, so the deployment must be recreated, which
+// is signalled by returning errRecreatingDeployment.
+func (r *WireguardClientReconciler) migrateSelector(ctx context.Context, wg *corev1alpha1.WireguardClient, desired *appsv1.Deployment) error {
+	existing := &appsv1.Deployment{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(desired), existing); apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if existing.DeletionTimestamp != nil {
+		return errRecreatingDeployment
+	}
+	if equality.Semantic.DeepEqual(existing.Spec.Selector, desired.Spec.Selector) {
+		return nil
+	}
+	if !metav1.IsControlledBy(existing, wg) {
+		return fmt.Errorf("deployment %s exists and is not controlled by %s", existing.Name, wg.Name)
+	}
+
+	log.FromContext(ctx).Info("Deleting deployment with outdated selector",
+		"ns", existing.Namespace, "name", existing.Name,
+		"selector", existing.Spec.Selector.MatchLabels,
+	)
+	err := r.Delete(ctx, existing,
+		client.PropagationPolicy(metav1.DeletePropagationBackground),
+		client.Preconditions{UID: &existing.UID},
+	)
+	if client.IgnoreNotFound(err) != nil {
 		return err
 	}
 
-	return r.Create(ctx, deployment)
+	return errRecreatingDeployment
 }
 
 func (r *WireguardClientReconciler) CreateVolume(ctx context.Context, c corev1alpha1.WireguardClientConfig) (corev1.Volume, error) {
+	return configVolume(c)
+}
+
+func configVolume(c corev1alpha1.WireguardClientConfig) (corev1.Volume, error) {
+	if c.ValueFrom == nil {
+		return corev1.Volume{}, vpn.Invalid("config %s has no value source", c.Name)
+	}
+
 	if c.ValueFrom.SecretKeyRef != nil {
 		secret := c.ValueFrom.SecretKeyRef
 		return corev1.Volume{
@@ -326,7 +754,12 @@ func (r *WireguardClientReconciler) CreateVolume(ctx context.Context, c corev1al
 		}, nil
 	}
 
-	return corev1.Volume{}, fmt.Errorf("invalid wireguard client config")
+	return corev1.Volume{}, vpn.Invalid("config %s must reference a secret or config map key", c.Name)
+}
+
+// deleteControlled deletes the objects that exist and are controlled by wg
+func (r *WireguardClientReconciler) deleteControlled(ctx context.Context, wg *corev1alpha1.WireguardClient, objs ...client.Object) error {
+	return deleteControlled(ctx, r.Client, wg, objs...)
 }
 
 func (r *WireguardClientReconciler) FinalizerOperations(ctx context.Context, wg *corev1alpha1.WireguardClient) error {
@@ -337,9 +770,32 @@ func (r *WireguardClientReconciler) FinalizerOperations(ctx context.Context, wg
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *WireguardClientReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	ctx := context.Background()
+	indexer := mgr.GetFieldIndexer()
+	if err := indexer.IndexField(ctx, &corev1alpha1.WireguardClient{}, ConfigSecretIndex, indexConfigSecrets); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &corev1alpha1.WireguardClient{}, ConfigMapIndex, indexConfigMaps); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &corev1alpha1.WireguardClient{}, InboundProtonVPNConfigIndex, indexInboundProtonVPNConfigs); err != nil {
+		return err
+	}
+	if err := indexer.IndexField(ctx, &corev1alpha1.WireguardClient{}, InboundServiceIndex, indexInboundServices); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1alpha1.WireguardClient{}).
 		Named("core-wireguardclient").
 		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Secret{}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.Service{}).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(podToWireguardClient)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.clientsReferencing(ConfigSecretIndex))).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.clientsReferencing(ConfigMapIndex))).
+		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(r.clientsReferencing(InboundServiceIndex))).
+		Watches(&corev1alpha1.ProtonVPNConfig{}, handler.EnqueueRequestsFromMapFunc(r.clientsReferencing(InboundProtonVPNConfigIndex))).
 		Complete(r)
 }
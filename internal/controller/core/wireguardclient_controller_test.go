@@ -18,20 +18,28 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
 )
 
 var _ = Describe("WireguardClient Controller", func() {
@@ -127,12 +135,18 @@ var _ = Describe("WireguardClient Controller", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			By("Cleaning up the specific resource instance WireguardClient")
+			if controllerutil.RemoveFinalizer(resource, WireguardClientFinalizer) {
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			}
 			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
 
 			By("Removing any dangling deployments")
 			deployment := &appsv1.Deployment{}
 			err = k8sClient.Get(ctx, typeNamespacedName, deployment)
 			Expect(client.IgnoreNotFound(err)).NotTo(HaveOccurred())
+			if err == nil {
+				Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+			}
 
 			By("Cleaning up everything else")
 			Expect(k8sClient.Delete(ctx, configMap)).To(Succeed())
@@ -163,13 +177,16 @@ var _ = Describe("WireguardClient Controller", func() {
 			)))
 
 			Expect(deployment.Spec.Replicas).To(Equal(ptr.To[int32](1)))
-			Expect(deployment.Spec.Selector.MatchLabels).To(And(
-				HaveKeyWithValue("app.kubernetes.io/name", "wireguard"),
-				HaveKeyWithValue("app.kubernetes.io/version", "latest"),
-				HaveKeyWithValue("app.kubernetes.io/managed-by", "WireguardClientController"),
-			))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			Expect(deployment.Spec.Selector.MatchLabels).To(Equal(map[string]string{
+				"app.kubernetes.io/name":     "wireguard",
+				"app.kubernetes.io/instance": resourceName,
+				WireguardClientUIDLabel:      string(wireguardclient.UID),
+			}))
 			Expect(deployment.Spec.Template.Labels).To(And(
 				HaveKeyWithValue("app.kubernetes.io/name", "wireguard"),
+				HaveKeyWithValue("app.kubernetes.io/instance", resourceName),
+				HaveKeyWithValue(WireguardClientUIDLabel, string(wireguardclient.UID)),
 				HaveKeyWithValue("app.kubernetes.io/version", "latest"),
 				HaveKeyWithValue("app.kubernetes.io/managed-by", "WireguardClientController"),
 			))
@@ -235,11 +252,1083 @@ var _ = Describe("WireguardClient Controller", func() {
 				HaveField("Type", TypeAvailableWireguardClient), &conditions,
 			))
 			Expect(conditions).To(HaveLen(1), "Multiple conditions of type %s", TypeAvailableWireguardClient)
-			Expect(conditions[0].Status).To(Equal(metav1.ConditionTrue), "condition %s", TypeAvailableWireguardClient)
-			Expect(conditions[0].Reason).To(Equal("Reconciling"), "condition %s", TypeAvailableWireguardClient)
+			Expect(conditions[0].Status).To(Equal(metav1.ConditionFalse), "condition %s", TypeAvailableWireguardClient)
+			Expect(conditions[0].Reason).To(Equal("PodsNotReady"), "condition %s", TypeAvailableWireguardClient)
+			Expect(wireguardclient.Status.ObservedGeneration).To(Equal(wireguardclient.Generation))
+			Expect(wireguardclient.Status.ReadyPods).To(BeEmpty())
 
 			By("Checking that the finalizer was added")
 			Expect(wireguardclient.Finalizers).To(ConsistOf(WireguardClientFinalizer))
 		})
+
+		When("the deployment has rolled out", func() {
+			var controllerReconciler *WireguardClientReconciler
+
+			BeforeEach(func(ctx context.Context) {
+				controllerReconciler = &WireguardClientReconciler{
+					Client: k8sClient,
+					Scheme: k8sClient.Scheme(),
+				}
+			})
+
+			// rollOut reconciles the client and simulates the deployment controller
+			// and kubelet reporting a pod with the given container state
+			rollOut := func(ctx context.Context, ready bool, state corev1.ContainerState) {
+				By("Reconciling the created resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				By("Reporting the deployment status")
+				deployment := &appsv1.Deployment{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+				readyReplicas, available := int32(0), corev1.ConditionFalse
+				if ready {
+					readyReplicas, available = 1, corev1.ConditionTrue
+				}
+				deployment.Status = appsv1.DeploymentStatus{
+					ObservedGeneration: deployment.Generation,
+					Replicas:           1,
+					UpdatedReplicas:    1,
+					ReadyReplicas:      readyReplicas,
+					AvailableReplicas:  readyReplicas,
+					Conditions: []appsv1.DeploymentCondition{
+						{Type: appsv1.DeploymentAvailable, Status: available, Reason: "MinimumReplicasAvailable"},
+						{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionTrue, Reason: "NewReplicaSetAvailable"},
+					},
+				}
+				Expect(k8sClient.Status().Update(ctx, deployment)).To(Succeed())
+
+				By("Creating a client pod")
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      resourceName + "-abc12",
+						Namespace: "default",
+						Labels:    deployment.Spec.Template.Labels,
+					},
+					Spec: deployment.Spec.Template.Spec,
+				}
+				Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+				DeferCleanup(func(ctx context.Context) {
+					Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+				})
+
+				podReady := corev1.ConditionFalse
+				if ready {
+					podReady = corev1.ConditionTrue
+				}
+				pod.Status = corev1.PodStatus{
+					Phase:      corev1.PodRunning,
+					Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: podReady}},
+					ContainerStatuses: []corev1.ContainerStatus{{
+						Name:    "wireguard",
+						Ready:   ready,
+						State:   state,
+						Image:   "lscr.io/linuxserver/wireguard:latest",
+						ImageID: "lscr.io/linuxserver/wireguard@sha256:0123456789abcdef",
+					}},
+				}
+				Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+
+				By("Reconciling the resource again")
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			}
+
+			It("should be available", func(ctx context.Context) {
+				rollOut(ctx, true, corev1.ContainerState{
+					Running: &corev1.ContainerStateRunning{},
+				})
+
+				conditions := wireguardclient.Status.Conditions
+				Expect(meta.IsStatusConditionTrue(conditions, TypeAvailableWireguardClient)).To(BeTrue())
+				Expect(meta.IsStatusConditionFalse(conditions, TypeProgressingWireguardClient)).To(BeTrue())
+				Expect(meta.IsStatusConditionFalse(conditions, TypeDegradedWireguardClient)).To(BeTrue())
+				Expect(wireguardclient.Status.ReadyPods).To(ConsistOf(resourceName + "-abc12"))
+				Expect(wireguardclient.Status.LastFailureReason).To(BeEmpty())
+				Expect(wireguardclient.Status.ImageID).To(Equal("lscr.io/linuxserver/wireguard@sha256:0123456789abcdef"))
+			})
+
+			It("should report crash-looping pods", func(ctx context.Context) {
+				rollOut(ctx, false, corev1.ContainerState{
+					Waiting: &corev1.ContainerStateWaiting{
+						Reason:  "CrashLoopBackOff",
+						Message: "back-off restarting failed container",
+					},
+				})
+
+				conditions := wireguardclient.Status.Conditions
+				Expect(meta.IsStatusConditionFalse(conditions, TypeAvailableWireguardClient)).To(BeTrue())
+				degraded := meta.FindStatusCondition(conditions, TypeDegradedWireguardClient)
+				Expect(degraded).NotTo(BeNil())
+				Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+				Expect(degraded.Reason).To(Equal("CrashLoopBackOff"))
+				Expect(wireguardclient.Status.ReadyPods).To(BeEmpty())
+				Expect(wireguardclient.Status.LastFailureReason).To(Equal("CrashLoopBackOff"))
+			})
+		})
+
+		It("should recreate deployments with outdated selectors", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("Creating a deployment with the legacy selector")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			legacy := map[string]string{
+				"app.kubernetes.io/name":       "wireguard",
+				"app.kubernetes.io/version":    "latest",
+				"app.kubernetes.io/managed-by": "WireguardClientController",
+			}
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: legacy},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: legacy},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name:  "wireguard",
+								Image: "lscr.io/linuxserver/wireguard:latest",
+							}},
+						},
+					},
+				},
+			}
+			Expect(ctrl.SetControllerReference(wireguardclient, deployment, k8sClient.Scheme())).To(Succeed())
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			By("Reconciling the resource")
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).NotTo(BeZero())
+
+			By("Checking that the status reports the migration")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			cond := meta.FindStatusCondition(wireguardclient.Status.Conditions, TypeAvailableWireguardClient)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Reason).To(Equal("Recreating"))
+
+			By("Reconciling the resource again")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking that the deployment was recreated")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Selector.MatchLabels).To(HaveKeyWithValue(
+				WireguardClientUIDLabel, string(wireguardclient.UID),
+			))
+		})
+
+		It("should roll out spec changes", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Updating the spec")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			wireguardclient.Spec.TZ = "Europe/London"
+			wireguardclient.Spec.AllowedIPs = []string{"10.0.0.0/8"}
+			wireguardclient.Spec.ReadOnly = ptr.To(true)
+			wireguardclient.Spec.Configs = wireguardclient.Spec.Configs[:1]
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+
+			By("Reconciling the updated resource")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking that the deployment was updated")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.Volumes).To(HaveLen(1))
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.Env).To(ContainElements(
+				corev1.EnvVar{Name: "TZ", Value: "Europe/London"},
+				corev1.EnvVar{Name: "ALLOWEDIPS", Value: "10.0.0.0/8"},
+			))
+			Expect(container.VolumeMounts).To(HaveLen(1))
+			Expect(container.SecurityContext.ReadOnlyRootFilesystem).To(Equal(ptr.To(true)))
+		})
+
+		It("should merge pod template overrides", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("Setting a pod template override")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			wireguardclient.Spec.PodTemplate = &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"app.kubernetes.io/instance": "hijacked", "team": "net"},
+					Annotations: map[string]string{"example.com/tunnel": "true"},
+				},
+				Spec: corev1.PodSpec{
+					NodeSelector:      map[string]string{"example.com/egress": "true"},
+					PriorityClassName: "tunnels",
+					Tolerations: []corev1.Toleration{{
+						Key:      "egress",
+						Operator: corev1.TolerationOpExists,
+					}},
+					Containers: []corev1.Container{{
+						Name: "wireguard",
+						Env:  []corev1.EnvVar{{Name: "EXTRA", Value: "1"}},
+						Resources: corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("64Mi"),
+							},
+						},
+					}},
+				},
+			}
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+
+			By("Reconciling the resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking that the overrides were merged")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			template := deployment.Spec.Template
+			Expect(template.Labels).To(And(
+				HaveKeyWithValue("app.kubernetes.io/instance", resourceName),
+				HaveKeyWithValue("team", "net"),
+			))
+			Expect(template.Annotations).To(HaveKeyWithValue("example.com/tunnel", "true"))
+			Expect(template.Spec.NodeSelector).To(HaveKeyWithValue("example.com/egress", "true"))
+			Expect(template.Spec.PriorityClassName).To(Equal("tunnels"))
+			Expect(template.Spec.Tolerations).To(HaveLen(1))
+			Expect(template.Spec.Volumes).To(HaveLen(2))
+
+			Expect(template.Spec.Containers).To(HaveLen(1))
+			container := template.Spec.Containers[0]
+			Expect(container.Image).To(Equal("lscr.io/linuxserver/wireguard:latest"))
+			Expect(container.Env).To(ContainElements(
+				corev1.EnvVar{Name: "TZ", Value: "America/Chicago"},
+				corev1.EnvVar{Name: "EXTRA", Value: "1"},
+			))
+			Expect(container.Resources.Limits.Memory().String()).To(Equal("64Mi"))
+		})
+
+		It("should use the configured image", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client:       k8sClient,
+				Scheme:       k8sClient.Scheme(),
+				DefaultImage: "example.com/wireguard:default",
+			}
+
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking that the default image is used")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("example.com/wireguard:default"))
+			Expect(deployment.Spec.Template.Labels).To(HaveKeyWithValue("app.kubernetes.io/version", "default"))
+
+			By("Pinning the image")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			wireguardclient.Spec.Image = "lscr.io/linuxserver/wireguard:1.0.20250521"
+			wireguardclient.Spec.ImagePullPolicy = corev1.PullIfNotPresent
+			wireguardclient.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "registry"}}
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+
+			By("Reconciling the updated resource")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking that the pinned image is used")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.Image).To(Equal("lscr.io/linuxserver/wireguard:1.0.20250521"))
+			Expect(container.ImagePullPolicy).To(Equal(corev1.PullIfNotPresent))
+			Expect(deployment.Spec.Template.Spec.ImagePullSecrets).To(ConsistOf(
+				corev1.LocalObjectReference{Name: "registry"},
+			))
+			Expect(deployment.Spec.Template.Labels).To(HaveKeyWithValue("app.kubernetes.io/version", "1.0.20250521"))
+		})
+
+		It("should roll pods when a referenced config changes", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			hash := deployment.Spec.Template.Annotations[ConfigHashAnnotation]
+			Expect(hash).NotTo(BeEmpty())
+
+			By("Checking that the client is indexed by its configs")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			Expect(indexConfigSecrets(wireguardclient)).To(ConsistOf(secret.Name))
+			Expect(indexConfigMaps(wireguardclient)).To(ConsistOf(configMap.Name))
+
+			By("Editing the referenced secret")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			secret.Data["client.conf"] = []byte("new config")
+			Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+
+			By("Reconciling the resource again")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking that the pod template changed")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey(ConfigHashAnnotation))
+			Expect(deployment.Spec.Template.Annotations[ConfigHashAnnotation]).NotTo(Equal(hash))
+		})
+
+		It("should render inline configs", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			privateKey, err := wireguard.GeneratePrivateKey()
+			Expect(err).NotTo(HaveOccurred())
+			peerKey, err := wireguard.GeneratePrivateKey()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Adding an inline config")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			wireguardclient.Spec.Configs = append(wireguardclient.Spec.Configs, corev1alpha1.WireguardClientConfig{
+				Name: "test-inline",
+				Inline: &corev1alpha1.WireguardInlineConfig{
+					Interface: corev1alpha1.WireguardInterface{
+						Address: []string{"10.0.0.2/32"},
+						DNS:     []string{"10.0.0.1"},
+						MTU:     ptr.To[int32](1420),
+						PrivateKeySecretRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name},
+							Key:                  "privateKey",
+						},
+					},
+					Peers: []corev1alpha1.WireguardInlinePeer{{
+						PublicKey:           peerKey.PublicKey().String(),
+						Endpoint:            "vpn.example.com:51820",
+						AllowedIPs:          []string{"0.0.0.0/0"},
+						PersistentKeepalive: ptr.To[int32](25),
+					}},
+				},
+			})
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+			Expect(indexConfigSecrets(wireguardclient)).To(ConsistOf(
+				secret.Name, secret.Name,
+				InlineConfigSecretName(wireguardclient, wireguardclient.Spec.Configs[2]),
+				PresharedKeySecretName(wireguardclient, wireguardclient.Spec.Configs[2]),
+			))
+
+			By("Reconciling before the private key exists")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			available := meta.FindStatusCondition(wireguardclient.Status.Conditions, TypeAvailableWireguardClient)
+			Expect(available).NotTo(BeNil())
+			Expect(available.Status).To(Equal(metav1.ConditionFalse))
+			Expect(available.Reason).To(Equal("InvalidConfig"))
+
+			By("Adding the private key")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			secret.Data["privateKey"] = []byte(privateKey.String())
+			Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+
+			By("Reconciling the resource again")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the rendered secret")
+			rendered := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      InlineConfigSecretName(wireguardclient, wireguardclient.Spec.Configs[2]),
+				Namespace: "default",
+			}, rendered)).To(Succeed())
+			Expect(metav1.IsControlledBy(rendered, wireguardclient)).To(BeTrue())
+
+			config, err := wireguard.Parse(string(rendered.Data["wg0.conf"]))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Interface.PrivateKey).To(Equal(privateKey.String()))
+			Expect(config.Interface.Address).To(ConsistOf("10.0.0.2/32"))
+			Expect(config.Interface.MTU).To(Equal(1420))
+			Expect(config.Peers).To(ConsistOf(wireguard.Peer{
+				PublicKey:           peerKey.PublicKey().String(),
+				Endpoint:            "vpn.example.com:51820",
+				AllowedIPs:          []string{"0.0.0.0/0"},
+				PersistentKeepalive: 25,
+			}))
+
+			By("Checking that the rendered secret is mounted")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.Volumes).To(ContainElement(SatisfyAll(
+				HaveField("Name", "test-inline"),
+				HaveField("Secret.SecretName", rendered.Name),
+			)))
+
+			Expect(k8sClient.Delete(ctx, rendered)).To(Succeed())
+		})
+
+		It("should give inline config secrets unique names", func() {
+			name := func(client, config string) (string, string) {
+				wg := &corev1alpha1.WireguardClient{ObjectMeta: metav1.ObjectMeta{Name: client}}
+				c := corev1alpha1.WireguardClientConfig{Name: config}
+				return InlineConfigSecretName(wg, c), PresharedKeySecretName(wg, c)
+			}
+
+			names := map[string]struct{}{}
+			for _, pair := range [][2]string{
+				{"a-b", "c"},
+				{"a", "b-c"},
+				{"a", "b-psk"},
+				{"a", "b"},
+			} {
+				inline, psk := name(pair[0], pair[1])
+				Expect(names).NotTo(HaveKey(inline))
+				names[inline] = struct{}{}
+				Expect(names).NotTo(HaveKey(psk))
+				names[psk] = struct{}{}
+			}
+
+			long, _ := name(strings.Repeat("a", 253), "config")
+			Expect(len(long)).To(BeNumerically("<=", 253))
+		})
+
+		It("should not overwrite secrets it doesn't control", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			privateKey, err := wireguard.GeneratePrivateKey()
+			Expect(err).NotTo(HaveOccurred())
+			peerKey, err := wireguard.GeneratePrivateKey()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Adding an inline config")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			secret.Data["privateKey"] = []byte(privateKey.String())
+			Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			inline := corev1alpha1.WireguardClientConfig{
+				Name: "test-conflict",
+				Inline: &corev1alpha1.WireguardInlineConfig{
+					Interface: corev1alpha1.WireguardInterface{
+						Address: []string{"10.0.0.2/32"},
+						PrivateKeySecretRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name},
+							Key:                  "privateKey",
+						},
+					},
+					Peers: []corev1alpha1.WireguardInlinePeer{{
+						PublicKey:  peerKey.PublicKey().String(),
+						AllowedIPs: []string{"0.0.0.0/0"},
+					}},
+				},
+			}
+			wireguardclient.Spec.Configs = append(wireguardclient.Spec.Configs, inline)
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+
+			By("Creating a secret with the rendered secret's name")
+			existing := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      InlineConfigSecretName(wireguardclient, inline),
+					Namespace: "default",
+				},
+				StringData: map[string]string{"wg0.conf": "unrelated"},
+			}
+			Expect(k8sClient.Create(ctx, existing)).To(Succeed())
+			DeferCleanup(func(ctx context.Context) {
+				Expect(k8sClient.Delete(ctx, existing)).To(Succeed())
+			})
+
+			By("Reconciling the resource")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(existing), existing)).To(Succeed())
+			Expect(existing.Data).To(HaveKeyWithValue("wg0.conf", []byte("unrelated")))
+			Expect(existing.OwnerReferences).To(BeEmpty())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			available := meta.FindStatusCondition(wireguardclient.Status.Conditions, TypeAvailableWireguardClient)
+			Expect(available).NotTo(BeNil())
+			Expect(available.Status).To(Equal(metav1.ConditionFalse))
+			Expect(available.Reason).To(Equal("InvalidConfig"))
+		})
+
+		It("should generate and rotate preshared keys", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			privateKey, err := wireguard.GeneratePrivateKey()
+			Expect(err).NotTo(HaveOccurred())
+			peerKey, err := wireguard.GeneratePrivateKey()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Adding an inline config with a generated preshared key")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			secret.Data["privateKey"] = []byte(privateKey.String())
+			Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			inline := corev1alpha1.WireguardClientConfig{
+				Name: "test-psk",
+				Inline: &corev1alpha1.WireguardInlineConfig{
+					Interface: corev1alpha1.WireguardInterface{
+						Address: []string{"10.0.0.2/32"},
+						PrivateKeySecretRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name},
+							Key:                  "privateKey",
+						},
+					},
+					Peers: []corev1alpha1.WireguardInlinePeer{{
+						PublicKey:            peerKey.PublicKey().String(),
+						AllowedIPs:           []string{"0.0.0.0/0"},
+						GeneratePresharedKey: true,
+					}},
+				},
+			}
+			wireguardclient.Spec.Configs = append(wireguardclient.Spec.Configs, inline)
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+
+			By("Reconciling the resource")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			renderedPSK := func() string {
+				GinkgoHelper()
+				psks := &corev1.Secret{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{
+					Name:      PresharedKeySecretName(wireguardclient, inline),
+					Namespace: "default",
+				}, psks)).To(Succeed())
+				Expect(metav1.IsControlledBy(psks, wireguardclient)).To(BeTrue())
+				psk := string(psks.Data[PresharedKeyName(peerKey.PublicKey().String())])
+				Expect(psk).NotTo(BeEmpty())
+
+				rendered := &corev1.Secret{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{
+					Name:      InlineConfigSecretName(wireguardclient, inline),
+					Namespace: "default",
+				}, rendered)).To(Succeed())
+				config, err := wireguard.Parse(string(rendered.Data["wg0.conf"]))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Peers).To(HaveLen(1))
+				Expect(config.Peers[0].PresharedKey).To(Equal(psk))
+
+				return psk
+			}
+
+			psk := renderedPSK()
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			hash := deployment.Spec.Template.Annotations[ConfigHashAnnotation]
+
+			By("Reconciling without a rotation")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(renderedPSK()).To(Equal(psk))
+
+			By("Requesting a rotation")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			wireguardclient.Annotations = map[string]string{RotateAnnotation: "1"}
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking that the key was rotated and the pods rolled")
+			Expect(renderedPSK()).NotTo(Equal(psk))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			Expect(wireguardclient.Status.ObservedRotate).To(Equal("1"))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations[ConfigHashAnnotation]).NotTo(Equal(hash))
+
+			for _, name := range []string{
+				InlineConfigSecretName(wireguardclient, inline),
+				PresharedKeySecretName(wireguardclient, inline),
+			} {
+				Expect(k8sClient.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+				}})).To(Succeed())
+			}
+		})
+
+		It("should run as a gateway", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("Enabling gateway mode")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			wireguardclient.Spec.Gateway = &corev1alpha1.WireguardClientGateway{
+				NamespaceSelector: metav1.LabelSelector{
+					MatchLabels: map[string]string{"routed": "true"},
+				},
+				LocalCIDRs: []string{"10.42.0.0/16", "10.43.0.0/16"},
+			}
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+			Expect(wireguardclient.Spec.Gateway.VXLANID).To(Equal(int32(42)))
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			gatewayName := types.NamespacedName{Name: GatewayName(wireguardclient), Namespace: "default"}
+
+			By("Checking the gateway settings")
+			settings := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, gatewayName, settings)).To(Succeed())
+			Expect(metav1.IsControlledBy(settings, wireguardclient)).To(BeTrue())
+			Expect(settings.Data[GatewaySettingsKey]).To(SatisfyAll(
+				ContainSubstring(`GATEWAY_NAME="test-resource-gateway.default.svc.cluster.local"`),
+				ContainSubstring(`NOT_ROUTED_TO_GATEWAY_CIDRS="10.42.0.0/16 10.43.0.0/16"`),
+				ContainSubstring(`VXLAN_ID="42"`),
+				ContainSubstring(`VPN_INTERFACE="test-config"`),
+			))
+
+			By("Checking the gateway service")
+			svc := &corev1.Service{}
+			Expect(k8sClient.Get(ctx, gatewayName, svc)).To(Succeed())
+			Expect(svc.Spec.ClusterIP).To(Equal(corev1.ClusterIPNone))
+			Expect(svc.Spec.Selector).To(Equal(SelectorLabels(wireguardclient)))
+
+			By("Checking the gateway containers")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			spec := deployment.Spec.Template.Spec
+			Expect(spec.InitContainers).To(ConsistOf(SatisfyAll(
+				HaveField("Name", GatewayInitContainerName),
+				HaveField("Command", []string{"/bin/gateway_init.sh"}),
+				HaveField("SecurityContext.Privileged", HaveValue(BeTrue())),
+			)))
+			Expect(spec.Containers).To(ContainElement(SatisfyAll(
+				HaveField("Name", GatewayContainerName),
+				HaveField("Command", []string{"/bin/gateway_sidecar.sh"}),
+			)))
+			Expect(spec.Volumes).To(ContainElement(HaveField("ConfigMap.Name", gatewayName.Name)))
+
+			By("Disabling gateway mode")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			wireguardclient.Spec.Gateway = nil
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, gatewayName, settings))).To(BeTrue())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, gatewayName, svc))).To(BeTrue())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.InitContainers).To(BeEmpty())
+		})
+
+		It("should expose a proxy", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("Enabling the proxy")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			wireguardclient.Spec.Proxy = &corev1alpha1.WireguardClientProxy{
+				AuthSecretRef: &corev1.LocalObjectReference{Name: secret.Name},
+			}
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+			Expect(indexConfigSecrets(wireguardclient)).To(ConsistOf(secret.Name, secret.Name))
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			proxyName := types.NamespacedName{Name: ProxyName(wireguardclient), Namespace: "default"}
+
+			By("Checking the proxy service")
+			svc := &corev1.Service{}
+			Expect(k8sClient.Get(ctx, proxyName, svc)).To(Succeed())
+			Expect(metav1.IsControlledBy(svc, wireguardclient)).To(BeTrue())
+			Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
+			Expect(svc.Spec.Selector).To(Equal(SelectorLabels(wireguardclient)))
+			Expect(svc.Spec.Ports).To(ConsistOf(
+				SatisfyAll(HaveField("Name", "socks"), HaveField("Port", int32(1080))),
+				SatisfyAll(HaveField("Name", "http"), HaveField("Port", int32(8080))),
+			))
+
+			By("Checking the proxy container")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.Containers).To(ContainElement(SatisfyAll(
+				HaveField("Name", ProxyContainerName),
+				HaveField("Image", DefaultProxyImage),
+				HaveField("Args", []string{
+					"-L", "socks5://$(PROXY_USERNAME):$(PROXY_PASSWORD)@:1080",
+					"-L", "http://$(PROXY_USERNAME):$(PROXY_PASSWORD)@:8080",
+				}),
+				HaveField("Env", ContainElement(SatisfyAll(
+					HaveField("Name", "PROXY_PASSWORD"),
+					HaveField("ValueFrom.SecretKeyRef.Key", corev1.BasicAuthPasswordKey),
+				))),
+			)))
+
+			By("Disabling the proxy")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			wireguardclient.Spec.Proxy = nil
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, proxyName, svc))).To(BeTrue())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.Containers).To(HaveLen(1))
+		})
+
+		It("should forward inbound ports", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("Creating the target service")
+			target := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "inbound-target",
+					Namespace: "default",
+				},
+				Spec: corev1.ServiceSpec{
+					Selector: map[string]string{"app": "inbound-target"},
+					Ports:    []corev1.ServicePort{{Port: 8080}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, target)).To(Succeed())
+			DeferCleanup(func(ctx context.Context) {
+				Expect(k8sClient.Delete(ctx, target)).To(Succeed())
+			})
+
+			By("Adding an inbound rule")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			wireguardclient.Spec.Inbound = []corev1alpha1.WireguardClientInbound{{
+				Name: "web",
+				Port: 51413,
+				Service: corev1alpha1.WireguardClientInboundService{
+					Name: target.Name,
+					Port: 8080,
+				},
+			}}
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+			Expect(indexInboundServices(wireguardclient)).To(ConsistOf(target.Name))
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			inboundName := types.NamespacedName{Name: InboundName(wireguardclient), Namespace: "default"}
+
+			By("Checking the inbound rules")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: target.Name, Namespace: "default"}, target)).To(Succeed())
+			cm := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, inboundName, cm)).To(Succeed())
+			Expect(metav1.IsControlledBy(cm, wireguardclient)).To(BeTrue())
+			Expect(cm.Data).To(HaveKeyWithValue(InboundRulesKey,
+				fmt.Sprintf("tcp 51413 %s:8080", target.Spec.ClusterIP),
+			))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			Expect(wireguardclient.Status.Inbound).To(ConsistOf("web: TCP/51413 -> inbound-target:8080"))
+
+			By("Checking the inbound containers")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.InitContainers).To(ContainElement(
+				HaveField("Name", InboundInitContainerName),
+			))
+			Expect(deployment.Spec.Template.Spec.Containers).To(ContainElement(SatisfyAll(
+				HaveField("Name", InboundContainerName),
+				HaveField("Env", ContainElement(HaveField("Name", "TUNNEL_INTERFACE"))),
+			)))
+			Expect(deployment.Spec.Template.Spec.Volumes).To(ContainElement(
+				HaveField("ConfigMap.Name", InboundName(wireguardclient)),
+			))
+
+			By("Removing the inbound rule")
+			wireguardclient.Spec.Inbound = nil
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, inboundName, cm))).To(BeTrue())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.Containers).To(HaveLen(1))
+		})
+
+		It("should skip inbound rules targeting headless services", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("Creating a headless target service")
+			target := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "inbound-headless",
+					Namespace: "default",
+				},
+				Spec: corev1.ServiceSpec{
+					ClusterIP: corev1.ClusterIPNone,
+					Selector:  map[string]string{"app": "inbound-headless"},
+					Ports:     []corev1.ServicePort{{Port: 8080}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, target)).To(Succeed())
+			DeferCleanup(func(ctx context.Context) {
+				Expect(k8sClient.Delete(ctx, target)).To(Succeed())
+			})
+
+			By("Adding an inbound rule")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			wireguardclient.Spec.Inbound = []corev1alpha1.WireguardClientInbound{{
+				Name: "web",
+				Port: 51413,
+				Service: corev1alpha1.WireguardClientInboundService{
+					Name: target.Name,
+					Port: 8080,
+				},
+			}}
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking that the rule was skipped")
+			cm := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      InboundName(wireguardclient),
+				Namespace: "default",
+			}, cm)).To(Succeed())
+			DeferCleanup(func(ctx context.Context) {
+				Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
+			})
+			Expect(cm.Data).To(HaveKeyWithValue(InboundRulesKey, BeEmpty()))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			Expect(wireguardclient.Status.Inbound).To(BeEmpty())
+			available := meta.FindStatusCondition(wireguardclient.Status.Conditions, TypeAvailableWireguardClient)
+			Expect(available).NotTo(BeNil())
+			Expect(available.Reason).NotTo(Equal("InvalidConfig"))
+		})
+
+		It("should run the NAT-PMP sidecar for forwarded ports", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client:      k8sClient,
+				Scheme:      k8sClient.Scheme(),
+				NATPMPImage: "example.com/operator:latest",
+			}
+
+			By("Adding inbound rules that follow a ProtonVPN config")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			for _, protocol := range []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP} {
+				wireguardclient.Spec.Inbound = append(wireguardclient.Spec.Inbound, corev1alpha1.WireguardClientInbound{
+					Name:               strings.ToLower(string(protocol)),
+					ProtonVPNConfigRef: &corev1.LocalObjectReference{Name: "test-protonvpn"},
+					Protocol:           protocol,
+					Service: corev1alpha1.WireguardClientInboundService{
+						Name: "inbound-target",
+						Port: 8080,
+					},
+				})
+			}
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the sidecar")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.ServiceAccountName).To(Equal(NATPMPName(wireguardclient)))
+			Expect(deployment.Spec.Template.Spec.Containers).To(ContainElement(SatisfyAll(
+				HaveField("Name", NATPMPContainerName),
+				HaveField("Image", "example.com/operator:latest"),
+				HaveField("Args", ConsistOf("test-protonvpn")),
+			)))
+
+			By("Checking the sidecar may only report the followed config")
+			natpmpName := types.NamespacedName{Name: NATPMPName(wireguardclient), Namespace: "default"}
+			role := &rbacv1.Role{}
+			Expect(k8sClient.Get(ctx, natpmpName, role)).To(Succeed())
+			Expect(metav1.IsControlledBy(role, wireguardclient)).To(BeTrue())
+			Expect(role.Rules).To(HaveEach(HaveField("ResourceNames", ConsistOf("test-protonvpn"))))
+			binding := &rbacv1.RoleBinding{}
+			Expect(k8sClient.Get(ctx, natpmpName, binding)).To(Succeed())
+			Expect(binding.Subjects).To(ConsistOf(HaveField("Name", NATPMPName(wireguardclient))))
+
+			By("Removing the inbound rules")
+			wireguardclient.Spec.Inbound = nil
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, natpmpName, role))).To(BeTrue())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, natpmpName, binding))).To(BeTrue())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.Containers).To(HaveLen(1))
+		})
+
+		It("should reject configs with more than one source", func(ctx context.Context) {
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			wireguardclient.Spec.Configs[0].Inline = &corev1alpha1.WireguardInlineConfig{
+				Interface: corev1alpha1.WireguardInterface{
+					Address: []string{"10.0.0.2/32"},
+					PrivateKeySecretRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: secret.Name},
+						Key:                  "privateKey",
+					},
+				},
+				Peers: []corev1alpha1.WireguardInlinePeer{{
+					PublicKey:  "GVCXCb1fWmPw2sFHgGmEu+ZTvu9BWbZuTNz7N6Kk6VA=",
+					AllowedIPs: []string{"0.0.0.0/0"},
+				}},
+			}
+
+			err := k8sClient.Update(ctx, wireguardclient)
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("exactly one of valueFrom or inline must be set"))
+		})
+
+		It("should not run a pod for sidecar only clients", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, &appsv1.Deployment{})).To(Succeed())
+
+			By("Making the client sidecar only")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			wireguardclient.Spec.SidecarOnly = true
+			Expect(k8sClient.Update(ctx, wireguardclient)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking that the deployment was deleted")
+			err = k8sClient.Get(ctx, typeNamespacedName, &appsv1.Deployment{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, wireguardclient)).To(Succeed())
+			available := meta.FindStatusCondition(wireguardclient.Status.Conditions, TypeAvailableWireguardClient)
+			Expect(available).NotTo(BeNil())
+			Expect(available.Status).To(Equal(metav1.ConditionTrue))
+			Expect(available.Reason).To(Equal("SidecarOnly"))
+		})
+
+		It("should correct manual drift", func(ctx context.Context) {
+			controllerReconciler := &WireguardClientReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Editing the deployment")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			deployment.Spec.Replicas = ptr.To[int32](3)
+			deployment.Spec.Template.Spec.Containers[0].Image = "example.com/wireguard:drift"
+			Expect(k8sClient.Update(ctx, deployment)).To(Succeed())
+
+			By("Reconciling the resource again")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking that the drift was reverted")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(deployment.Spec.Replicas).To(Equal(ptr.To[int32](1)))
+			Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("lscr.io/linuxserver/wireguard:latest"))
+			Expect(deployment.ManagedFields).To(ContainElement(
+				HaveField("Manager", WireguardClientFieldOwner),
+			))
+		})
 	})
 })
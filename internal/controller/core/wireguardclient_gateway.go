@@ -0,0 +1,218 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+)
+
+const (
+	// DefaultGatewayImage is the pod-gateway image used by gateways that don't specify one
+	DefaultGatewayImage = "ghcr.io/angelnu/pod-gateway:v1.13.0"
+
+	// GatewaySettingsKey is the key the pod-gateway settings are stored under
+	GatewaySettingsKey = "settings.sh"
+
+	// GatewayInitContainerName is the name of the container that sets up the
+	// overlay in gateway and routed pods
+	GatewayInitContainerName = "wireguard-gateway-init"
+
+	// GatewayContainerName is the name of the container that keeps the overlay running
+	GatewayContainerName = "wireguard-gateway"
+
+	defaultGatewayVXLANID       = 42
+	defaultGatewayVXLANNetwork  = "172.16.0"
+	defaultGatewayClusterDomain = "cluster.local"
+
+	gatewaySettingsPath = "/config"
+)
+
+// GatewayName returns the name of the service and settings of the gateway of wg
+func GatewayName(wg *corev1alpha1.WireguardClient) string {
+	return fmt.Sprintf("%s-gateway", wg.Name)
+}
+
+// GatewaySettings renders the pod-gateway settings of the gateway of wg
+func GatewaySettings(wg *corev1alpha1.WireguardClient) string {
+	g := wg.Spec.Gateway
+	vxlanID, network, domain := g.VXLANID, g.VXLANNetwork, g.ClusterDomain
+	if vxlanID == 0 {
+		vxlanID = defaultGatewayVXLANID
+	}
+	if network == "" {
+		network = defaultGatewayVXLANNetwork
+	}
+	if domain == "" {
+		domain = defaultGatewayClusterDomain
+	}
+
+	b := &strings.Builder{}
+	_, _ = fmt.Fprintf(b, "GATEWAY_NAME=%q\n", fmt.Sprintf("%s.%s.svc.%s", GatewayName(wg), wg.Namespace, domain))
+	_, _ = fmt.Fprintf(b, "NOT_ROUTED_TO_GATEWAY_CIDRS=%q\n", strings.Join(g.LocalCIDRs, " "))
+	_, _ = fmt.Fprintf(b, "VPN_LOCAL_CIDRS=%q\n", strings.Join(g.LocalCIDRs, " "))
+	_, _ = fmt.Fprintf(b, "VXLAN_ID=%q\n", fmt.Sprint(vxlanID))
+	_, _ = fmt.Fprintf(b, "VXLAN_IP_NETWORK=%q\n", network)
+	_, _ = fmt.Fprintf(b, "VPN_INTERFACE=%q\n", tunnelInterface(wg))
+	_, _ = fmt.Fprintf(b, "DNS_LOCAL_CIDRS=%q\n", domain)
+
+	return b.String()
+}
+
+// tunnelInterface returns the name of the interface of the tunnel of wg.
+// linuxserver/wireguard names interfaces after their config.
+func tunnelInterface(wg *corev1alpha1.WireguardClient) string {
+	if len(wg.Spec.Configs) > 0 {
+		return wg.Spec.Configs[0].Name
+	}
+
+	return "wg0"
+}
+
+func gatewayImage(wg *corev1alpha1.WireguardClient) string {
+	if wg.Spec.Gateway.Image != "" {
+		return wg.Spec.Gateway.Image
+	}
+
+	return DefaultGatewayImage
+}
+
+// gatewayContainers returns the pod-gateway containers of a pod running script,
+// an init container that sets up the overlay and a sidecar that maintains it
+func gatewayContainers(image, script string) (corev1.Container, corev1.Container) {
+	securityContext := &corev1.SecurityContext{
+		Capabilities: &corev1.Capabilities{
+			Add: []corev1.Capability{"NET_ADMIN", "NET_RAW"},
+		},
+		RunAsUser:    ptr.To[int64](0),
+		RunAsNonRoot: ptr.To(false),
+	}
+	mounts := []corev1.VolumeMount{{
+		Name:      corev1alpha1.GatewaySettingsVolume,
+		MountPath: gatewaySettingsPath,
+	}}
+
+	init := corev1.Container{
+		Name:            GatewayInitContainerName,
+		Image:           image,
+		Command:         []string{fmt.Sprintf("/bin/%s_init.sh", script)},
+		VolumeMounts:    mounts,
+		SecurityContext: securityContext,
+	}
+	sidecar := corev1.Container{
+		Name:            GatewayContainerName,
+		Image:           image,
+		Command:         []string{fmt.Sprintf("/bin/%s_sidecar.sh", script)},
+		VolumeMounts:    mounts,
+		SecurityContext: securityContext.DeepCopy(),
+	}
+
+	return init, sidecar
+}
+
+// NewGatewayContainers returns the containers and settings volume that turn
+// the pod of wg into a gateway. The gateway sets up ip forwarding, so its
+// init container is privileged.
+func NewGatewayContainers(wg *corev1alpha1.WireguardClient) (corev1.Container, corev1.Container, corev1.Volume) {
+	init, sidecar := gatewayContainers(gatewayImage(wg), "gateway")
+	init.SecurityContext.Privileged = ptr.To(true)
+
+	volume := corev1.Volume{
+		Name: corev1alpha1.GatewaySettingsVolume,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: GatewayName(wg)},
+			},
+		},
+	}
+
+	return init, sidecar, volume
+}
+
+// NewGatewayClientContainers returns the containers and settings volume that
+// route a pod through the gateway of wg. Routed pods may live in any namespace,
+// so the settings are passed inline and written out by the init container.
+func NewGatewayClientContainers(wg *corev1alpha1.WireguardClient) (corev1.Container, corev1.Container, corev1.Volume) {
+	init, sidecar := gatewayContainers(gatewayImage(wg), "client")
+	init.Command = []string{"/bin/sh", "-c", fmt.Sprintf(
+		`printf '%%s' "$GATEWAY_SETTINGS" > %s/%s && exec %s`,
+		gatewaySettingsPath, GatewaySettingsKey, init.Command[0],
+	)}
+	init.Env = []corev1.EnvVar{{
+		Name:  "GATEWAY_SETTINGS",
+		Value: GatewaySettings(wg),
+	}}
+	sidecar.RestartPolicy = ptr.To(corev1.ContainerRestartPolicyAlways)
+
+	volume := corev1.Volume{
+		Name: corev1alpha1.GatewaySettingsVolume,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}
+
+	return init, sidecar, volume
+}
+
+// ApplyGateway writes the settings and headless service of the gateway of wg,
+// or removes them when wg isn't a gateway. Routed pods reach the gateway pod
+// directly through the service.
+func (r *WireguardClientReconciler) ApplyGateway(ctx context.Context, wg *corev1alpha1.WireguardClient) error {
+	meta := metav1.ObjectMeta{
+		Name:      GatewayName(wg),
+		Namespace: wg.Namespace,
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: meta}
+	svc := &corev1.Service{ObjectMeta: *meta.DeepCopy()}
+
+	if wg.Spec.Gateway == nil {
+		return r.deleteControlled(ctx, wg, cm, svc)
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		cm.Data = map[string]string{
+			GatewaySettingsKey: GatewaySettings(wg),
+		}
+
+		return ctrl.SetControllerReference(wg, cm, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("writing gateway settings: %w", err)
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, svc, func() error {
+		svc.Spec.ClusterIP = corev1.ClusterIPNone
+		svc.Spec.Selector = SelectorLabels(wg)
+		svc.Spec.PublishNotReadyAddresses = true
+
+		return ctrl.SetControllerReference(wg, svc, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("writing gateway service: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+)
+
+const (
+	// InboundRulesKey is the key the resolved inbound rules are stored under
+	InboundRulesKey = "rules"
+
+	// InboundContainerName is the name of the container that keeps the DNAT rules in sync
+	InboundContainerName = "wireguard-inbound"
+
+	// InboundInitContainerName is the name of the container that enables forwarding
+	InboundInitContainerName = "wireguard-inbound-init"
+
+	// InboundProtonVPNConfigIndex indexes WireguardClients by the ProtonVPNConfigs their inbound rules follow
+	InboundProtonVPNConfigIndex = "spec.inbound.protonVPNConfigRef.name"
+
+	// InboundServiceIndex indexes WireguardClients by the services their inbound rules target
+	InboundServiceIndex = "spec.inbound.service.name"
+
+	inboundPath = "/inbound"
+)
+
+// inboundScript applies the rules in the inbound config map whenever they change.
+// The config map is mounted as a directory, so the kubelet updates it in place
+// and the pod doesn't need to restart when a forwarded port changes.
+const inboundScript = `set -eu
+chain=THECLUSTER-INBOUND
+iptables -t nat -N "$chain" 2>/dev/null || true
+iptables -t nat -C PREROUTING -i "$TUNNEL_INTERFACE" -j "$chain" 2>/dev/null ||
+  iptables -t nat -A PREROUTING -i "$TUNNEL_INTERFACE" -j "$chain"
+iptables -t nat -C POSTROUTING -m conntrack --ctstate DNAT -j MASQUERADE 2>/dev/null ||
+  iptables -t nat -A POSTROUTING -m conntrack --ctstate DNAT -j MASQUERADE
+
+applied=""
+while true; do
+  rules="$(cat ` + inboundPath + `/` + InboundRulesKey + ` 2>/dev/null || true)"
+  if [ "$rules" != "$applied" ]; then
+    iptables -t nat -F "$chain"
+    echo "$rules" | while read -r protocol port destination; do
+      [ -n "$protocol" ] || continue
+      iptables -t nat -A "$chain" -p "$protocol" --dport "$port" -j DNAT --to-destination "$destination"
+    done
+    applied="$rules"
+    echo "Applied inbound rules:"
+    echo "$rules"
+  fi
+  sleep 10
+done
+`
+
+// InboundName returns the name of the config map holding the inbound rules of wg
+func InboundName(wg *corev1alpha1.WireguardClient) string {
+	return fmt.Sprintf("%s-inbound", wg.Name)
+}
+
+// NewInboundContainers returns the containers and volume that forward the
+// inbound ports of wg. They use the wireguard image, which ships iptables.
+// Forwarding has to be enabled for the pod, so the init container is privileged.
+func NewInboundContainers(wg *corev1alpha1.WireguardClient, image string) (corev1.Container, corev1.Container, corev1.Volume) {
+	init := corev1.Container{
+		Name:    InboundInitContainerName,
+		Image:   image,
+		Command: []string{"sysctl", "-w", "net.ipv4.ip_forward=1"},
+		SecurityContext: &corev1.SecurityContext{
+			Privileged:   ptr.To(true),
+			RunAsUser:    ptr.To[int64](0),
+			RunAsNonRoot: ptr.To(false),
+		},
+	}
+
+	sidecar := corev1.Container{
+		Name:    InboundContainerName,
+		Image:   image,
+		Command: []string{"/bin/sh", "-c", inboundScript},
+		Env: []corev1.EnvVar{{
+			Name:  "TUNNEL_INTERFACE",
+			Value: tunnelInterface(wg),
+		}},
+		VolumeMounts: []corev1.VolumeMount{{
+			Name:      corev1alpha1.InboundVolume,
+			MountPath: inboundPath,
+			ReadOnly:  true,
+		}},
+		SecurityContext: &corev1.SecurityContext{
+			Capabilities: &corev1.Capabilities{
+				Add: []corev1.Capability{"NET_ADMIN", "NET_RAW"},
+			},
+			RunAsUser:    ptr.To[int64](0),
+			RunAsNonRoot: ptr.To(false),
+		},
+	}
+
+	volume := corev1.Volume{
+		Name: corev1alpha1.InboundVolume,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: InboundName(wg)},
+			},
+		},
+	}
+
+	return init, sidecar, volume
+}
+
+// ApplyInbound resolves the inbound rules of wg and writes them to the inbound
+// config map, or removes it when wg has no inbound rules. Rules whose port or
+// service isn't known yet are left out until it is.
+func (r *WireguardClientReconciler) ApplyInbound(ctx context.Context, wg *corev1alpha1.WireguardClient) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      InboundName(wg),
+			Namespace: wg.Namespace,
+		},
+	}
+	if len(wg.Spec.Inbound) == 0 {
+		wg.Status.Inbound = nil
+		return r.deleteControlled(ctx, wg, cm)
+	}
+
+	rules, status := []string{}, []string{}
+	for _, in := range wg.Spec.Inbound {
+		port, destination, err := r.resolveInbound(ctx, wg, in)
+		if err != nil {
+			return fmt.Errorf("inbound rule %s: %w", in.Name, err)
+		}
+		if port == 0 || destination == "" {
+			continue
+		}
+
+		protocol := in.Protocol
+		if protocol == "" {
+			protocol = corev1.ProtocolTCP
+		}
+		rules = append(rules, fmt.Sprintf("%s %d %s", strings.ToLower(string(protocol)), port, destination))
+		status = append(status, fmt.Sprintf("%s: %s/%d -> %s:%d",
+			in.Name, protocol, port, in.Service.Name, in.Service.Port,
+		))
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		cm.Data = map[string]string{
+			InboundRulesKey: strings.Join(rules, "\n"),
+		}
+
+		return ctrl.SetControllerReference(wg, cm, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("writing inbound rules: %w", err)
+	}
+
+	wg.Status.Inbound = status
+	return nil
+}
+
+// resolveInbound returns the tunnel port and destination of in. Either is
+// empty when the forwarded port or the service aren't available yet, or
+// when the service is headless.
+func (r *WireguardClientReconciler) resolveInbound(ctx context.Context, wg *corev1alpha1.WireguardClient, in corev1alpha1.WireguardClientInbound) (int32, string, error) {
+	log := log.FromContext(ctx)
+
+	port := in.Port
+	if ref := in.ProtonVPNConfigRef; ref != nil {
+		pc := &corev1alpha1.ProtonVPNConfig{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: wg.Namespace, Name: ref.Name}, pc); client.IgnoreNotFound(err) != nil {
+			return 0, "", err
+		}
+
+		port = pc.Status.ForwardedPort
+		if port == 0 {
+			log.Info("Waiting for forwarded port", "rule", in.Name, "protonvpnconfig", ref.Name)
+		}
+	}
+
+	svc := &corev1.Service{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: wg.Namespace, Name: in.Service.Name}, svc); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return 0, "", err
+		}
+
+		log.Info("Waiting for inbound service", "rule", in.Name, "service", in.Service.Name)
+		return port, "", nil
+	}
+	if svc.Spec.ClusterIP == "" || svc.Spec.ClusterIP == corev1.ClusterIPNone {
+		// Headless services have no single address to forward to
+		log.Info("Skipping inbound rule, the service has no cluster IP", "rule", in.Name, "service", in.Service.Name)
+		return port, "", nil
+	}
+
+	return port, fmt.Sprintf("%s:%d", svc.Spec.ClusterIP, in.Service.Port), nil
+}
+
+// indexInboundProtonVPNConfigs is an indexer for [InboundProtonVPNConfigIndex]
+func indexInboundProtonVPNConfigs(obj client.Object) []string {
+	wg, ok := obj.(*corev1alpha1.WireguardClient)
+	if !ok {
+		return nil
+	}
+
+	names := []string{}
+	for _, in := range wg.Spec.Inbound {
+		if in.ProtonVPNConfigRef != nil {
+			names = append(names, in.ProtonVPNConfigRef.Name)
+		}
+	}
+
+	return names
+}
+
+// indexInboundServices is an indexer for [InboundServiceIndex]
+func indexInboundServices(obj client.Object) []string {
+	wg, ok := obj.(*corev1alpha1.WireguardClient)
+	if !ok {
+		return nil
+	}
+
+	names := []string{}
+	for _, in := range wg.Spec.Inbound {
+		names = append(names, in.Service.Name)
+	}
+
+	return names
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+// InlineConfigSecretName returns the name of the secret an inline config is rendered to
+func InlineConfigSecretName(wg *corev1alpha1.WireguardClient, c corev1alpha1.WireguardClientConfig) string {
+	return inlineSecretName(wg, c, "")
+}
+
+// PresharedKeySecretName returns the name of the secret the preshared keys
+// generated for an inline config are stored in
+func PresharedKeySecretName(wg *corev1alpha1.WireguardClient, c corev1alpha1.WireguardClientConfig) string {
+	return inlineSecretName(wg, c, "psk")
+}
+
+// inlineSecretName names a secret of c. Client and config names can both contain
+// '-', so a hash of the unambiguous tuple is appended to keep the names unique.
+func inlineSecretName(wg *corev1alpha1.WireguardClient, c corev1alpha1.WireguardClientConfig, purpose string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{wg.Name, c.Name, purpose}, "/")))
+	suffix := hex.EncodeToString(sum[:4])
+	if purpose != "" {
+		suffix = purpose + "-" + suffix
+	}
+
+	prefix := wg.Name + "-" + c.Name
+	if limit := validation.DNS1123SubdomainMaxLength - len(suffix) - 1; len(prefix) > limit {
+		prefix = strings.TrimRight(prefix[:limit], "-.")
+	}
+
+	return prefix + "-" + suffix
+}
+
+// ensureControlled returns an error when obj exists and is not controlled by wg,
+// so secrets created by something else are never overwritten
+func ensureControlled(wg *corev1alpha1.WireguardClient, obj metav1.Object) error {
+	if obj.GetUID() == "" || metav1.IsControlledBy(obj, wg) {
+		return nil
+	}
+
+	return vpn.Invalid("secret %s exists and is not controlled by %s", obj.GetName(), wg.Name)
+}
+
+// PresharedKeyName returns the secret key the preshared key generated for the
+// peer with publicKey is stored under. Secret keys can't contain '+' or '/',
+// so the public key is re-encoded with the URL safe alphabet.
+func PresharedKeyName(publicKey string) string {
+	return strings.NewReplacer("+", "-", "/", "_", "=", "").Replace(publicKey)
+}
+
+// configSource returns the source mounted for c. Inline configs are
+// mounted from the secret they are rendered to.
+func configSource(wg *corev1alpha1.WireguardClient, c corev1alpha1.WireguardClientConfig) *corev1alpha1.WireguardClientConfigSource {
+	if c.Inline == nil {
+		return c.ValueFrom
+	}
+
+	return &corev1alpha1.WireguardClientConfigSource{
+		SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: InlineConfigSecretName(wg, c)},
+			Key:                  vpn.ConfigKey,
+		},
+	}
+}
+
+// RenderInlineConfigs writes the inline configs of wg to secrets owned by wg,
+// generating any preshared keys they ask for. Configs referencing missing keys,
+// or keys that aren't valid, are reported as [vpn.InvalidError]s.
+func (r *WireguardClientReconciler) RenderInlineConfigs(ctx context.Context, wg *corev1alpha1.WireguardClient) error {
+	rotate, rotateRequested := wg.Annotations[RotateAnnotation]
+	rotateRequested = rotateRequested && rotate != wg.Status.ObservedRotate
+
+	for _, c := range wg.Spec.Configs {
+		if c.Inline == nil {
+			continue
+		}
+
+		if err := r.generatePresharedKeys(ctx, wg, c, rotateRequested); err != nil {
+			return fmt.Errorf("generating preshared keys for config %s: %w", c.Name, err)
+		}
+
+		config, err := r.renderInlineConfig(ctx, wg, c)
+		if err != nil {
+			return fmt.Errorf("config %s: %w", c.Name, err)
+		}
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      InlineConfigSecretName(wg, c),
+				Namespace: wg.Namespace,
+			},
+		}
+		_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+			if err := ensureControlled(wg, secret); err != nil {
+				return err
+			}
+
+			secret.Data = map[string][]byte{
+				vpn.ConfigKey: []byte(config.String()),
+			}
+
+			return ctrl.SetControllerReference(wg, secret, r.Scheme)
+		})
+		if err != nil {
+			return fmt.Errorf("writing config %s: %w", c.Name, err)
+		}
+
+		// Earlier versions named the secrets without a hash
+		err = r.deleteControlled(ctx, wg,
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: wg.Name + "-" + c.Name, Namespace: wg.Namespace}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: wg.Name + "-" + c.Name + "-psk", Namespace: wg.Namespace}},
+		)
+		if err != nil {
+			return fmt.Errorf("deleting legacy secrets of config %s: %w", c.Name, err)
+		}
+	}
+
+	wg.Status.ObservedRotate = rotate
+	return nil
+}
+
+// generatePresharedKeys ensures the preshared key secret of c holds a key for
+// every peer that asks for one, replacing them all when rotate is true
+func (r *WireguardClientReconciler) generatePresharedKeys(ctx context.Context, wg *corev1alpha1.WireguardClient, c corev1alpha1.WireguardClientConfig, rotate bool) error {
+	names := []string{}
+	for _, p := range c.Inline.Peers {
+		if p.GeneratePresharedKey {
+			names = append(names, PresharedKeyName(p.PublicKey))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PresharedKeySecretName(wg, c),
+			Namespace: wg.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if err := ensureControlled(wg, secret); err != nil {
+			return err
+		}
+
+		data := make(map[string][]byte, len(names))
+		for _, name := range names {
+			existing, err := wireguard.ParseKey(strings.TrimSpace(string(secret.Data[name])))
+			if err == nil && !rotate {
+				data[name] = []byte(existing.String())
+				continue
+			}
+
+			key, err := wireguard.GenerateKey()
+			if err != nil {
+				return err
+			}
+
+			data[name] = []byte(key.String())
+		}
+
+		// Keys of peers that were removed, or no longer ask for one, are dropped
+		secret.Data = data
+		return ctrl.SetControllerReference(wg, secret, r.Scheme)
+	})
+
+	return err
+}
+
+func (r *WireguardClientReconciler) renderInlineConfig(ctx context.Context, wg *corev1alpha1.WireguardClient, c corev1alpha1.WireguardClientConfig) (*wireguard.Config, error) {
+	namespace, inline := wg.Namespace, c.Inline
+	privateKey, err := vpn.ResolveValue(ctx, r.Client, namespace, "", nil, privateKeyRef(inline.Interface))
+	if err != nil {
+		return nil, err
+	}
+
+	config := &wireguard.Config{
+		Interface: wireguard.Interface{
+			PrivateKey: strings.TrimSpace(privateKey),
+			Address:    inline.Interface.Address,
+			DNS:        inline.Interface.DNS,
+		},
+	}
+	if mtu := inline.Interface.MTU; mtu != nil {
+		config.Interface.MTU = int(*mtu)
+	}
+
+	for _, p := range inline.Peers {
+		peer := wireguard.Peer{
+			PublicKey:  p.PublicKey,
+			Endpoint:   p.Endpoint,
+			AllowedIPs: p.AllowedIPs,
+		}
+		if keepalive := p.PersistentKeepalive; keepalive != nil {
+			peer.PersistentKeepalive = int(*keepalive)
+		}
+		if ref := presharedKeyRef(wg, c, p); ref != nil {
+			psk, err := vpn.ResolveValue(ctx, r.Client, namespace, "", nil, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			peer.PresharedKey = strings.TrimSpace(psk)
+		}
+
+		config.Peers = append(config.Peers, peer)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, vpn.Invalid("%s", err)
+	}
+
+	return config, nil
+}
+
+// privateKeyRef returns the secret key holding the private key of i. A
+// WireguardKeyPair's keys are stored in a secret with the same name.
+func privateKeyRef(i corev1alpha1.WireguardInterface) *corev1.SecretKeySelector {
+	if i.KeyPairRef != nil {
+		return &corev1.SecretKeySelector{
+			LocalObjectReference: *i.KeyPairRef,
+			Key:                  corev1alpha1.WireguardKeyPairPrivateKey,
+		}
+	}
+
+	return i.PrivateKeySecretRef
+}
+
+// presharedKeyRef returns the secret key holding the preshared key of p, if it has one
+func presharedKeyRef(wg *corev1alpha1.WireguardClient, c corev1alpha1.WireguardClientConfig, p corev1alpha1.WireguardInlinePeer) *corev1.SecretKeySelector {
+	if p.GeneratePresharedKey {
+		return &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: PresharedKeySecretName(wg, c)},
+			Key:                  PresharedKeyName(p.PublicKey),
+		}
+	}
+
+	return p.PresharedKeySecretRef
+}
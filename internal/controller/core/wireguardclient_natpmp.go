@@ -0,0 +1,149 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+)
+
+// NATPMPContainerName is the name of the container that keeps the ports of the
+// ProtonVPNConfigs followed by inbound rules forwarded
+const NATPMPContainerName = "wireguard-natpmp"
+
+// NATPMPName returns the name of the service account, role and role binding
+// the NAT-PMP sidecar of wg reports the forwarded ports with
+func NATPMPName(wg *corev1alpha1.WireguardClient) string {
+	return fmt.Sprintf("%s-natpmp", wg.Name)
+}
+
+// portForwardedConfigs returns the sorted names of the ProtonVPNConfigs followed
+// by the inbound rules of wg
+func portForwardedConfigs(wg *corev1alpha1.WireguardClient) []string {
+	names := []string{}
+	for _, in := range wg.Spec.Inbound {
+		if in.ProtonVPNConfigRef != nil {
+			names = append(names, in.ProtonVPNConfigRef.Name)
+		}
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// NewNATPMPContainer returns the sidecar that forwards the ports of configs. It
+// shares the tunnel of wg, the only place the NAT-PMP gateway is reachable from,
+// and reports the forwarded ports in the status of the configs.
+func NewNATPMPContainer(image string, configs []string) corev1.Container {
+	return corev1.Container{
+		Name:    NATPMPContainerName,
+		Image:   image,
+		Command: []string{"/natpmp"},
+		Args:    configs,
+		Env: []corev1.EnvVar{{
+			Name: "NAMESPACE",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+			},
+		}},
+		SecurityContext: &corev1.SecurityContext{
+			AllowPrivilegeEscalation: ptr.To(false),
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+			},
+		},
+	}
+}
+
+// ApplyNATPMP lets the NAT-PMP sidecar of wg report the ports of the configs its
+// inbound rules follow, or removes its access when there are none
+func (r *WireguardClientReconciler) ApplyNATPMP(ctx context.Context, wg *corev1alpha1.WireguardClient) error {
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      NATPMPName(wg),
+			Namespace: wg.Namespace,
+		},
+	}
+	role := &rbacv1.Role{ObjectMeta: sa.ObjectMeta}
+	binding := &rbacv1.RoleBinding{ObjectMeta: sa.ObjectMeta}
+
+	configs := portForwardedConfigs(wg)
+	if len(configs) == 0 {
+		return r.deleteControlled(ctx, wg, binding, role, sa)
+	}
+	if r.NATPMPImage == "" {
+		log.FromContext(ctx).Info("No NAT-PMP image is configured, forwarded ports won't be renewed", "protonvpnconfigs", configs)
+		return r.deleteControlled(ctx, wg, binding, role, sa)
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, sa, func() error {
+		return ctrl.SetControllerReference(wg, sa, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("applying service account: %w", err)
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, role, func() error {
+		role.Rules = []rbacv1.PolicyRule{{
+			APIGroups:     []string{corev1alpha1.GroupVersion.Group},
+			Resources:     []string{"protonvpnconfigs"},
+			ResourceNames: configs,
+			Verbs:         []string{"get"},
+		}, {
+			APIGroups:     []string{corev1alpha1.GroupVersion.Group},
+			Resources:     []string{"protonvpnconfigs/status"},
+			ResourceNames: configs,
+			Verbs:         []string{"patch"},
+		}}
+
+		return ctrl.SetControllerReference(wg, role, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("applying role: %w", err)
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+		binding.RoleRef = rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     role.Name,
+		}
+		binding.Subjects = []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      sa.Name,
+			Namespace: sa.Namespace,
+		}}
+
+		return ctrl.SetControllerReference(wg, binding, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("applying role binding: %w", err)
+	}
+
+	return nil
+}
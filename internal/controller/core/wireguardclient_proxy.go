@@ -0,0 +1,149 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+)
+
+const (
+	// DefaultProxyImage is the gost image used by proxies that don't specify one
+	DefaultProxyImage = "gogost/gost:3.0.0"
+
+	// ProxyContainerName is the name of the proxy container
+	ProxyContainerName = "proxy"
+
+	defaultProxySOCKSPort = 1080
+	defaultProxyHTTPPort  = 8080
+
+	// The gost image runs as root by default
+	proxyUser = 65534
+)
+
+// ProxyName returns the name of the proxy service of wg
+func ProxyName(wg *corev1alpha1.WireguardClient) string {
+	return fmt.Sprintf("%s-proxy", wg.Name)
+}
+
+func proxyPorts(p *corev1alpha1.WireguardClientProxy) (socks, http int32) {
+	socks, http = p.SOCKSPort, p.HTTPPort
+	if socks == 0 {
+		socks = defaultProxySOCKSPort
+	}
+	if http == 0 {
+		http = defaultProxyHTTPPort
+	}
+
+	return socks, http
+}
+
+// NewProxyContainer builds the proxy container of wg. It shares the network
+// namespace of the wireguard container, so proxied traffic goes through the tunnel.
+func NewProxyContainer(wg *corev1alpha1.WireguardClient) corev1.Container {
+	p := wg.Spec.Proxy
+	image := p.Image
+	if image == "" {
+		image = DefaultProxyImage
+	}
+
+	var auth string
+	var env []corev1.EnvVar
+	if ref := p.AuthSecretRef; ref != nil {
+		// Kubernetes expands the variables in the args
+		auth = "$(PROXY_USERNAME):$(PROXY_PASSWORD)@"
+		env = append(env,
+			secretEnvVar("PROXY_USERNAME", *ref, corev1.BasicAuthUsernameKey),
+			secretEnvVar("PROXY_PASSWORD", *ref, corev1.BasicAuthPasswordKey),
+		)
+	}
+
+	socks, http := proxyPorts(p)
+	return corev1.Container{
+		Name:  ProxyContainerName,
+		Image: image,
+		Args: []string{
+			"-L", fmt.Sprintf("socks5://%s:%d", auth, socks),
+			"-L", fmt.Sprintf("http://%s:%d", auth, http),
+		},
+		Env: env,
+		Ports: []corev1.ContainerPort{
+			{Name: "socks", ContainerPort: socks, Protocol: corev1.ProtocolTCP},
+			{Name: "http", ContainerPort: http, Protocol: corev1.ProtocolTCP},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+			},
+			RunAsUser:                ptr.To[int64](proxyUser),
+			RunAsNonRoot:             ptr.To(true),
+			AllowPrivilegeEscalation: ptr.To(false),
+			ReadOnlyRootFilesystem:   ptr.To(true),
+		},
+	}
+}
+
+// ApplyProxy writes the proxy service of wg, or removes it when wg has no proxy
+func (r *WireguardClientReconciler) ApplyProxy(ctx context.Context, wg *corev1alpha1.WireguardClient) error {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ProxyName(wg),
+			Namespace: wg.Namespace,
+		},
+	}
+	if wg.Spec.Proxy == nil {
+		return r.deleteControlled(ctx, wg, svc)
+	}
+
+	socks, http := proxyPorts(wg.Spec.Proxy)
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, svc, func() error {
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+		svc.Spec.Selector = SelectorLabels(wg)
+		svc.Spec.Ports = []corev1.ServicePort{
+			{Name: "socks", Port: socks, TargetPort: intstr.FromString("socks"), Protocol: corev1.ProtocolTCP},
+			{Name: "http", Port: http, TargetPort: intstr.FromString("http"), Protocol: corev1.ProtocolTCP},
+		}
+
+		return ctrl.SetControllerReference(wg, svc, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("writing proxy service: %w", err)
+	}
+
+	return nil
+}
+
+func secretEnvVar(name string, ref corev1.LocalObjectReference, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: ref,
+				Key:                  key,
+			},
+		},
+	}
+}
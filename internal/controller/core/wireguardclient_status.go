@@ -0,0 +1,213 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+)
+
+// failureReasons are the container waiting reasons that indicate a pod
+// won't become ready without intervention
+var failureReasons = []string{
+	"CrashLoopBackOff",
+	"CreateContainerConfigError",
+	"CreateContainerError",
+	"ErrImagePull",
+	"ImagePullBackOff",
+	"InvalidImageName",
+	"RunContainerError",
+}
+
+// SetWireguardClientStatus derives the status of wg from its deployment and pods
+func SetWireguardClientStatus(wg *corev1alpha1.WireguardClient, deployment *appsv1.Deployment, pods []corev1.Pod) {
+	wg.Status.ObservedGeneration = wg.Generation
+
+	wg.Status.ReadyPods = []string{}
+	for _, pod := range pods {
+		if podReady(&pod) {
+			wg.Status.ReadyPods = append(wg.Status.ReadyPods, pod.Name)
+		}
+	}
+	slices.Sort(wg.Status.ReadyPods)
+
+	if id := runningImageID(pods); id != "" {
+		wg.Status.ImageID = id
+	}
+
+	reason, message := podFailure(pods)
+	if reason != "" {
+		wg.Status.LastFailureReason = reason
+	}
+
+	// The deployment status is stale until the deployment controller observes the latest spec
+	observed := deployment.Status.ObservedGeneration >= deployment.Generation
+
+	available := metav1.Condition{
+		Type:    TypeAvailableWireguardClient,
+		Status:  metav1.ConditionFalse,
+		Reason:  "PodsNotReady",
+		Message: fmt.Sprintf("%d of %d pods are ready", deployment.Status.ReadyReplicas, replicas(deployment)),
+	}
+	if c := deploymentCondition(deployment, appsv1.DeploymentAvailable); c != nil && c.Status == corev1.ConditionFalse {
+		available.Reason, available.Message = c.Reason, c.Message
+	} else if c != nil && c.Status == corev1.ConditionTrue && deployment.Status.ReadyReplicas > 0 {
+		available.Status, available.Reason, available.Message = metav1.ConditionTrue, c.Reason, c.Message
+	}
+	_ = meta.SetStatusCondition(&wg.Status.Conditions, available)
+
+	progressing := metav1.Condition{
+		Type:    TypeProgressingWireguardClient,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Reconciling",
+		Message: "Waiting for the deployment to be observed",
+	}
+	if c := deploymentCondition(deployment, appsv1.DeploymentProgressing); c != nil && observed {
+		progressing.Status = metav1.ConditionFalse
+		progressing.Reason, progressing.Message = c.Reason, c.Message
+		if c.Status == corev1.ConditionTrue && !rolledOut(deployment) {
+			progressing.Status = metav1.ConditionTrue
+		}
+	}
+	_ = meta.SetStatusCondition(&wg.Status.Conditions, progressing)
+
+	degraded := metav1.Condition{
+		Type:    TypeDegradedWireguardClient,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Healthy",
+		Message: "No pod failures observed",
+	}
+	if reason != "" {
+		degraded.Status, degraded.Reason, degraded.Message = metav1.ConditionTrue, reason, message
+	} else if c := deploymentCondition(deployment, appsv1.DeploymentReplicaFailure); c != nil && c.Status == corev1.ConditionTrue {
+		degraded.Status, degraded.Reason, degraded.Message = metav1.ConditionTrue, c.Reason, c.Message
+	} else if c := deploymentCondition(deployment, appsv1.DeploymentProgressing); c != nil && c.Reason == "ProgressDeadlineExceeded" {
+		degraded.Status, degraded.Reason, degraded.Message = metav1.ConditionTrue, c.Reason, c.Message
+	}
+	_ = meta.SetStatusCondition(&wg.Status.Conditions, degraded)
+}
+
+// podToWireguardClient maps a client pod to its WireguardClient
+func podToWireguardClient(_ context.Context, obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	if _, ok := labels[WireguardClientUIDLabel]; !ok {
+		return nil
+	}
+
+	name, ok := labels["app.kubernetes.io/instance"]
+	if !ok {
+		return nil
+	}
+
+	return []reconcile.Request{{
+		NamespacedName: client.ObjectKey{Namespace: obj.GetNamespace(), Name: name},
+	}}
+}
+
+func podReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// runningImageID returns the image ID of the wireguard container in the
+// first ready pod, falling back to any running pod
+func runningImageID(pods []corev1.Pod) string {
+	id := ""
+	for _, pod := range pods {
+		for _, s := range pod.Status.ContainerStatuses {
+			if s.Name != "wireguard" || s.ImageID == "" || s.State.Running == nil {
+				continue
+			}
+			if podReady(&pod) {
+				return s.ImageID
+			}
+			if id == "" {
+				id = s.ImageID
+			}
+		}
+	}
+
+	return id
+}
+
+// podFailure returns the first container failure found in pods
+func podFailure(pods []corev1.Pod) (string, string) {
+	for _, pod := range pods {
+		statuses := slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses)
+		for _, s := range statuses {
+			if w := s.State.Waiting; w != nil && slices.Contains(failureReasons, w.Reason) {
+				return w.Reason, fmt.Sprintf("Container %s in pod %s: %s", s.Name, pod.Name, w.Message)
+			}
+			if t := s.State.Terminated; t != nil && t.ExitCode != 0 {
+				reason := t.Reason
+				if reason == "" {
+					reason = "Error"
+				}
+
+				return reason, fmt.Sprintf("Container %s in pod %s exited with code %d", s.Name, pod.Name, t.ExitCode)
+			}
+		}
+	}
+
+	return "", ""
+}
+
+func deploymentCondition(d *appsv1.Deployment, t appsv1.DeploymentConditionType) *appsv1.DeploymentCondition {
+	for i := range d.Status.Conditions {
+		if d.Status.Conditions[i].Type == t {
+			return &d.Status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+// rolledOut reports whether every replica of d runs the latest template and is available
+func rolledOut(d *appsv1.Deployment) bool {
+	want := replicas(d)
+
+	return d.Status.UpdatedReplicas == want &&
+		d.Status.Replicas == want &&
+		d.Status.AvailableReplicas == want
+}
+
+func replicas(d *appsv1.Deployment) int32 {
+	if d.Spec.Replicas != nil {
+		return *d.Spec.Replicas
+	}
+
+	return 1
+}
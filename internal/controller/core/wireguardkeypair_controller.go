@@ -0,0 +1,225 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+const (
+	// RotateAnnotation requests new keys whenever its value changes. It rotates the
+	// keypair of a WireguardKeyPair, and the generated preshared keys of a WireguardClient.
+	RotateAnnotation = "core.thecluster.io/rotate"
+
+	// MinRotationInterval is the shortest rotation interval honored by the controller,
+	// shorter intervals are rejected by the API and clamped here.
+	MinRotationInterval = time.Hour
+)
+
+var (
+	TypeAvailableWireguardKeyPair = "Available"
+)
+
+// WireguardKeyPairReconciler reconciles a WireguardKeyPair object
+type WireguardKeyPairReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardkeypairs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardkeypairs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardkeypairs/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch
+
+func (r *WireguardKeyPairReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	kp := &corev1alpha1.WireguardKeyPair{}
+	if err := r.Get(ctx, req.NamespacedName, kp); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, req.NamespacedName, secret)
+	if client.IgnoreNotFound(err) != nil {
+		log.Error(err, "Failed to get keypair secret")
+		return ctrl.Result{}, err
+	}
+
+	if err == nil && !metav1.IsControlledBy(secret, kp) {
+		// Adopting the secret could rotate keys that something else depends on
+		log.Info("Keypair secret exists and is not controlled by the keypair")
+		_ = meta.SetStatusCondition(
+			&kp.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardKeyPair,
+				Status:  metav1.ConditionFalse,
+				Reason:  "SecretConflict",
+				Message: fmt.Sprintf("Secret %s exists and is not controlled by the keypair", secret.Name),
+			},
+		)
+		if err := r.Status().Update(ctx, kp); err != nil {
+			log.Error(err, "Failed to update keypair status")
+			return ctrl.Result{}, err
+		}
+
+		// Unowned secrets aren't watched, check again in case it was removed
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	key, keyErr := wireguard.ParseKey(strings.TrimSpace(string(secret.Data[corev1alpha1.WireguardKeyPairPrivateKey])))
+	reason := rotationReason(kp, keyErr == nil, time.Now())
+	if reason == "" {
+		kp.Status.PublicKey = key.PublicKey().String()
+		kp.Status.SecretName = secret.Name
+		if kp.Status.LastRotationTime == nil {
+			// Existing keys are kept, and their age counts towards the schedule
+			kp.Status.LastRotationTime = &secret.CreationTimestamp
+		}
+		_ = meta.SetStatusCondition(
+			&kp.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardKeyPair,
+				Status:  metav1.ConditionTrue,
+				Reason:  "Reconciling",
+				Message: "Keypair is available",
+			},
+		)
+		if err := r.Status().Update(ctx, kp); err != nil {
+			log.Error(err, "Failed to update keypair status")
+			return ctrl.Result{}, err
+		}
+
+		return r.requeueForRotation(kp, time.Now()), nil
+	}
+
+	log.Info("Generating keypair", "reason", reason)
+	key, err = wireguard.GeneratePrivateKey()
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("generating private key: %w", err)
+	}
+
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kp.Name,
+			Namespace: kp.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Data = map[string][]byte{
+			corev1alpha1.WireguardKeyPairPrivateKey: []byte(key.String()),
+			corev1alpha1.WireguardKeyPairPublicKey:  []byte(key.PublicKey().String()),
+		}
+
+		return ctrl.SetControllerReference(kp, secret, r.Scheme)
+	})
+	if err != nil {
+		log.Error(err, "Failed to write keypair secret")
+		_ = meta.SetStatusCondition(
+			&kp.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardKeyPair,
+				Status:  metav1.ConditionFalse,
+				Reason:  "GenerateFailed",
+				Message: fmt.Sprintf("Failed to write keypair secret: %s", err),
+			},
+		)
+		if err := r.Status().Update(ctx, kp); err != nil {
+			log.Error(err, "Failed to update keypair status")
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	now := metav1.Now()
+	kp.Status.PublicKey = key.PublicKey().String()
+	kp.Status.SecretName = secret.Name
+	kp.Status.LastRotationTime = &now
+	kp.Status.ObservedRotate = kp.Annotations[RotateAnnotation]
+	_ = meta.SetStatusCondition(
+		&kp.Status.Conditions,
+		metav1.Condition{
+			Type:    TypeAvailableWireguardKeyPair,
+			Status:  metav1.ConditionTrue,
+			Reason:  reason,
+			Message: "Generated a new keypair",
+		},
+	)
+	if err := r.Status().Update(ctx, kp); err != nil {
+		log.Error(err, "Failed to update keypair status")
+		return ctrl.Result{}, err
+	}
+
+	return r.requeueForRotation(kp, now.Time), nil
+}
+
+// rotationReason returns why kp needs a new keypair, or an empty string
+// when the current keypair should be kept
+func rotationReason(kp *corev1alpha1.WireguardKeyPair, valid bool, now time.Time) string {
+	if !valid {
+		return "Generated"
+	}
+	if v, ok := kp.Annotations[RotateAnnotation]; ok && v != kp.Status.ObservedRotate {
+		return "RotationRequested"
+	}
+	if kp.Spec.RotationInterval != nil && kp.Status.LastRotationTime != nil {
+		if !now.Before(kp.Status.LastRotationTime.Add(rotationInterval(kp))) {
+			return "RotationScheduled"
+		}
+	}
+
+	return ""
+}
+
+// requeueForRotation requeues kp when its next scheduled rotation is due
+func (r *WireguardKeyPairReconciler) requeueForRotation(kp *corev1alpha1.WireguardKeyPair, now time.Time) ctrl.Result {
+	if kp.Spec.RotationInterval == nil || kp.Status.LastRotationTime == nil {
+		return ctrl.Result{}
+	}
+
+	next := kp.Status.LastRotationTime.Add(rotationInterval(kp))
+	return ctrl.Result{RequeueAfter: max(next.Sub(now), time.Second)}
+}
+
+// rotationInterval returns the rotation interval of kp, clamped to MinRotationInterval
+func rotationInterval(kp *corev1alpha1.WireguardKeyPair) time.Duration {
+	return max(kp.Spec.RotationInterval.Duration, MinRotationInterval)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *WireguardKeyPairReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1alpha1.WireguardKeyPair{}).
+		Named("core-wireguardkeypair").
+		Owns(&corev1.Secret{}).
+		Complete(r)
+}
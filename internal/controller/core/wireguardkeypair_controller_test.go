@@ -0,0 +1,251 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+var _ = Describe("WireguardKeyPair Controller", func() {
+	Context("When reconciling a resource", func() {
+		const resourceName = "test-keypair"
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		var (
+			keypair              *corev1alpha1.WireguardKeyPair
+			controllerReconciler *WireguardKeyPairReconciler
+		)
+
+		reconcileKeyPair := func(ctx context.Context) reconcile.Result {
+			GinkgoHelper()
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, keypair)).To(Succeed())
+
+			return result
+		}
+
+		secretKeys := func(ctx context.Context) (wireguard.Key, string) {
+			GinkgoHelper()
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			key, err := wireguard.ParseKey(string(secret.Data[corev1alpha1.WireguardKeyPairPrivateKey]))
+			Expect(err).NotTo(HaveOccurred())
+
+			return key, string(secret.Data[corev1alpha1.WireguardKeyPairPublicKey])
+		}
+
+		BeforeEach(func() {
+			keypair = &corev1alpha1.WireguardKeyPair{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+			}
+
+			controllerReconciler = &WireguardKeyPairReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+		})
+
+		JustBeforeEach(func(ctx context.Context) {
+			By("Creating the custom resource for the Kind WireguardKeyPair")
+			err := k8sClient.Get(ctx, typeNamespacedName, keypair)
+			if err != nil && errors.IsNotFound(err) {
+				Expect(k8sClient.Create(ctx, keypair)).To(Succeed())
+			}
+		})
+
+		AfterEach(func(ctx context.Context) {
+			resource := &corev1alpha1.WireguardKeyPair{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance WireguardKeyPair")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			By("Deleting the keypair secret")
+			secret := &corev1.Secret{}
+			if err := k8sClient.Get(ctx, typeNamespacedName, secret); err == nil {
+				Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+			}
+		})
+
+		It("should generate a keypair", func(ctx context.Context) {
+			By("Reconciling the created resource")
+			result := reconcileKeyPair(ctx)
+			Expect(result.RequeueAfter).To(BeZero())
+
+			By("Checking the keypair secret")
+			key, publicKey := secretKeys(ctx)
+			Expect(publicKey).To(Equal(key.PublicKey().String()))
+
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			Expect(metav1.IsControlledBy(secret, keypair)).To(BeTrue())
+
+			By("Checking the status")
+			Expect(keypair.Status.PublicKey).To(Equal(publicKey))
+			Expect(keypair.Status.SecretName).To(Equal(resourceName))
+			Expect(keypair.Status.LastRotationTime).NotTo(BeNil())
+			available := meta.FindStatusCondition(keypair.Status.Conditions, TypeAvailableWireguardKeyPair)
+			Expect(available).NotTo(BeNil())
+			Expect(available.Status).To(Equal(metav1.ConditionTrue))
+			Expect(available.Reason).To(Equal("Generated"))
+
+			By("Reconciling the resource again")
+			reconcileKeyPair(ctx)
+			again, _ := secretKeys(ctx)
+			Expect(again).To(Equal(key))
+		})
+
+		It("should keep an existing private key", func(ctx context.Context) {
+			key, err := wireguard.GeneratePrivateKey()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Creating the keypair secret ahead of time")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				StringData: map[string]string{
+					corev1alpha1.WireguardKeyPairPrivateKey: key.String() + "\n",
+				},
+			}
+			Expect(controllerutil.SetControllerReference(keypair, secret, k8sClient.Scheme())).To(Succeed())
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+			By("Reconciling the created resource")
+			reconcileKeyPair(ctx)
+
+			Expect(keypair.Status.PublicKey).To(Equal(key.PublicKey().String()))
+			Expect(keypair.Status.LastRotationTime).NotTo(BeNil())
+		})
+
+		It("should not adopt a secret it doesn't control", func(ctx context.Context) {
+			key, err := wireguard.GeneratePrivateKey()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Creating an unowned secret with the keypair's name")
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				StringData: map[string]string{
+					corev1alpha1.WireguardKeyPairPrivateKey: key.String(),
+				},
+			})).To(Succeed())
+
+			By("Reconciling the created resource")
+			result := reconcileKeyPair(ctx)
+			Expect(result.RequeueAfter).NotTo(BeZero())
+
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			Expect(secret.OwnerReferences).To(BeEmpty())
+			Expect(keypair.Status.PublicKey).To(BeEmpty())
+			available := meta.FindStatusCondition(keypair.Status.Conditions, TypeAvailableWireguardKeyPair)
+			Expect(available).NotTo(BeNil())
+			Expect(available.Status).To(Equal(metav1.ConditionFalse))
+			Expect(available.Reason).To(Equal("SecretConflict"))
+		})
+
+		It("should rotate the keypair on demand", func(ctx context.Context) {
+			By("Reconciling the created resource")
+			reconcileKeyPair(ctx)
+			key, _ := secretKeys(ctx)
+
+			By("Requesting a rotation")
+			keypair.Annotations = map[string]string{RotateAnnotation: "1"}
+			Expect(k8sClient.Update(ctx, keypair)).To(Succeed())
+			reconcileKeyPair(ctx)
+
+			rotated, publicKey := secretKeys(ctx)
+			Expect(rotated).NotTo(Equal(key))
+			Expect(keypair.Status.PublicKey).To(Equal(publicKey))
+			Expect(keypair.Status.ObservedRotate).To(Equal("1"))
+			available := meta.FindStatusCondition(keypair.Status.Conditions, TypeAvailableWireguardKeyPair)
+			Expect(available).NotTo(BeNil())
+			Expect(available.Reason).To(Equal("RotationRequested"))
+
+			By("Only rotating once per annotation value")
+			reconcileKeyPair(ctx)
+			again, _ := secretKeys(ctx)
+			Expect(again).To(Equal(rotated))
+		})
+
+		When("a rotation interval is configured", func() {
+			BeforeEach(func() {
+				keypair.Spec.RotationInterval = &metav1.Duration{Duration: time.Hour}
+			})
+
+			It("should requeue for the next rotation", func(ctx context.Context) {
+				result := reconcileKeyPair(ctx)
+				Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+			})
+
+			It("should reject intervals shorter than an hour", func(ctx context.Context) {
+				invalid := keypair.DeepCopy()
+				invalid.Name = "test-keypair-short"
+				invalid.Spec.RotationInterval = &metav1.Duration{Duration: time.Minute}
+				Expect(k8sClient.Create(ctx, invalid)).NotTo(Succeed())
+			})
+
+			It("should rotate keypairs that are due", func(ctx context.Context) {
+				By("Reconciling the created resource")
+				reconcileKeyPair(ctx)
+				key, _ := secretKeys(ctx)
+
+				By("Backdating the last rotation")
+				keypair.Status.LastRotationTime = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+				Expect(k8sClient.Status().Update(ctx, keypair)).To(Succeed())
+
+				By("Reconciling the resource again")
+				result := reconcileKeyPair(ctx)
+				Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+
+				rotated, _ := secretKeys(ctx)
+				Expect(rotated).NotTo(Equal(key))
+				available := meta.FindStatusCondition(keypair.Status.Conditions, TypeAvailableWireguardKeyPair)
+				Expect(available).NotTo(BeNil())
+				Expect(available.Reason).To(Equal("RotationScheduled"))
+			})
+		})
+	})
+})
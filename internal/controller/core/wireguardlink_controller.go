@@ -0,0 +1,560 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+const (
+	// WireguardLinkFieldOwner is the field manager used to apply link resources
+	WireguardLinkFieldOwner = "wireguardlink-controller"
+
+	// WireguardLinkUIDLabel is the pod label holding the UID of the owning WireguardLink
+	WireguardLinkUIDLabel = "core.thecluster.io/wireguardlink-uid"
+
+	// LinkInitContainerName is the name of the container that enables forwarding and NAT
+	LinkInitContainerName = "wireguard-link-init"
+
+	// LinkRemoteKeySecretIndex indexes WireguardLinks by the secret holding the remote public key
+	LinkRemoteKeySecretIndex = "spec.remote.publicKeySecretRef.name"
+
+	// handshakeTimeout is how long a link is considered connected after its latest handshake.
+	// WireGuard re-handshakes every two minutes while traffic or keepalives flow.
+	handshakeTimeout = 180
+)
+
+var (
+	TypeAvailableWireguardLink = "Available"
+	TypeConnectedWireguardLink = "Connected"
+)
+
+// handshakeProbe succeeds while the tunnel has completed a recent handshake
+var handshakeProbe = fmt.Sprintf(`latest="$(wg show wg0 latest-handshakes | awk '{ print $2 }' | sort -n | tail -n 1)"
+[ -n "$latest" ] && [ "$latest" -gt 0 ] && [ $(( $(date +%%s) - latest )) -lt %d ]`, handshakeTimeout)
+
+// LinkSelectorLabels returns the labels selecting the pods of link
+func LinkSelectorLabels(link *corev1alpha1.WireguardLink) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":     "wireguard-link",
+		"app.kubernetes.io/instance": link.Name,
+		WireguardLinkUIDLabel:        string(link.UID),
+	}
+}
+
+// LinkKeySecretName returns the name of the secret holding the keys of link
+func LinkKeySecretName(link *corev1alpha1.WireguardLink) string {
+	return fmt.Sprintf("%s-key", link.Name)
+}
+
+// LinkPublicSecretName returns the name of the secret link publishes for the remote side
+func LinkPublicSecretName(link *corev1alpha1.WireguardLink) string {
+	return fmt.Sprintf("%s-public", link.Name)
+}
+
+// WireguardLinkReconciler reconciles a WireguardLink object
+type WireguardLinkReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// The image used for links that don't specify one, defaults to [DefaultWireguardImage]
+	DefaultImage string
+}
+
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardlinks,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardlinks/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardlinks/finalizers,verbs=update
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+
+func (r *WireguardLinkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	link := &corev1alpha1.WireguardLink{}
+	if err := r.Get(ctx, req.NamespacedName, link); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if len(link.Status.Conditions) == 0 {
+		_ = meta.SetStatusCondition(
+			&link.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardLink,
+				Status:  metav1.ConditionUnknown,
+				Reason:  "Reconciling",
+				Message: "Starting reconciliation",
+			},
+		)
+		if err := r.Status().Update(ctx, link); err != nil {
+			log.Error(err, "Failed to update wireguard link status")
+			return ctrl.Result{}, err
+		}
+		if err := r.Get(ctx, req.NamespacedName, link); err != nil {
+			log.Error(err, "Failed to re-fetch wireguard link")
+			return ctrl.Result{}, err
+		}
+	}
+
+	key, err := r.Publish(ctx, link)
+	if err != nil {
+		log.Error(err, "Failed to publish link")
+		var conflict *vpn.SecretConflictError
+		reason := "Reconciling"
+		if errors.As(err, &conflict) {
+			reason = "SecretConflict"
+		}
+		_ = meta.SetStatusCondition(
+			&link.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardLink,
+				Status:  metav1.ConditionFalse,
+				Reason:  reason,
+				Message: fmt.Sprintf("Failed to publish %s: %s", link.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, link); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		// Unowned secrets aren't watched, check again in case it was removed
+		if conflict != nil {
+			return ctrl.Result{RequeueAfter: time.Minute}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	config, err := r.RenderConfig(ctx, link, key)
+	if err != nil {
+		log.Info("Link config is incomplete", "reason", err.Error())
+		var invalid *vpn.InvalidError
+		var conflict *vpn.SecretConflictError
+		reason := "Reconciling"
+		switch {
+		case errors.As(err, &invalid):
+			reason = "WaitingForRemoteKey"
+		case errors.As(err, &conflict):
+			reason = "SecretConflict"
+		}
+		_ = meta.SetStatusCondition(
+			&link.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardLink,
+				Status:  metav1.ConditionFalse,
+				Reason:  reason,
+				Message: err.Error(),
+			},
+		)
+		if err := r.Status().Update(ctx, link); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		// The remote key secret is watched, so there's no need to requeue
+		if invalid != nil {
+			return ctrl.Result{}, nil
+		}
+		// Unowned secrets aren't watched, check again in case it was removed
+		if conflict != nil {
+			return ctrl.Result{RequeueAfter: time.Minute}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	deployment, err := r.NewDeployment(link, config)
+	if err == nil {
+		err = applyAs(ctx, r.Client, WireguardLinkFieldOwner, deployment)
+	}
+	if err != nil {
+		log.Error(err, "Failed to apply deployment for wireguard link")
+		_ = meta.SetStatusCondition(
+			&link.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardLink,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Reconciling",
+				Message: fmt.Sprintf("Failed to apply deployment for %s: %s", link.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, link); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	if rolledOut(deployment) {
+		_ = meta.SetStatusCondition(
+			&link.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardLink,
+				Status:  metav1.ConditionTrue,
+				Reason:  "Reconciling",
+				Message: "Tunnel endpoint is running",
+			},
+		)
+	} else {
+		_ = meta.SetStatusCondition(
+			&link.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardLink,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Progressing",
+				Message: fmt.Sprintf("Waiting for deployment %s to roll out", deployment.Name),
+			},
+		)
+	}
+
+	// Pods only become ready after a handshake with the remote side
+	if deployment.Status.ReadyReplicas > 0 {
+		_ = meta.SetStatusCondition(
+			&link.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeConnectedWireguardLink,
+				Status:  metav1.ConditionTrue,
+				Reason:  "HandshakeCompleted",
+				Message: "Completed a handshake with the remote side",
+			},
+		)
+	} else {
+		_ = meta.SetStatusCondition(
+			&link.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeConnectedWireguardLink,
+				Status:  metav1.ConditionFalse,
+				Reason:  "NoHandshake",
+				Message: fmt.Sprintf("No handshake with the remote side in the last %d seconds", handshakeTimeout),
+			},
+		)
+	}
+	if err := r.Status().Update(ctx, link); err != nil {
+		log.Error(err, "Failed to update status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// Publish generates the keys of link, exposes it when it has a service, and writes
+// its public key, endpoint and CIDRs to the public secret for the remote side.
+// It returns the private key of link.
+func (r *WireguardLinkReconciler) Publish(ctx context.Context, link *corev1alpha1.WireguardLink) (wireguard.Key, error) {
+	key, err := ensurePrivateKey(ctx, r.Client, r.Scheme, link, LinkKeySecretName(link))
+	if err != nil {
+		return wireguard.Key{}, fmt.Errorf("generating link key: %w", err)
+	}
+
+	endpoint := ""
+	if link.Spec.Service != nil {
+		svc, err := newTunnelService(link, r.Scheme, LinkSelectorLabels(link), linkListenPort(link), *link.Spec.Service)
+		if err == nil {
+			err = applyAs(ctx, r.Client, WireguardLinkFieldOwner, svc)
+		}
+		if err != nil {
+			return wireguard.Key{}, fmt.Errorf("applying service: %w", err)
+		}
+		if endpoint, err = serviceEndpoint(ctx, r.Client, link.Spec.Endpoint, svc); err != nil {
+			return wireguard.Key{}, fmt.Errorf("resolving endpoint: %w", err)
+		}
+	} else {
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      link.Name,
+				Namespace: link.Namespace,
+			},
+		}
+		if err := deleteControlled(ctx, r.Client, link, svc); err != nil {
+			return wireguard.Key{}, err
+		}
+	}
+
+	cidrs := []string{}
+	if prefix, err := netip.ParsePrefix(link.Spec.Address); err == nil {
+		cidrs = append(cidrs, prefix.Masked().String())
+	}
+	cidrs = append(cidrs, link.Spec.LocalCIDRs...)
+
+	public := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      LinkPublicSecretName(link),
+			Namespace: link.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, public, func() error {
+		if err := vpn.CheckSecretOwner(link, public); err != nil {
+			return err
+		}
+		public.Data = map[string][]byte{
+			corev1alpha1.WireguardKeyPairPublicKey: []byte(key.PublicKey().String()),
+			corev1alpha1.WireguardLinkCIDRs:        []byte(strings.Join(cidrs, ",")),
+		}
+		if endpoint != "" {
+			public.Data[corev1alpha1.WireguardLinkEndpoint] = []byte(endpoint)
+		}
+
+		return ctrl.SetControllerReference(link, public, r.Scheme)
+	})
+	if err != nil {
+		return wireguard.Key{}, fmt.Errorf("writing public secret: %w", err)
+	}
+
+	link.Status.PublicKey = key.PublicKey().String()
+	link.Status.Endpoint = endpoint
+	link.Status.PublicSecretName = public.Name
+	return key, nil
+}
+
+// RenderConfig renders the wg-quick config of link into its config secret.
+// wg-quick installs routes for the peer's allowed IPs, so the remote CIDRs are
+// routed through the tunnel.
+func (r *WireguardLinkReconciler) RenderConfig(ctx context.Context, link *corev1alpha1.WireguardLink, key wireguard.Key) (*wireguard.Config, error) {
+	remoteKey, err := r.remotePublicKey(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := netip.ParsePrefix(link.Spec.Address)
+	if err != nil {
+		return nil, fmt.Errorf("parsing address: %w", err)
+	}
+
+	peer := wireguard.Peer{
+		PublicKey:  remoteKey,
+		Endpoint:   link.Spec.Remote.Endpoint,
+		AllowedIPs: append([]string{prefix.Masked().String()}, link.Spec.Remote.CIDRs...),
+	}
+	if k := link.Spec.Remote.PersistentKeepalive; k != nil {
+		peer.PersistentKeepalive = int(*k)
+	}
+
+	config := &wireguard.Config{
+		Interface: wireguard.Interface{
+			PrivateKey: key.String(),
+			Address:    []string{link.Spec.Address},
+			ListenPort: int(linkListenPort(link)),
+		},
+		Peers: []wireguard.Peer{peer},
+	}
+	if err := config.Validate(); err != nil {
+		return nil, vpn.Invalid("invalid link config: %s", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      link.Name,
+			Namespace: link.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if err := vpn.CheckSecretOwner(link, secret); err != nil {
+			return err
+		}
+		secret.Data = map[string][]byte{
+			vpn.ConfigKey: []byte(config.String()),
+		}
+
+		return ctrl.SetControllerReference(link, secret, r.Scheme)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("writing link config: %w", err)
+	}
+
+	return config, nil
+}
+
+// remotePublicKey returns the public key of the remote side of link
+func (r *WireguardLinkReconciler) remotePublicKey(ctx context.Context, link *corev1alpha1.WireguardLink) (string, error) {
+	remote := link.Spec.Remote
+	ref := remote.PublicKeySecretRef
+	if ref == nil {
+		return remote.PublicKey, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: link.Namespace, Name: ref.Name}, secret); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return "", err
+		}
+
+		return "", vpn.Invalid("waiting for secret %s with the remote public key", ref.Name)
+	}
+
+	key := ref.Key
+	if key == "" {
+		key = corev1alpha1.WireguardKeyPairPublicKey
+	}
+
+	value := strings.TrimSpace(string(secret.Data[key]))
+	if _, err := wireguard.ParseKey(value); err != nil {
+		return "", vpn.Invalid("secret %s has no valid public key under %q: %s", ref.Name, key, err)
+	}
+
+	return value, nil
+}
+
+// NewDeployment builds the desired deployment for link. Pods are rolled whenever
+// the config changes, and are ready while the tunnel has a recent handshake.
+func (r *WireguardLinkReconciler) NewDeployment(link *corev1alpha1.WireguardLink, config *wireguard.Config) (*appsv1.Deployment, error) {
+	sum := sha256.Sum256([]byte(config.String()))
+	image := tunnelImage(link.Spec.Image, r.DefaultImage)
+
+	init := newNATInitContainer(LinkInitContainerName, image, config.Peers[0].AllowedIPs)
+	container := newTunnelContainer(image, link.Spec.ImagePullPolicy, linkListenPort(link))
+	container.ReadinessProbe = &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{
+				Command: []string{"/bin/sh", "-c", handshakeProbe},
+			},
+		},
+		PeriodSeconds: 10,
+	}
+
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      link.Name,
+			Namespace: link.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To[int32](1),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: LinkSelectorLabels(link),
+			},
+			Strategy: appsv1.DeploymentStrategy{
+				// Only one pod can hold the link's key at a time
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: LinkSelectorLabels(link),
+					Annotations: map[string]string{
+						ConfigHashAnnotation: hex.EncodeToString(sum[:]),
+					},
+				},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{init},
+					Containers:     []corev1.Container{container},
+					Volumes: []corev1.Volume{{
+						Name: "config",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{
+								SecretName: link.Name,
+							},
+						},
+					}},
+					SecurityContext: &corev1.PodSecurityContext{
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if err := ctrl.SetControllerReference(link, deployment, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	return deployment, nil
+}
+
+func linkListenPort(link *corev1alpha1.WireguardLink) int32 {
+	if link.Spec.ListenPort > 0 {
+		return link.Spec.ListenPort
+	}
+
+	return 51820
+}
+
+// indexLinkRemoteKeySecrets is an indexer for [LinkRemoteKeySecretIndex]
+func indexLinkRemoteKeySecrets(obj client.Object) []string {
+	link, ok := obj.(*corev1alpha1.WireguardLink)
+	if !ok || link.Spec.Remote.PublicKeySecretRef == nil {
+		return nil
+	}
+
+	return []string{link.Spec.Remote.PublicKeySecretRef.Name}
+}
+
+// linksForSecret enqueues the WireguardLinks reading the remote public key from a secret
+func (r *WireguardLinkReconciler) linksForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := &corev1alpha1.WireguardLinkList{}
+	if err := r.List(ctx, list,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{LinkRemoteKeySecretIndex: obj.GetName()},
+	); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list links for secret")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, len(list.Items))
+	for i, l := range list.Items {
+		requests[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&l)}
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *WireguardLinkReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	ctx := context.Background()
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &corev1alpha1.WireguardLink{}, LinkRemoteKeySecretIndex, indexLinkRemoteKeySecrets); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1alpha1.WireguardLink{}).
+		Named("core-wireguardlink").
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Owns(&corev1.Secret{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.linksForSecret)).
+		Complete(r)
+}
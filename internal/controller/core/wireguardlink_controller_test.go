@@ -0,0 +1,268 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+var _ = Describe("WireguardLink Controller", func() {
+	Context("When reconciling a resource", func() {
+		const (
+			resourceName = "test-link"
+			remoteName   = "test-link-remote"
+		)
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		var (
+			link                 *corev1alpha1.WireguardLink
+			remoteKey            wireguard.Key
+			controllerReconciler *WireguardLinkReconciler
+		)
+
+		reconcileLink := func(ctx context.Context) {
+			GinkgoHelper()
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, link)).To(Succeed())
+		}
+
+		createRemoteKey := func(ctx context.Context) {
+			GinkgoHelper()
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      remoteName,
+					Namespace: "default",
+				},
+				StringData: map[string]string{
+					corev1alpha1.WireguardKeyPairPublicKey: remoteKey.PublicKey().String(),
+				},
+			})).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			var err error
+			remoteKey, err = wireguard.GeneratePrivateKey()
+			Expect(err).NotTo(HaveOccurred())
+
+			link = &corev1alpha1.WireguardLink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: corev1alpha1.WireguardLinkSpec{
+					Address:    "10.100.0.1/30",
+					LocalCIDRs: []string{"10.42.0.0/16"},
+					Remote: corev1alpha1.WireguardLinkRemote{
+						Endpoint: "cluster-b.example.com:51820",
+						PublicKeySecretRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: remoteName},
+							Key:                  corev1alpha1.WireguardKeyPairPublicKey,
+						},
+						CIDRs: []string{"10.52.0.0/16"},
+					},
+					Service: &corev1alpha1.WireguardServerService{
+						Type: corev1.ServiceTypeLoadBalancer,
+					},
+					Endpoint: "cluster-a.example.com",
+				},
+			}
+
+			controllerReconciler = &WireguardLinkReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+		})
+
+		JustBeforeEach(func(ctx context.Context) {
+			By("Creating the custom resource for the Kind WireguardLink")
+			err := k8sClient.Get(ctx, typeNamespacedName, link)
+			if err != nil && errors.IsNotFound(err) {
+				Expect(k8sClient.Create(ctx, link)).To(Succeed())
+			}
+		})
+
+		AfterEach(func(ctx context.Context) {
+			resource := &corev1alpha1.WireguardLink{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance WireguardLink")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			By("Deleting the owned resources")
+			for _, obj := range []client.Object{
+				&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"}},
+				&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: LinkKeySecretName(resource), Namespace: "default"}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: LinkPublicSecretName(resource), Namespace: "default"}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: remoteName, Namespace: "default"}},
+			} {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, obj))).To(Succeed())
+			}
+		})
+
+		It("should run the tunnel endpoint", func(ctx context.Context) {
+			createRemoteKey(ctx)
+
+			By("Reconciling the created resource")
+			reconcileLink(ctx)
+
+			By("Checking the public secret")
+			keySecret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      LinkKeySecretName(link),
+				Namespace: "default",
+			}, keySecret)).To(Succeed())
+			key, err := wireguard.ParseKey(string(keySecret.Data[corev1alpha1.WireguardKeyPairPrivateKey]))
+			Expect(err).NotTo(HaveOccurred())
+
+			public := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      LinkPublicSecretName(link),
+				Namespace: "default",
+			}, public)).To(Succeed())
+			Expect(public.Data).To(Equal(map[string][]byte{
+				corev1alpha1.WireguardKeyPairPublicKey: []byte(key.PublicKey().String()),
+				corev1alpha1.WireguardLinkCIDRs:        []byte("10.100.0.0/30,10.42.0.0/16"),
+				corev1alpha1.WireguardLinkEndpoint:     []byte("cluster-a.example.com:51820"),
+			}))
+			Expect(link.Status.PublicKey).To(Equal(key.PublicKey().String()))
+			Expect(link.Status.Endpoint).To(Equal("cluster-a.example.com:51820"))
+			Expect(link.Status.PublicSecretName).To(Equal(public.Name))
+
+			By("Checking the link config")
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			config, err := wireguard.Parse(string(secret.Data[vpn.ConfigKey]))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(&wireguard.Config{
+				Interface: wireguard.Interface{
+					PrivateKey: key.String(),
+					Address:    []string{"10.100.0.1/30"},
+					ListenPort: 51820,
+				},
+				Peers: []wireguard.Peer{{
+					PublicKey:  remoteKey.PublicKey().String(),
+					Endpoint:   "cluster-b.example.com:51820",
+					AllowedIPs: []string{"10.100.0.0/30", "10.52.0.0/16"},
+				}},
+			}))
+
+			By("Checking the deployment")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(metav1.IsControlledBy(deployment, link)).To(BeTrue())
+			Expect(deployment.Spec.Template.Spec.InitContainers).To(ConsistOf(SatisfyAll(
+				HaveField("Name", LinkInitContainerName),
+				HaveField("Env", ConsistOf(HaveField("Value", "10.100.0.0/30 10.52.0.0/16"))),
+			)))
+			Expect(deployment.Spec.Template.Spec.Containers).To(ConsistOf(
+				HaveField("ReadinessProbe.Exec.Command", ContainElement(handshakeProbe)),
+			))
+
+			By("Reporting the handshake state")
+			connected := meta.FindStatusCondition(link.Status.Conditions, TypeConnectedWireguardLink)
+			Expect(connected).NotTo(BeNil())
+			Expect(connected.Status).To(Equal(metav1.ConditionFalse))
+			Expect(connected.Reason).To(Equal("NoHandshake"))
+		})
+
+		It("should wait for the remote public key", func(ctx context.Context) {
+			reconcileLink(ctx)
+
+			available := meta.FindStatusCondition(link.Status.Conditions, TypeAvailableWireguardLink)
+			Expect(available).NotTo(BeNil())
+			Expect(available.Reason).To(Equal("WaitingForRemoteKey"))
+			Expect(link.Status.PublicKey).NotTo(BeEmpty())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &appsv1.Deployment{}))).To(BeTrue())
+
+			By("Creating the remote key")
+			createRemoteKey(ctx)
+			Expect(indexLinkRemoteKeySecrets(link)).To(ConsistOf(remoteName))
+			reconcileLink(ctx)
+			Expect(k8sClient.Get(ctx, typeNamespacedName, &appsv1.Deployment{})).To(Succeed())
+		})
+
+		When("the public secret exists and is not controlled by the link", func() {
+			publicName := types.NamespacedName{
+				Name:      resourceName + "-public",
+				Namespace: "default",
+			}
+
+			BeforeEach(func(ctx context.Context) {
+				By("Creating an unowned public secret")
+				Expect(k8sClient.Create(ctx, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      publicName.Name,
+						Namespace: publicName.Namespace,
+					},
+					StringData: map[string]string{"other": "value"},
+				})).To(Succeed())
+			})
+
+			It("should not adopt the secret", func(ctx context.Context) {
+				By("Reconciling the created resource")
+				result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(time.Minute))
+
+				By("Checking the secret is untouched")
+				secret := &corev1.Secret{}
+				Expect(k8sClient.Get(ctx, publicName, secret)).To(Succeed())
+				Expect(secret.OwnerReferences).To(BeEmpty())
+				Expect(secret.Data).To(Equal(map[string][]byte{"other": []byte("value")}))
+
+				By("Checking the conflict is reported")
+				Expect(k8sClient.Get(ctx, typeNamespacedName, link)).To(Succeed())
+				available := meta.FindStatusCondition(link.Status.Conditions, TypeAvailableWireguardLink)
+				Expect(available).NotTo(BeNil())
+				Expect(available.Reason).To(Equal("SecretConflict"))
+			})
+		})
+
+		It("should reject links with more than one remote key", func(ctx context.Context) {
+			link.Spec.Remote.PublicKey = remoteKey.PublicKey().String()
+			err := k8sClient.Update(ctx, link)
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("exactly one of publicKey or publicKeySecretRef must be set"))
+		})
+	})
+})
@@ -0,0 +1,781 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	"github.com/unmango/thecluster-operator/internal/ipam"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+const (
+	// WireguardMeshFieldOwner is the field manager used to apply mesh resources
+	WireguardMeshFieldOwner = "wireguardmesh-controller"
+
+	// WireguardMeshUIDLabel is the pod label holding the UID of the owning WireguardMesh
+	WireguardMeshUIDLabel = "core.thecluster.io/wireguardmesh-uid"
+
+	// WireguardMeshLabel is the label holding the name of the WireguardMesh a node's
+	// published key belongs to
+	WireguardMeshLabel = "core.thecluster.io/wireguardmesh"
+
+	// MeshPublicKeyKey is the key agents publish their node's public key under
+	MeshPublicKeyKey = "publicKey"
+
+	// MeshEndpointKey is the key agents publish their node's endpoint under
+	MeshEndpointKey = "endpoint"
+
+	// MeshPeersKey is the key the [Peer] sections of all mesh nodes are stored under
+	MeshPeersKey = "peers.conf"
+
+	// MeshAddressesKey is the key the "<node> <address>" lines of all mesh nodes are stored under
+	MeshAddressesKey = "addresses"
+
+	// MeshAgentContainerName is the name of the agent container
+	MeshAgentContainerName = "agent"
+
+	// meshNodeNameExtra is the user info extra the API server records the node
+	// of the pod a service account token is bound to in
+	meshNodeNameExtra = "authentication.kubernetes.io/node-name"
+
+	meshKeyPath   = "/var/lib/thecluster-operator/wireguard"
+	meshPeersPath = "/mesh"
+)
+
+var (
+	TypeAvailableWireguardMesh = "Available"
+)
+
+// meshAgentScript generates the node's key on the host, publishes the public key and
+// endpoint in the node's config map, and keeps the mesh interface in sync with the peers
+// config map. The config map is mounted as a directory, so the kubelet updates it
+// in place as nodes join and leave.
+var meshAgentScript = `set -eu
+key_file="` + meshKeyPath + `/$MESH_NAME.key"
+mkdir -p "$(dirname "$key_file")"
+[ -s "$key_file" ] || (umask 077 && wg genkey > "$key_file")
+public_key="$(wg pubkey < "$key_file")"
+
+case "$NODE_IP" in *:*) endpoint="[$NODE_IP]:$LISTEN_PORT" ;; *) endpoint="$NODE_IP:$LISTEN_PORT" ;; esac
+sa=/var/run/secrets/kubernetes.io/serviceaccount
+api="https://$KUBERNETES_SERVICE_HOST:$KUBERNETES_SERVICE_PORT/api/v1/namespaces/$NAMESPACE/configmaps"
+kube() {
+  curl -sS --cacert "$sa/ca.crt" -H "Authorization: Bearer $(cat "$sa/token")" \
+    -H "Content-Type: application/json" -o /dev/null -w '%{http_code}' "$@"
+}
+body="{\"apiVersion\":\"v1\",\"kind\":\"ConfigMap\",\"metadata\":{\"name\":\"$NODE_CONFIG_MAP\",\"labels\":{\"` + WireguardMeshLabel + `\":\"$MESH_NAME\"},\"ownerReferences\":[{\"apiVersion\":\"` + meshAPIVersion + `\",\"kind\":\"WireguardMesh\",\"name\":\"$MESH_NAME\",\"uid\":\"$MESH_UID\"}]},\"data\":{\"` + MeshPublicKeyKey + `\":\"$public_key\",\"` + MeshEndpointKey + `\":\"$endpoint\"}}"
+status="$(kube -X PUT -d "$body" "$api/$NODE_CONFIG_MAP")"
+[ "$status" != 404 ] || status="$(kube -X POST -d "$body" "$api")"
+case "$status" in 2*) ;; *) echo "Failed to publish public key: HTTP $status" >&2; exit 1 ;; esac
+echo "Published public key $public_key"
+
+ip link show "$INTERFACE" >/dev/null 2>&1 || ip link add "$INTERFACE" type wireguard
+trap 'ip link del "$INTERFACE" 2>/dev/null || true; exit 0' TERM INT
+
+applied=""
+routes=""
+while true; do
+  address="$(awk -v node="$NODE_NAME" '$1 == node { print $2 }' ` + meshPeersPath + `/` + MeshAddressesKey + ` 2>/dev/null || true)"
+  peers="$(awk -v key="PublicKey = $public_key" 'BEGIN { RS = ""; ORS = "\n\n" } index($0, key) == 0' ` + meshPeersPath + `/` + MeshPeersKey + ` 2>/dev/null || true)"
+  if [ -n "$address" ] && [ "$address $peers" != "$applied" ]; then
+    { printf '[Interface]\nPrivateKey = %s\nListenPort = %s\n\n' "$(cat "$key_file")" "$LISTEN_PORT"; printf '%s\n' "$peers"; } > /tmp/mesh.conf
+    wg syncconf "$INTERFACE" /tmp/mesh.conf
+    ip address replace "$address" dev "$INTERFACE"
+    ip link set "$INTERFACE" up
+
+    # Mesh addresses are covered by the pool's route, anything else is routed explicitly
+    for route in $routes; do ip route del "$route" dev "$INTERFACE" 2>/dev/null || true; done
+    routes="$(printf '%s\n' "$peers" | awk -F ' = ' '$1 == "AllowedIPs" { print $2 }' | tr ',' '\n' | tr -d ' ' | grep -v -e '/32$' -e '/128$' || true)"
+    for route in $routes; do ip route replace "$route" dev "$INTERFACE"; done
+
+    applied="$address $peers"
+    echo "Synced mesh peers"
+  fi
+  sleep 10 & wait $!
+done
+`
+
+var meshAPIVersion = corev1alpha1.GroupVersion.String()
+
+// MeshNodeConfigMapName returns the name of the config map the agent of mesh on
+// node publishes its public key and endpoint in
+func MeshNodeConfigMapName(mesh *corev1alpha1.WireguardMesh, node string) string {
+	return fmt.Sprintf("%s-node-%s", mesh.Name, node)
+}
+
+// MeshAgentName returns the name of the agent daemon set and service account of mesh
+func MeshAgentName(mesh *corev1alpha1.WireguardMesh) string {
+	return fmt.Sprintf("%s-mesh-agent", mesh.Name)
+}
+
+// MeshAgentPolicyName returns the name of the admission policy limiting the agents
+// of mesh to the config maps of their own nodes
+func MeshAgentPolicyName(mesh *corev1alpha1.WireguardMesh) string {
+	return fmt.Sprintf("wireguardmesh-%s-agent", mesh.Name)
+}
+
+// MeshPeersName returns the name of the config map holding the peers of mesh
+func MeshPeersName(mesh *corev1alpha1.WireguardMesh) string {
+	return fmt.Sprintf("%s-mesh-peers", mesh.Name)
+}
+
+// MeshSelectorLabels returns the labels selecting the agent pods of mesh
+func MeshSelectorLabels(mesh *corev1alpha1.WireguardMesh) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":     "wireguard-mesh",
+		"app.kubernetes.io/instance": mesh.Name,
+		WireguardMeshUIDLabel:        string(mesh.UID),
+	}
+}
+
+// WireguardMeshReconciler reconciles a WireguardMesh object
+type WireguardMeshReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// The image used for meshes that don't specify one, defaults to [DefaultWireguardImage]
+	DefaultImage string
+}
+
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardmeshes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardmeshes/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardmeshes/finalizers,verbs=update
+// +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=configmaps;serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingadmissionpolicies;validatingadmissionpolicybindings,verbs=get;list;watch;create;update;patch
+
+func (r *WireguardMeshReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	mesh := &corev1alpha1.WireguardMesh{}
+	if err := r.Get(ctx, req.NamespacedName, mesh); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if len(mesh.Status.Conditions) == 0 {
+		_ = meta.SetStatusCondition(
+			&mesh.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardMesh,
+				Status:  metav1.ConditionUnknown,
+				Reason:  "Reconciling",
+				Message: "Starting reconciliation",
+			},
+		)
+		if err := r.Status().Update(ctx, mesh); err != nil {
+			log.Error(err, "Failed to update wireguard mesh status")
+			return ctrl.Result{}, err
+		}
+		if err := r.Get(ctx, req.NamespacedName, mesh); err != nil {
+			log.Error(err, "Failed to re-fetch wireguard mesh")
+			return ctrl.Result{}, err
+		}
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes, client.MatchingLabels(mesh.Spec.NodeSelector)); err != nil {
+		log.Error(err, "Failed to list nodes")
+		return ctrl.Result{}, err
+	}
+
+	published := &corev1.ConfigMapList{}
+	if err := r.List(ctx, published,
+		client.InNamespace(meshNamespace(mesh)),
+		client.MatchingLabels{WireguardMeshLabel: mesh.Name},
+	); err != nil {
+		log.Error(err, "Failed to list published keys")
+		return ctrl.Result{}, err
+	}
+
+	if err := SyncMeshNodes(mesh, nodes.Items, published.Items); err != nil {
+		log.Error(err, "Failed to allocate node addresses")
+		var invalid *vpn.InvalidError
+		reason := "Reconciling"
+		if errors.As(err, &invalid) {
+			reason = "AllocationFailed"
+		}
+		_ = meta.SetStatusCondition(
+			&mesh.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardMesh,
+				Status:  metav1.ConditionFalse,
+				Reason:  reason,
+				Message: fmt.Sprintf("Failed to allocate node addresses for %s: %s", mesh.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, mesh); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		// Nodes are watched, so removing one triggers a reconcile
+		if invalid != nil {
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	daemonSet, err := r.ApplyAgents(ctx, mesh, nodes.Items, published.Items)
+	if err != nil {
+		log.Error(err, "Failed to apply mesh agents")
+		_ = meta.SetStatusCondition(
+			&mesh.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardMesh,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Reconciling",
+				Message: fmt.Sprintf("Failed to apply agents for %s: %s", mesh.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, mesh); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	desired, ready := daemonSet.Status.DesiredNumberScheduled, daemonSet.Status.NumberReady
+	if desired > 0 && ready == desired {
+		_ = meta.SetStatusCondition(
+			&mesh.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardMesh,
+				Status:  metav1.ConditionTrue,
+				Reason:  "Reconciling",
+				Message: fmt.Sprintf("Agents are ready on %d nodes", ready),
+			},
+		)
+	} else {
+		_ = meta.SetStatusCondition(
+			&mesh.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardMesh,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Progressing",
+				Message: fmt.Sprintf("Agents are ready on %d of %d nodes", ready, desired),
+			},
+		)
+	}
+	if err := r.Status().Update(ctx, mesh); err != nil {
+		log.Error(err, "Failed to update status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SyncMeshNodes updates the nodes in the status of mesh to the selected nodes.
+// Nodes keep their address for as long as they're selected, new nodes are
+// allocated the lowest free address, and the addresses of nodes that left are
+// released. The public keys and endpoints are read from the config maps the
+// agents published, which are only trusted under the name of their node.
+func SyncMeshNodes(mesh *corev1alpha1.WireguardMesh, nodes []corev1.Node, published []corev1.ConfigMap) error {
+	pool, err := netip.ParsePrefix(mesh.Spec.AddressPool)
+	if err != nil {
+		return vpn.Invalid("parsing address pool: %s", err)
+	}
+
+	keys := map[string]map[string]string{}
+	for _, cm := range published {
+		keys[cm.Name] = cm.Data
+	}
+
+	addresses := map[string]netip.Addr{}
+	for _, n := range mesh.Status.Nodes {
+		if addr, err := netip.ParsePrefix(n.Address); err == nil {
+			addresses[n.Name] = addr.Addr()
+		}
+	}
+
+	selected := []corev1.Node{}
+	used := []netip.Addr{}
+	for _, node := range nodes {
+		if node.DeletionTimestamp != nil {
+			continue
+		}
+		selected = append(selected, node)
+		if addr, ok := addresses[node.Name]; ok {
+			used = append(used, addr)
+		}
+	}
+	slices.SortFunc(selected, func(a, b corev1.Node) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	status := []corev1alpha1.WireguardMeshNode{}
+	for _, node := range selected {
+		addr, ok := addresses[node.Name]
+		if !ok {
+			if addr, err = ipam.Allocate(pool, used...); errors.Is(err, ipam.ErrExhausted) {
+				return vpn.Invalid("%s", err)
+			} else if err != nil {
+				return err
+			}
+			used = append(used, addr)
+		}
+
+		data := keys[MeshNodeConfigMapName(mesh, node.Name)]
+		status = append(status, corev1alpha1.WireguardMeshNode{
+			Name:      node.Name,
+			Address:   netip.PrefixFrom(addr, pool.Bits()).String(),
+			PublicKey: data[MeshPublicKeyKey],
+			Endpoint:  data[MeshEndpointKey],
+		})
+	}
+
+	mesh.Status.Nodes = status
+	return nil
+}
+
+// MeshPeers renders the [Peer] sections of the nodes of mesh that published their
+// keys, and the "<node> <address>" lines of all of its nodes
+func MeshPeers(mesh *corev1alpha1.WireguardMesh, nodes []corev1.Node) (string, string) {
+	podCIDRs := map[string][]string{}
+	for _, node := range nodes {
+		podCIDRs[node.Name] = node.Spec.PodCIDRs
+		if len(node.Spec.PodCIDRs) == 0 && node.Spec.PodCIDR != "" {
+			podCIDRs[node.Name] = []string{node.Spec.PodCIDR}
+		}
+	}
+
+	peers, addresses := &strings.Builder{}, &strings.Builder{}
+	for _, n := range mesh.Status.Nodes {
+		_, _ = fmt.Fprintf(addresses, "%s %s\n", n.Name, n.Address)
+
+		addr, err := netip.ParsePrefix(n.Address)
+		if err != nil || n.PublicKey == "" {
+			continue
+		}
+		if _, err := wireguard.ParseKey(n.PublicKey); err != nil {
+			continue
+		}
+
+		peer := wireguard.Peer{
+			PublicKey:  n.PublicKey,
+			Endpoint:   n.Endpoint,
+			AllowedIPs: []string{ipam.HostPrefix(addr.Addr()).String()},
+		}
+		if mesh.Spec.RoutePodCIDRs {
+			peer.AllowedIPs = append(peer.AllowedIPs, podCIDRs[n.Name]...)
+		}
+		if k := mesh.Spec.PersistentKeepalive; k != nil {
+			peer.PersistentKeepalive = int(*k)
+		}
+
+		peers.WriteString(peer.String())
+		peers.WriteString("\n")
+	}
+
+	return peers.String(), addresses.String()
+}
+
+// ApplyAgents applies the peers config map, the agent RBAC, and the agent daemon set
+// of mesh, and deletes the keys published by nodes that left the mesh. It returns
+// the daemon set as observed after the apply.
+func (r *WireguardMeshReconciler) ApplyAgents(ctx context.Context, mesh *corev1alpha1.WireguardMesh, nodes []corev1.Node, published []corev1.ConfigMap) (*appsv1.DaemonSet, error) {
+	namespace := meshNamespace(mesh)
+	peers, addresses := MeshPeers(mesh, nodes)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MeshPeersName(mesh),
+			Namespace: namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		cm.Data = map[string]string{
+			MeshPeersKey:     peers,
+			MeshAddressesKey: addresses,
+		}
+
+		return ctrl.SetControllerReference(mesh, cm, r.Scheme)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("writing peers: %w", err)
+	}
+
+	if err := r.applyAgentRBAC(ctx, mesh); err != nil {
+		return nil, fmt.Errorf("applying agent RBAC: %w", err)
+	}
+
+	members := map[string]bool{}
+	for _, n := range mesh.Status.Nodes {
+		members[MeshNodeConfigMapName(mesh, n.Name)] = true
+	}
+	for _, cm := range published {
+		if !members[cm.Name] {
+			if err := r.Delete(ctx, &cm); client.IgnoreNotFound(err) != nil {
+				return nil, fmt.Errorf("deleting published key %s: %w", cm.Name, err)
+			}
+		}
+	}
+
+	daemonSet, err := r.NewDaemonSet(mesh)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyAs(ctx, r.Client, WireguardMeshFieldOwner, daemonSet); err != nil {
+		return nil, fmt.Errorf("applying daemon set: %w", err)
+	}
+
+	return daemonSet, nil
+}
+
+// applyAgentRBAC lets the agents of mesh publish their keys in config maps of the
+// agent namespace. The role can't be limited to the agent's own node, so an
+// admission policy checks the node name bound to the agent's token instead.
+func (r *WireguardMeshReconciler) applyAgentRBAC(ctx context.Context, mesh *corev1alpha1.WireguardMesh) error {
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MeshAgentName(mesh),
+			Namespace: meshNamespace(mesh),
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, sa, func() error {
+		return ctrl.SetControllerReference(mesh, sa, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MeshAgentName(mesh),
+			Namespace: sa.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, role, func() error {
+		role.Rules = []rbacv1.PolicyRule{{
+			APIGroups: []string{""},
+			Resources: []string{"configmaps"},
+			Verbs:     []string{"create", "update"},
+		}}
+
+		return ctrl.SetControllerReference(mesh, role, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MeshAgentName(mesh),
+			Namespace: sa.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+		binding.RoleRef = rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     role.Name,
+		}
+		binding.Subjects = []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      sa.Name,
+			Namespace: sa.Namespace,
+		}}
+
+		return ctrl.SetControllerReference(mesh, binding, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.applyAgentPolicy(ctx, mesh, sa)
+}
+
+// applyAgentPolicy only admits config map writes by the agents of mesh to the config
+// map of the node the agent runs on, labeled for mesh. Updates must also target a
+// config map that already belongs to mesh, so agents can't take over other config maps.
+func (r *WireguardMeshReconciler) applyAgentPolicy(ctx context.Context, mesh *corev1alpha1.WireguardMesh, sa *corev1.ServiceAccount) error {
+	labels := func(obj string) string {
+		return fmt.Sprintf(`has(%[1]s.metadata.labels) && %[2]q in %[1]s.metadata.labels && %[1]s.metadata.labels[%[2]q] == %[3]q`,
+			obj, WireguardMeshLabel, mesh.Name)
+	}
+
+	policy := &admissionregistrationv1.ValidatingAdmissionPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: MeshAgentPolicyName(mesh),
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, policy, func() error {
+		policy.Spec = admissionregistrationv1.ValidatingAdmissionPolicySpec{
+			FailurePolicy: ptr.To(admissionregistrationv1.Fail),
+			MatchConstraints: &admissionregistrationv1.MatchResources{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{corev1.LabelMetadataName: sa.Namespace},
+				},
+				ResourceRules: []admissionregistrationv1.NamedRuleWithOperations{{
+					RuleWithOperations: admissionregistrationv1.RuleWithOperations{
+						Operations: []admissionregistrationv1.OperationType{
+							admissionregistrationv1.Create,
+							admissionregistrationv1.Update,
+						},
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{""},
+							APIVersions: []string{"v1"},
+							Resources:   []string{"configmaps"},
+						},
+					},
+				}},
+			},
+			MatchConditions: []admissionregistrationv1.MatchCondition{{
+				Name:       "mesh-agent",
+				Expression: fmt.Sprintf(`request.userInfo.username == "system:serviceaccount:%s:%s"`, sa.Namespace, sa.Name),
+			}},
+			Validations: []admissionregistrationv1.Validation{
+				{
+					Expression: fmt.Sprintf(`%[1]q in request.userInfo.extra && object.metadata.name == %[2]q + request.userInfo.extra[%[1]q][0]`,
+						meshNodeNameExtra, MeshNodeConfigMapName(mesh, "")),
+					Message: "mesh agents may only publish the config map of their own node",
+				},
+				{
+					Expression: labels("object"),
+					Message:    fmt.Sprintf("mesh agents must label their config map %s=%s", WireguardMeshLabel, mesh.Name),
+				},
+				{
+					Expression: fmt.Sprintf(`request.operation == "CREATE" || (%s)`, labels("oldObject")),
+					Message:    "mesh agents may only update config maps of their mesh",
+				},
+			},
+		}
+
+		return ctrl.SetControllerReference(mesh, policy, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+
+	binding := &admissionregistrationv1.ValidatingAdmissionPolicyBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: MeshAgentPolicyName(mesh),
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+		binding.Spec = admissionregistrationv1.ValidatingAdmissionPolicyBindingSpec{
+			PolicyName:        policy.Name,
+			ValidationActions: []admissionregistrationv1.ValidationAction{admissionregistrationv1.Deny},
+		}
+
+		return ctrl.SetControllerReference(mesh, binding, r.Scheme)
+	})
+
+	return err
+}
+
+// NewDaemonSet builds the desired agent daemon set for mesh. Agents run on the
+// host network, so the mesh interface is created in each node's network namespace.
+func (r *WireguardMeshReconciler) NewDaemonSet(mesh *corev1alpha1.WireguardMesh) (*appsv1.DaemonSet, error) {
+	iface := meshInterface(mesh)
+	listenPort := mesh.Spec.ListenPort
+	if listenPort == 0 {
+		listenPort = 51821
+	}
+
+	fieldEnv := func(name, path string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: path},
+			},
+		}
+	}
+
+	container := corev1.Container{
+		Name:            MeshAgentContainerName,
+		Image:           tunnelImage(mesh.Spec.Image, r.DefaultImage),
+		ImagePullPolicy: mesh.Spec.ImagePullPolicy,
+		Command:         []string{"/bin/sh", "-c", meshAgentScript},
+		Env: []corev1.EnvVar{
+			{Name: "MESH_NAME", Value: mesh.Name},
+			{Name: "INTERFACE", Value: iface},
+			{Name: "LISTEN_PORT", Value: fmt.Sprint(listenPort)},
+			{Name: "MESH_UID", Value: string(mesh.UID)},
+			fieldEnv("NAMESPACE", "metadata.namespace"),
+			fieldEnv("NODE_NAME", "spec.nodeName"),
+			fieldEnv("NODE_IP", "status.hostIP"),
+			// Expanded by the kubelet, so it must come after NODE_NAME
+			{Name: "NODE_CONFIG_MAP", Value: MeshNodeConfigMapName(mesh, "$(NODE_NAME)")},
+		},
+		Ports: []corev1.ContainerPort{{
+			Name:          "wireguard",
+			ContainerPort: listenPort,
+			HostPort:      listenPort,
+			Protocol:      corev1.ProtocolUDP,
+		}},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "keys", MountPath: meshKeyPath},
+			{Name: "peers", MountPath: meshPeersPath, ReadOnly: true},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{
+					Command: []string{"wg", "show", iface},
+				},
+			},
+			PeriodSeconds: 10,
+		},
+		SecurityContext: &corev1.SecurityContext{
+			Privileged:   ptr.To(true),
+			RunAsUser:    ptr.To[int64](0),
+			RunAsNonRoot: ptr.To(false),
+		},
+	}
+
+	daemonSet := &appsv1.DaemonSet{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+			Kind:       "DaemonSet",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MeshAgentName(mesh),
+			Namespace: meshNamespace(mesh),
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: MeshSelectorLabels(mesh),
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: MeshSelectorLabels(mesh),
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: MeshAgentName(mesh),
+					HostNetwork:        true,
+					DNSPolicy:          corev1.DNSClusterFirstWithHostNet,
+					NodeSelector:       mesh.Spec.NodeSelector,
+					Tolerations:        mesh.Spec.Tolerations,
+					Containers:         []corev1.Container{container},
+					Volumes: []corev1.Volume{
+						{
+							Name: "keys",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{
+									Path: meshKeyPath,
+									Type: ptr.To(corev1.HostPathDirectoryOrCreate),
+								},
+							},
+						},
+						{
+							Name: "peers",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: MeshPeersName(mesh)},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if err := ctrl.SetControllerReference(mesh, daemonSet, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	return daemonSet, nil
+}
+
+func meshNamespace(mesh *corev1alpha1.WireguardMesh) string {
+	if mesh.Spec.AgentNamespace != "" {
+		return mesh.Spec.AgentNamespace
+	}
+
+	return metav1.NamespaceSystem
+}
+
+func meshInterface(mesh *corev1alpha1.WireguardMesh) string {
+	if mesh.Spec.Interface != "" {
+		return mesh.Spec.Interface
+	}
+
+	return "wgmesh0"
+}
+
+// allMeshes enqueues every WireguardMesh, since any of them may select a node
+func (r *WireguardMeshReconciler) allMeshes(ctx context.Context, _ client.Object) []reconcile.Request {
+	list := &corev1alpha1.WireguardMeshList{}
+	if err := r.List(ctx, list); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list meshes")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, len(list.Items))
+	for i, m := range list.Items {
+		requests[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&m)}
+	}
+
+	return requests
+}
+
+// meshForPublishedKey enqueues the WireguardMesh a config map published by an agent belongs to
+func meshForPublishedKey(_ context.Context, obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[WireguardMeshLabel]
+	if !ok {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Name: name}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *WireguardMeshReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1alpha1.WireguardMesh{}).
+		Named("core-wireguardmesh").
+		Owns(&appsv1.DaemonSet{}).
+		Owns(&corev1.ConfigMap{}).
+		Watches(&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(meshForPublishedKey),
+		).
+		// Node status changes constantly, only joining and leaving matter
+		Watches(&corev1.Node{},
+			handler.EnqueueRequestsFromMapFunc(r.allMeshes),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		).
+		Complete(r)
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+var _ = Describe("WireguardMesh Controller", func() {
+	Context("When reconciling a resource", func() {
+		const resourceName = "test-mesh"
+
+		typeNamespacedName := types.NamespacedName{Name: resourceName}
+		nodeNames := []string{"test-mesh-node-a", "test-mesh-node-b", "test-mesh-node-c"}
+
+		var (
+			mesh                 *corev1alpha1.WireguardMesh
+			nodeKeys             []wireguard.Key
+			controllerReconciler *WireguardMeshReconciler
+		)
+
+		reconcileMesh := func(ctx context.Context) {
+			GinkgoHelper()
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, mesh)).To(Succeed())
+		}
+
+		BeforeEach(func(ctx context.Context) {
+			mesh = &corev1alpha1.WireguardMesh{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName},
+				Spec: corev1alpha1.WireguardMeshSpec{
+					AddressPool:    "10.200.0.0/24",
+					NodeSelector:   map[string]string{"mesh.example.com/member": "true"},
+					RoutePodCIDRs:  true,
+					AgentNamespace: "default",
+				},
+			}
+
+			controllerReconciler = &WireguardMeshReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("Creating the nodes")
+			nodeKeys = make([]wireguard.Key, len(nodeNames))
+			for i, name := range nodeNames {
+				key, err := wireguard.GeneratePrivateKey()
+				Expect(err).NotTo(HaveOccurred())
+				nodeKeys[i] = key.PublicKey()
+
+				labels := map[string]string{}
+				if name != "test-mesh-node-c" {
+					labels["mesh.example.com/member"] = "true"
+				}
+				Expect(k8sClient.Create(ctx, &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   name,
+						Labels: labels,
+					},
+					Spec: corev1.NodeSpec{
+						PodCIDR:  fmt.Sprintf("10.42.%d.0/24", i),
+						PodCIDRs: []string{fmt.Sprintf("10.42.%d.0/24", i)},
+					},
+				})).To(Succeed())
+
+				By("Publishing the node's key as the agent would")
+				Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      MeshNodeConfigMapName(mesh, name),
+						Namespace: "default",
+						Labels:    map[string]string{WireguardMeshLabel: mesh.Name},
+					},
+					Data: map[string]string{
+						MeshPublicKeyKey: nodeKeys[i].String(),
+						MeshEndpointKey:  fmt.Sprintf("192.168.1.1%d:51821", i),
+					},
+				})).To(Succeed())
+			}
+		})
+
+		JustBeforeEach(func(ctx context.Context) {
+			By("Creating the custom resource for the Kind WireguardMesh")
+			err := k8sClient.Get(ctx, typeNamespacedName, mesh)
+			if err != nil && errors.IsNotFound(err) {
+				Expect(k8sClient.Create(ctx, mesh)).To(Succeed())
+			}
+		})
+
+		AfterEach(func(ctx context.Context) {
+			resource := &corev1alpha1.WireguardMesh{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance WireguardMesh")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			By("Deleting the owned resources and nodes")
+			objs := []client.Object{
+				&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: MeshAgentName(resource), Namespace: "default"}},
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: MeshPeersName(resource), Namespace: "default"}},
+				&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: MeshAgentName(resource), Namespace: "default"}},
+				&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: MeshAgentName(resource), Namespace: "default"}},
+				&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: MeshAgentName(resource), Namespace: "default"}},
+				&admissionregistrationv1.ValidatingAdmissionPolicy{ObjectMeta: metav1.ObjectMeta{Name: MeshAgentPolicyName(resource)}},
+				&admissionregistrationv1.ValidatingAdmissionPolicyBinding{ObjectMeta: metav1.ObjectMeta{Name: MeshAgentPolicyName(resource)}},
+			}
+			for _, name := range nodeNames {
+				objs = append(objs,
+					&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}},
+					&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: MeshNodeConfigMapName(resource, name), Namespace: "default"}},
+				)
+			}
+			for _, obj := range objs {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, obj))).To(Succeed())
+			}
+		})
+
+		It("should allocate addresses to the selected nodes", func(ctx context.Context) {
+			reconcileMesh(ctx)
+
+			Expect(mesh.Status.Nodes).To(HaveExactElements(
+				corev1alpha1.WireguardMeshNode{
+					Name:      "test-mesh-node-a",
+					Address:   "10.200.0.1/24",
+					PublicKey: nodeKeys[0].String(),
+					Endpoint:  "192.168.1.10:51821",
+				},
+				corev1alpha1.WireguardMeshNode{
+					Name:      "test-mesh-node-b",
+					Address:   "10.200.0.2/24",
+					PublicKey: nodeKeys[1].String(),
+					Endpoint:  "192.168.1.11:51821",
+				},
+			))
+
+			available := meta.FindStatusCondition(mesh.Status.Conditions, TypeAvailableWireguardMesh)
+			Expect(available).NotTo(BeNil())
+			Expect(available.Status).To(Equal(metav1.ConditionFalse))
+			Expect(available.Reason).To(Equal("Progressing"))
+		})
+
+		It("should render the peers of every node", func(ctx context.Context) {
+			reconcileMesh(ctx)
+
+			cm := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      MeshPeersName(mesh),
+				Namespace: "default",
+			}, cm)).To(Succeed())
+			Expect(cm.Data).To(HaveKeyWithValue(MeshAddressesKey,
+				"test-mesh-node-a 10.200.0.1/24\ntest-mesh-node-b 10.200.0.2/24\n"))
+			Expect(cm.Data).To(HaveKeyWithValue(MeshPeersKey, And(
+				ContainSubstring("PublicKey = "+nodeKeys[0].String()),
+				ContainSubstring("PublicKey = "+nodeKeys[1].String()),
+				ContainSubstring("AllowedIPs = 10.200.0.1/32, 10.42.0.0/24"),
+				ContainSubstring("Endpoint = 192.168.1.11:51821"),
+				Not(ContainSubstring(nodeKeys[2].String())),
+			)))
+		})
+
+		It("should run the agent on the selected nodes", func(ctx context.Context) {
+			reconcileMesh(ctx)
+
+			daemonSet := &appsv1.DaemonSet{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      MeshAgentName(mesh),
+				Namespace: "default",
+			}, daemonSet)).To(Succeed())
+			Expect(metav1.IsControlledBy(daemonSet, mesh)).To(BeTrue())
+
+			spec := daemonSet.Spec.Template.Spec
+			Expect(spec.HostNetwork).To(BeTrue())
+			Expect(spec.NodeSelector).To(Equal(mesh.Spec.NodeSelector))
+			Expect(spec.ServiceAccountName).To(Equal(MeshAgentName(mesh)))
+			Expect(spec.Containers).To(HaveLen(1))
+			Expect(spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{
+				Name:  "NODE_CONFIG_MAP",
+				Value: MeshNodeConfigMapName(mesh, "$(NODE_NAME)"),
+			}))
+
+			binding := &rbacv1.RoleBinding{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      MeshAgentName(mesh),
+				Namespace: "default",
+			}, binding)).To(Succeed())
+			Expect(binding.Subjects).To(ConsistOf(rbacv1.Subject{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      MeshAgentName(mesh),
+				Namespace: "default",
+			}))
+
+			policy := &admissionregistrationv1.ValidatingAdmissionPolicy{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: MeshAgentPolicyName(mesh)}, policy)).To(Succeed())
+			Expect(metav1.IsControlledBy(policy, mesh)).To(BeTrue())
+		})
+
+		It("should keep addresses as nodes join and leave", func(ctx context.Context) {
+			reconcileMesh(ctx)
+
+			By("Removing the first node from the mesh")
+			Expect(k8sClient.Delete(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "test-mesh-node-a"}})).To(Succeed())
+			reconcileMesh(ctx)
+			Expect(mesh.Status.Nodes).To(HaveExactElements(
+				HaveField("Address", "10.200.0.2/24"),
+			))
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      MeshNodeConfigMapName(mesh, "test-mesh-node-a"),
+				Namespace: "default",
+			}, &corev1.ConfigMap{}))).To(BeTrue())
+
+			By("Adding the third node to the mesh")
+			node := &corev1.Node{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "test-mesh-node-c"}, node)).To(Succeed())
+			node.Labels = map[string]string{"mesh.example.com/member": "true"}
+			Expect(k8sClient.Update(ctx, node)).To(Succeed())
+			reconcileMesh(ctx)
+			Expect(mesh.Status.Nodes).To(HaveExactElements(
+				HaveField("Address", "10.200.0.2/24"),
+				HaveField("Address", "10.200.0.1/24"),
+			))
+		})
+	})
+})
@@ -0,0 +1,440 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	"github.com/unmango/thecluster-operator/internal/ipam"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+const (
+	// PeerServerIndex indexes WireguardPeers by the server they connect to
+	PeerServerIndex = "spec.serverRef.name"
+)
+
+var (
+	TypeReadyWireguardPeer = "Ready"
+	WireguardPeerFinalizer = "wireguardpeer.core.thecluster.io/finalizer"
+)
+
+// WireguardPeerReconciler reconciles a WireguardPeer object
+type WireguardPeerReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardpeers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardpeers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardpeers/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardservers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardservers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch
+
+func (r *WireguardPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	peer := &corev1alpha1.WireguardPeer{}
+	if err := r.Get(ctx, req.NamespacedName, peer); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if peer.DeletionTimestamp != nil {
+		if !controllerutil.ContainsFinalizer(peer, WireguardPeerFinalizer) {
+			return ctrl.Result{}, nil
+		}
+
+		log.Info("Releasing peer address")
+		if err := r.release(ctx, peer); err != nil {
+			log.Error(err, "Failed to release peer address")
+			return ctrl.Result{}, err
+		}
+
+		controllerutil.RemoveFinalizer(peer, WireguardPeerFinalizer)
+		if err := r.Update(ctx, peer); err != nil {
+			log.Error(err, "Failed to remove finalizer for WireguardPeer")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if len(peer.Status.Conditions) == 0 {
+		_ = meta.SetStatusCondition(
+			&peer.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeReadyWireguardPeer,
+				Status:  metav1.ConditionUnknown,
+				Reason:  "Reconciling",
+				Message: "Starting reconciliation",
+			},
+		)
+		if err := r.Status().Update(ctx, peer); err != nil {
+			log.Error(err, "Failed to update wireguard peer status")
+			return ctrl.Result{}, err
+		}
+		if err := r.Get(ctx, req.NamespacedName, peer); err != nil {
+			log.Error(err, "Failed to re-fetch wireguard peer")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if !controllerutil.ContainsFinalizer(peer, WireguardPeerFinalizer) {
+		log.Info("Adding finalizer for WireguardPeer")
+		controllerutil.AddFinalizer(peer, WireguardPeerFinalizer)
+		if err := r.Update(ctx, peer); err != nil {
+			log.Error(err, "Failed to update WireguardPeer with finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
+	srv := &corev1alpha1.WireguardServer{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: peer.Namespace, Name: peer.Spec.ServerRef.Name}, srv); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to get wireguard server")
+			return ctrl.Result{}, err
+		}
+
+		// Servers are watched, so creating it triggers a reconcile
+		_ = meta.SetStatusCondition(
+			&peer.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeReadyWireguardPeer,
+				Status:  metav1.ConditionFalse,
+				Reason:  "ServerNotFound",
+				Message: fmt.Sprintf("WireguardServer %s does not exist", peer.Spec.ServerRef.Name),
+			},
+		)
+		if err := r.Status().Update(ctx, peer); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	address, err := r.allocate(ctx, srv, peer)
+	if err != nil {
+		log.Error(err, "Failed to allocate peer address")
+		var invalid *vpn.InvalidError
+		reason := "Reconciling"
+		if errors.As(err, &invalid) {
+			reason = "AllocationFailed"
+		}
+		_ = meta.SetStatusCondition(
+			&peer.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeReadyWireguardPeer,
+				Status:  metav1.ConditionFalse,
+				Reason:  reason,
+				Message: fmt.Sprintf("Failed to allocate an address for %s: %s", peer.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, peer); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		// Releasing an address updates the server, which triggers a reconcile
+		if invalid != nil {
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	publicKey, err := r.RenderConfig(ctx, srv, peer, address)
+	if err != nil {
+		log.Error(err, "Failed to render peer config")
+		var conflict *vpn.SecretConflictError
+		reason := "Reconciling"
+		if errors.As(err, &conflict) {
+			reason = "SecretConflict"
+		}
+		_ = meta.SetStatusCondition(
+			&peer.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeReadyWireguardPeer,
+				Status:  metav1.ConditionFalse,
+				Reason:  reason,
+				Message: fmt.Sprintf("Failed to render config for %s: %s", peer.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, peer); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		// Unowned secrets aren't watched, check again in case it was removed
+		if conflict != nil {
+			return ctrl.Result{RequeueAfter: time.Minute}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	peer.Status.Address = address.String()
+	peer.Status.PublicKey = publicKey
+	peer.Status.SecretName = peer.Name
+	if srv.Status.PublicKey == "" || srv.Status.Endpoint == "" {
+		_ = meta.SetStatusCondition(
+			&peer.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeReadyWireguardPeer,
+				Status:  metav1.ConditionFalse,
+				Reason:  "WaitingForServer",
+				Message: fmt.Sprintf("Waiting for WireguardServer %s to publish its endpoint", srv.Name),
+			},
+		)
+	} else {
+		_ = meta.SetStatusCondition(
+			&peer.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeReadyWireguardPeer,
+				Status:  metav1.ConditionTrue,
+				Reason:  "Reconciling",
+				Message: fmt.Sprintf("Peer config for %s is ready", srv.Status.Endpoint),
+			},
+		)
+	}
+	if err := r.Status().Update(ctx, peer); err != nil {
+		log.Error(err, "Failed to update status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// allocate returns the address allocated to peer by srv, allocating one when
+// peer doesn't have one yet. Allocations are recorded in the server's status,
+// so concurrent allocations fail with a conflict and are retried.
+func (r *WireguardPeerReconciler) allocate(ctx context.Context, srv *corev1alpha1.WireguardServer, peer *corev1alpha1.WireguardPeer) (netip.Addr, error) {
+	for _, a := range srv.Status.Allocations {
+		if a.Peer == peer.Name {
+			return netip.ParseAddr(a.Address)
+		}
+	}
+
+	pool, err := netip.ParsePrefix(srv.Spec.AddressPool)
+	if err != nil {
+		return netip.Addr{}, vpn.Invalid("parsing address pool: %s", err)
+	}
+	server, err := ServerAddress(srv.Spec.AddressPool)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	used := []netip.Addr{server.Addr()}
+	owners := map[netip.Addr]string{server.Addr(): srv.Name}
+	for _, a := range srv.Status.Allocations {
+		if addr, err := netip.ParseAddr(a.Address); err == nil {
+			used = append(used, addr)
+			owners[addr] = a.Peer
+		}
+	}
+
+	var addr netip.Addr
+	if peer.Spec.Address != "" {
+		if addr, err = netip.ParseAddr(peer.Spec.Address); err != nil {
+			return netip.Addr{}, vpn.Invalid("parsing address: %s", err)
+		}
+		if !ipam.Usable(pool, addr) {
+			return netip.Addr{}, vpn.Invalid("address %s is not a host address of %s", addr, pool)
+		}
+		if owner, ok := owners[addr]; ok {
+			return netip.Addr{}, vpn.Invalid("address %s is allocated to %s", addr, owner)
+		}
+	} else if addr, err = ipam.Allocate(pool, used...); errors.Is(err, ipam.ErrExhausted) {
+		return netip.Addr{}, vpn.Invalid("%s", err)
+	} else if err != nil {
+		return netip.Addr{}, err
+	}
+
+	srv.Status.Allocations = append(srv.Status.Allocations, corev1alpha1.WireguardServerAllocation{
+		Address: addr.String(),
+		Peer:    peer.Name,
+	})
+	if err := r.Status().Update(ctx, srv); err != nil {
+		return netip.Addr{}, fmt.Errorf("recording allocation: %w", err)
+	}
+
+	return addr, nil
+}
+
+// release removes the allocations of peer from its server
+func (r *WireguardPeerReconciler) release(ctx context.Context, peer *corev1alpha1.WireguardPeer) error {
+	srv := &corev1alpha1.WireguardServer{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: peer.Namespace, Name: peer.Spec.ServerRef.Name}, srv); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	allocations := slices.DeleteFunc(slices.Clone(srv.Status.Allocations), func(a corev1alpha1.WireguardServerAllocation) bool {
+		return a.Peer == peer.Name
+	})
+	if len(allocations) == len(srv.Status.Allocations) {
+		return nil
+	}
+
+	srv.Status.Allocations = allocations
+	return r.Status().Update(ctx, srv)
+}
+
+// RenderConfig writes the keys and client config of peer to its secret, generating
+// a keypair unless the peer specifies its public key. The config is left out until
+// the server has published its endpoint. It returns the peer's public key.
+func (r *WireguardPeerReconciler) RenderConfig(ctx context.Context, srv *corev1alpha1.WireguardServer, peer *corev1alpha1.WireguardPeer, address netip.Addr) (string, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      peer.Name,
+			Namespace: peer.Namespace,
+		},
+	}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(secret), secret); client.IgnoreNotFound(err) != nil {
+		return "", err
+	}
+	if err := vpn.CheckSecretOwner(peer, secret); err != nil {
+		return "", err
+	}
+
+	var privateKey, publicKey string
+	if peer.Spec.PublicKey != "" {
+		publicKey = peer.Spec.PublicKey
+	} else {
+		data := strings.TrimSpace(string(secret.Data[corev1alpha1.WireguardKeyPairPrivateKey]))
+		key, err := wireguard.ParseKey(data)
+		if err != nil {
+			log.FromContext(ctx).Info("Generating peer key")
+			if key, err = wireguard.GeneratePrivateKey(); err != nil {
+				return "", fmt.Errorf("generating private key: %w", err)
+			}
+		}
+
+		privateKey = key.String()
+		publicKey = key.PublicKey().String()
+	}
+
+	data := map[string][]byte{
+		corev1alpha1.WireguardKeyPairPublicKey: []byte(publicKey),
+	}
+	if privateKey != "" {
+		data[corev1alpha1.WireguardKeyPairPrivateKey] = []byte(privateKey)
+	}
+	if srv.Status.PublicKey != "" && srv.Status.Endpoint != "" {
+		data[vpn.ConfigKey] = []byte(PeerConfig(srv, peer, address, privateKey).String())
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if err := vpn.CheckSecretOwner(peer, secret); err != nil {
+			return err
+		}
+		secret.Data = data
+		return ctrl.SetControllerReference(peer, secret, r.Scheme)
+	})
+	if err != nil {
+		return "", fmt.Errorf("writing peer secret: %w", err)
+	}
+
+	return publicKey, nil
+}
+
+// PeerConfig returns the config peer imports to connect to srv
+func PeerConfig(srv *corev1alpha1.WireguardServer, peer *corev1alpha1.WireguardPeer, address netip.Addr, privateKey string) *wireguard.Config {
+	p := wireguard.Peer{
+		PublicKey:  srv.Status.PublicKey,
+		Endpoint:   srv.Status.Endpoint,
+		AllowedIPs: peer.Spec.AllowedIPs,
+	}
+	if len(p.AllowedIPs) == 0 {
+		p.AllowedIPs = []string{"0.0.0.0/0"}
+	}
+	if k := peer.Spec.PersistentKeepalive; k != nil {
+		p.PersistentKeepalive = int(*k)
+	}
+
+	return &wireguard.Config{
+		Interface: wireguard.Interface{
+			PrivateKey: privateKey,
+			Address:    []string{ipam.HostPrefix(address).String()},
+			DNS:        srv.Spec.DNS,
+		},
+		Peers: []wireguard.Peer{p},
+	}
+}
+
+// indexPeerServers is an indexer for [PeerServerIndex]
+func indexPeerServers(obj client.Object) []string {
+	peer, ok := obj.(*corev1alpha1.WireguardPeer)
+	if !ok {
+		return nil
+	}
+
+	return []string{peer.Spec.ServerRef.Name}
+}
+
+// peersForServer enqueues the WireguardPeers connecting to a WireguardServer
+func (r *WireguardPeerReconciler) peersForServer(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := &corev1alpha1.WireguardPeerList{}
+	if err := r.List(ctx, list,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{PeerServerIndex: obj.GetName()},
+	); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list peers for server")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, len(list.Items))
+	for i, p := range list.Items {
+		requests[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&p)}
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *WireguardPeerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	ctx := context.Background()
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &corev1alpha1.WireguardPeer{}, PeerServerIndex, indexPeerServers); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1alpha1.WireguardPeer{}).
+		Named("core-wireguardpeer").
+		Owns(&corev1.Secret{}).
+		Watches(&corev1alpha1.WireguardServer{}, handler.EnqueueRequestsFromMapFunc(r.peersForServer)).
+		Complete(r)
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+var _ = Describe("WireguardPeer Controller", func() {
+	Context("When reconciling a resource", func() {
+		const (
+			resourceName = "test-peer"
+			serverName   = "test-peer-server"
+		)
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		serverNamespacedName := types.NamespacedName{
+			Name:      serverName,
+			Namespace: "default",
+		}
+		var (
+			peer             *corev1alpha1.WireguardPeer
+			server           *corev1alpha1.WireguardServer
+			peerReconciler   *WireguardPeerReconciler
+			serverReconciler *WireguardServerReconciler
+		)
+
+		reconcilePeer := func(ctx context.Context, name types.NamespacedName) {
+			GinkgoHelper()
+			_, err := peerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: name})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		reconcileServer := func(ctx context.Context) {
+			GinkgoHelper()
+			_, err := serverReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: serverNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, serverNamespacedName, server)).To(Succeed())
+		}
+
+		BeforeEach(func(ctx context.Context) {
+			peerReconciler = &WireguardPeerReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			serverReconciler = &WireguardServerReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("Creating the server")
+			server = &corev1alpha1.WireguardServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      serverName,
+					Namespace: "default",
+				},
+				Spec: corev1alpha1.WireguardServerSpec{
+					AddressPool: "10.13.13.0/24",
+					DNS:         []string{"1.1.1.1"},
+					Endpoint:    "vpn.example.com",
+				},
+			}
+			Expect(k8sClient.Create(ctx, server)).To(Succeed())
+			reconcileServer(ctx)
+
+			By("Creating the custom resource for the Kind WireguardPeer")
+			peer = &corev1alpha1.WireguardPeer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: corev1alpha1.WireguardPeerSpec{
+					ServerRef:           corev1.LocalObjectReference{Name: serverName},
+					PersistentKeepalive: ptr.To[int32](25),
+				},
+			}
+			Expect(k8sClient.Create(ctx, peer)).To(Succeed())
+		})
+
+		AfterEach(func(ctx context.Context) {
+			By("Cleanup the peers")
+			peers := &corev1alpha1.WireguardPeerList{}
+			Expect(k8sClient.List(ctx, peers, client.InNamespace("default"))).To(Succeed())
+			for _, p := range peers.Items {
+				Expect(k8sClient.Delete(ctx, &p)).To(Succeed())
+				reconcilePeer(ctx, client.ObjectKeyFromObject(&p))
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: p.Name, Namespace: "default"},
+				}))).To(Succeed())
+			}
+
+			By("Cleanup the server")
+			Expect(k8sClient.Delete(ctx, server)).To(Succeed())
+			for _, obj := range []client.Object{
+				&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: serverName, Namespace: "default"}},
+				&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: serverName, Namespace: "default"}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: serverName, Namespace: "default"}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: ServerKeySecretName(server), Namespace: "default"}},
+			} {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, obj))).To(Succeed())
+			}
+		})
+
+		It("should allocate an address and render a config", func(ctx context.Context) {
+			By("Reconciling the created resource")
+			reconcilePeer(ctx, typeNamespacedName)
+			Expect(k8sClient.Get(ctx, typeNamespacedName, peer)).To(Succeed())
+			Expect(peer.Finalizers).To(ContainElement(WireguardPeerFinalizer))
+			Expect(peer.Status.Address).To(Equal("10.13.13.2"))
+			ready := meta.FindStatusCondition(peer.Status.Conditions, TypeReadyWireguardPeer)
+			Expect(ready).NotTo(BeNil())
+			Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+
+			By("Checking the allocation")
+			Expect(k8sClient.Get(ctx, serverNamespacedName, server)).To(Succeed())
+			Expect(server.Status.Allocations).To(ConsistOf(corev1alpha1.WireguardServerAllocation{
+				Address: "10.13.13.2",
+				Peer:    resourceName,
+			}))
+
+			By("Checking the peer config")
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			Expect(metav1.IsControlledBy(secret, peer)).To(BeTrue())
+			key, err := wireguard.ParseKey(string(secret.Data[corev1alpha1.WireguardKeyPairPrivateKey]))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(peer.Status.PublicKey).To(Equal(key.PublicKey().String()))
+
+			config, err := wireguard.Parse(string(secret.Data[vpn.ConfigKey]))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(&wireguard.Config{
+				Interface: wireguard.Interface{
+					PrivateKey: key.String(),
+					Address:    []string{"10.13.13.2/32"},
+					DNS:        []string{"1.1.1.1"},
+				},
+				Peers: []wireguard.Peer{{
+					PublicKey:           server.Status.PublicKey,
+					Endpoint:            "vpn.example.com:51820",
+					AllowedIPs:          []string{"0.0.0.0/0"},
+					PersistentKeepalive: 25,
+				}},
+			}))
+			Expect(config.Validate()).To(Succeed())
+
+			By("Adding the peer to the server")
+			reconcileServer(ctx)
+			serverSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, serverNamespacedName, serverSecret)).To(Succeed())
+			serverConfig, err := wireguard.Parse(string(serverSecret.Data[vpn.ConfigKey]))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serverConfig.Peers).To(ConsistOf(wireguard.Peer{
+				PublicKey:  peer.Status.PublicKey,
+				AllowedIPs: []string{"10.13.13.2/32"},
+			}))
+
+			By("Keeping the address and key across reconciles")
+			reconcilePeer(ctx, typeNamespacedName)
+			Expect(k8sClient.Get(ctx, typeNamespacedName, peer)).To(Succeed())
+			Expect(peer.Status.Address).To(Equal("10.13.13.2"))
+			Expect(peer.Status.PublicKey).To(Equal(key.PublicKey().String()))
+
+			By("Releasing the address on deletion")
+			Expect(k8sClient.Delete(ctx, peer)).To(Succeed())
+			reconcilePeer(ctx, typeNamespacedName)
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, peer))).To(BeTrue())
+			Expect(k8sClient.Get(ctx, serverNamespacedName, server)).To(Succeed())
+			Expect(server.Status.Allocations).To(BeEmpty())
+		})
+
+		It("should accept a public key", func(ctx context.Context) {
+			key, err := wireguard.GeneratePrivateKey()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, peer)).To(Succeed())
+			peer.Spec.PublicKey = key.PublicKey().String()
+			Expect(k8sClient.Update(ctx, peer)).To(Succeed())
+
+			reconcilePeer(ctx, typeNamespacedName)
+			Expect(k8sClient.Get(ctx, typeNamespacedName, peer)).To(Succeed())
+			Expect(peer.Status.PublicKey).To(Equal(key.PublicKey().String()))
+
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			Expect(secret.Data).NotTo(HaveKey(corev1alpha1.WireguardKeyPairPrivateKey))
+			Expect(string(secret.Data[vpn.ConfigKey])).NotTo(ContainSubstring("PrivateKey"))
+		})
+
+		It("should not allocate the same address twice", func(ctx context.Context) {
+			reconcilePeer(ctx, typeNamespacedName)
+
+			By("Requesting the allocated address")
+			other := &corev1alpha1.WireguardPeer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-peer-other",
+					Namespace: "default",
+				},
+				Spec: corev1alpha1.WireguardPeerSpec{
+					ServerRef: corev1.LocalObjectReference{Name: serverName},
+					Address:   "10.13.13.2",
+				},
+			}
+			Expect(k8sClient.Create(ctx, other)).To(Succeed())
+			reconcilePeer(ctx, client.ObjectKeyFromObject(other))
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(other), other)).To(Succeed())
+			Expect(other.Status.Address).To(BeEmpty())
+			ready := meta.FindStatusCondition(other.Status.Conditions, TypeReadyWireguardPeer)
+			Expect(ready).NotTo(BeNil())
+			Expect(ready.Reason).To(Equal("AllocationFailed"))
+			Expect(ready.Message).To(ContainSubstring("allocated to " + resourceName))
+		})
+
+		It("should fail allocations from a stale server", func(ctx context.Context) {
+			stale := server.DeepCopy()
+			reconcilePeer(ctx, typeNamespacedName)
+
+			_, err := peerReconciler.allocate(ctx, stale, &corev1alpha1.WireguardPeer{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-peer-stale", Namespace: "default"},
+			})
+			Expect(errors.IsConflict(err)).To(BeTrue())
+		})
+	})
+})
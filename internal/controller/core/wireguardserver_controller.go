@@ -0,0 +1,488 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	"github.com/unmango/thecluster-operator/internal/ipam"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+const (
+	// WireguardServerFieldOwner is the field manager used to apply server resources
+	WireguardServerFieldOwner = "wireguardserver-controller"
+
+	// WireguardServerUIDLabel is the pod label holding the UID of the owning WireguardServer
+	WireguardServerUIDLabel = "core.thecluster.io/wireguardserver-uid"
+
+	// ServerInitContainerName is the name of the container that enables forwarding and NAT
+	ServerInitContainerName = "wireguard-server-init"
+
+	// ServerSyncContainerName is the name of the container that syncs peers into the running server
+	ServerSyncContainerName = "wireguard-server-sync"
+
+	serverSyncPath = "/run/wireguard-server"
+)
+
+var (
+	TypeAvailableWireguardServer = "Available"
+)
+
+// serverSyncScript hot-adds and removes peers whenever the config secret changes.
+// The secret is mounted as a directory, so the kubelet updates it in place and
+// the server doesn't need to restart when its peers change.
+var serverSyncScript = `set -eu
+applied=""
+while true; do
+  config="$(cat ` + serverSyncPath + `/` + vpn.ConfigKey + ` 2>/dev/null || true)"
+  if [ "$config" != "$applied" ] && ip link show wg0 >/dev/null 2>&1; then
+    wg-quick strip ` + serverSyncPath + `/` + vpn.ConfigKey + ` > /tmp/peers.conf
+    wg syncconf wg0 /tmp/peers.conf
+    applied="$config"
+    echo "Synced peers"
+  fi
+  sleep 10
+done
+`
+
+// ServerSelectorLabels returns the labels selecting the pods of srv
+func ServerSelectorLabels(srv *corev1alpha1.WireguardServer) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":     "wireguard-server",
+		"app.kubernetes.io/instance": srv.Name,
+		WireguardServerUIDLabel:      string(srv.UID),
+	}
+}
+
+// ServerKeySecretName returns the name of the secret holding the keys of srv
+func ServerKeySecretName(srv *corev1alpha1.WireguardServer) string {
+	return fmt.Sprintf("%s-key", srv.Name)
+}
+
+// ServerAddress returns the tunnel address of a server, the first address of pool
+func ServerAddress(pool string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(pool)
+	if err != nil {
+		return netip.Prefix{}, vpn.Invalid("parsing address pool: %s", err)
+	}
+
+	addr, err := ipam.Allocate(prefix)
+	if err != nil {
+		return netip.Prefix{}, vpn.Invalid("address pool %s is too small", pool)
+	}
+
+	return netip.PrefixFrom(addr, prefix.Bits()), nil
+}
+
+// WireguardServerReconciler reconciles a WireguardServer object
+type WireguardServerReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// The image used for servers that don't specify one, defaults to [DefaultWireguardImage]
+	DefaultImage string
+}
+
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardservers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardservers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardservers/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardpeers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+
+func (r *WireguardServerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	srv := &corev1alpha1.WireguardServer{}
+	if err := r.Get(ctx, req.NamespacedName, srv); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if len(srv.Status.Conditions) == 0 {
+		_ = meta.SetStatusCondition(
+			&srv.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardServer,
+				Status:  metav1.ConditionUnknown,
+				Reason:  "Reconciling",
+				Message: "Starting reconciliation",
+			},
+		)
+		if err := r.Status().Update(ctx, srv); err != nil {
+			log.Error(err, "Failed to update wireguard server status")
+			return ctrl.Result{}, err
+		}
+		if err := r.Get(ctx, req.NamespacedName, srv); err != nil {
+			log.Error(err, "Failed to re-fetch wireguard server")
+			return ctrl.Result{}, err
+		}
+	}
+
+	config, err := r.RenderConfig(ctx, srv)
+	if err != nil {
+		log.Error(err, "Failed to render server config")
+		var invalid *vpn.InvalidError
+		var conflict *vpn.SecretConflictError
+		reason := "Reconciling"
+		switch {
+		case errors.As(err, &invalid):
+			reason = "InvalidConfig"
+		case errors.As(err, &conflict):
+			reason = "SecretConflict"
+		}
+		_ = meta.SetStatusCondition(
+			&srv.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardServer,
+				Status:  metav1.ConditionFalse,
+				Reason:  reason,
+				Message: fmt.Sprintf("Failed to render config for %s: %s", srv.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, srv); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		// An invalid address pool can only be fixed by editing the server
+		if invalid != nil {
+			return ctrl.Result{}, nil
+		}
+		// Unowned secrets aren't watched, check again in case it was removed
+		if conflict != nil {
+			return ctrl.Result{RequeueAfter: time.Minute}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	deployment, err := r.NewDeployment(srv, config)
+	if err == nil {
+		err = applyAs(ctx, r.Client, WireguardServerFieldOwner, deployment)
+	}
+	if err != nil {
+		log.Error(err, "Failed to apply deployment for wireguard server")
+		_ = meta.SetStatusCondition(
+			&srv.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardServer,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Reconciling",
+				Message: fmt.Sprintf("Failed to apply deployment for %s: %s", srv.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, srv); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	svc, err := r.NewService(srv)
+	if err == nil {
+		err = applyAs(ctx, r.Client, WireguardServerFieldOwner, svc)
+	}
+	if err != nil {
+		log.Error(err, "Failed to apply service for wireguard server")
+		_ = meta.SetStatusCondition(
+			&srv.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardServer,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Reconciling",
+				Message: fmt.Sprintf("Failed to apply service for %s: %s", srv.Name, err),
+			},
+		)
+		if err := r.Status().Update(ctx, srv); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	endpoint, err := serviceEndpoint(ctx, r.Client, srv.Spec.Endpoint, svc)
+	if err != nil {
+		log.Error(err, "Failed to resolve server endpoint")
+		return ctrl.Result{}, err
+	}
+
+	srv.Status.Endpoint = endpoint
+	switch {
+	case endpoint == "":
+		_ = meta.SetStatusCondition(
+			&srv.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardServer,
+				Status:  metav1.ConditionFalse,
+				Reason:  "WaitingForEndpoint",
+				Message: fmt.Sprintf("Waiting for service %s to be assigned an address", svc.Name),
+			},
+		)
+	case !rolledOut(deployment):
+		_ = meta.SetStatusCondition(
+			&srv.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardServer,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Progressing",
+				Message: fmt.Sprintf("Waiting for deployment %s to roll out", deployment.Name),
+			},
+		)
+	default:
+		_ = meta.SetStatusCondition(
+			&srv.Status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailableWireguardServer,
+				Status:  metav1.ConditionTrue,
+				Reason:  "Reconciling",
+				Message: fmt.Sprintf("Server is listening on %s", endpoint),
+			},
+		)
+	}
+	if err := r.Status().Update(ctx, srv); err != nil {
+		log.Error(err, "Failed to update status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// RenderConfig renders the wg-quick config of srv into its config secret,
+// generating the server's keys if they don't exist yet. Peers are added once
+// they have been allocated an address and have a public key. It records the
+// server's public key and address in the status.
+func (r *WireguardServerReconciler) RenderConfig(ctx context.Context, srv *corev1alpha1.WireguardServer) (*wireguard.Config, error) {
+	address, err := ServerAddress(srv.Spec.AddressPool)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ensurePrivateKey(ctx, r.Client, r.Scheme, srv, ServerKeySecretName(srv))
+	if err != nil {
+		return nil, fmt.Errorf("generating server key: %w", err)
+	}
+
+	config := &wireguard.Config{
+		Interface: wireguard.Interface{
+			PrivateKey: key.String(),
+			Address:    []string{address.String()},
+			ListenPort: int(listenPort(srv)),
+		},
+	}
+
+	peers, err := r.serverPeers(ctx, srv)
+	if err != nil {
+		return nil, fmt.Errorf("listing peers: %w", err)
+	}
+	config.Peers = peers
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      srv.Name,
+			Namespace: srv.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if err := vpn.CheckSecretOwner(srv, secret); err != nil {
+			return err
+		}
+		secret.Data = map[string][]byte{
+			vpn.ConfigKey: []byte(config.String()),
+		}
+
+		return ctrl.SetControllerReference(srv, secret, r.Scheme)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("writing server config: %w", err)
+	}
+
+	srv.Status.PublicKey = key.PublicKey().String()
+	srv.Status.Address = address.String()
+	return config, nil
+}
+
+// serverPeers returns the [Peer] sections of the WireguardPeers allocated an address by srv
+func (r *WireguardServerReconciler) serverPeers(ctx context.Context, srv *corev1alpha1.WireguardServer) ([]wireguard.Peer, error) {
+	list := &corev1alpha1.WireguardPeerList{}
+	if err := r.List(ctx, list, client.InNamespace(srv.Namespace)); err != nil {
+		return nil, err
+	}
+
+	publicKeys := map[string]string{}
+	for _, p := range list.Items {
+		if p.Spec.ServerRef.Name == srv.Name && p.DeletionTimestamp == nil {
+			publicKeys[p.Name] = p.Status.PublicKey
+		}
+	}
+
+	peers := []wireguard.Peer{}
+	for _, a := range srv.Status.Allocations {
+		addr, err := netip.ParseAddr(a.Address)
+		if err != nil || publicKeys[a.Peer] == "" {
+			continue
+		}
+
+		peers = append(peers, wireguard.Peer{
+			PublicKey:  publicKeys[a.Peer],
+			AllowedIPs: []string{ipam.HostPrefix(addr).String()},
+		})
+	}
+
+	return peers, nil
+}
+
+// NewDeployment builds the desired deployment for srv. Pods are rolled whenever
+// the interface changes, while peers are synced into the running server.
+func (r *WireguardServerReconciler) NewDeployment(srv *corev1alpha1.WireguardServer, config *wireguard.Config) (*appsv1.Deployment, error) {
+	iface := &wireguard.Config{Interface: config.Interface}
+	sum := sha256.Sum256([]byte(iface.String()))
+	image := tunnelImage(srv.Spec.Image, r.DefaultImage)
+
+	init := newNATInitContainer(ServerInitContainerName, image, []string{srv.Spec.AddressPool})
+	container := newTunnelContainer(image, srv.Spec.ImagePullPolicy, listenPort(srv))
+
+	sync := corev1.Container{
+		Name:            ServerSyncContainerName,
+		Image:           image,
+		ImagePullPolicy: srv.Spec.ImagePullPolicy,
+		Command:         []string{"/bin/sh", "-c", serverSyncScript},
+		VolumeMounts: []corev1.VolumeMount{{
+			Name:      "config",
+			MountPath: serverSyncPath,
+			ReadOnly:  true,
+		}},
+		SecurityContext: rootSecurityContext(),
+	}
+
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      srv.Name,
+			Namespace: srv.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To[int32](1),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: ServerSelectorLabels(srv),
+			},
+			Strategy: appsv1.DeploymentStrategy{
+				// Only one pod can hold the server's key at a time
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: ServerSelectorLabels(srv),
+					Annotations: map[string]string{
+						ConfigHashAnnotation: hex.EncodeToString(sum[:]),
+					},
+				},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{init},
+					Containers:     []corev1.Container{container, sync},
+					Volumes: []corev1.Volume{{
+						Name: "config",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{
+								SecretName: srv.Name,
+							},
+						},
+					}},
+					SecurityContext: &corev1.PodSecurityContext{
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if err := ctrl.SetControllerReference(srv, deployment, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	return deployment, nil
+}
+
+// NewService builds the desired service exposing srv
+func (r *WireguardServerReconciler) NewService(srv *corev1alpha1.WireguardServer) (*corev1.Service, error) {
+	return newTunnelService(srv, r.Scheme, ServerSelectorLabels(srv), listenPort(srv), srv.Spec.Service)
+}
+
+func listenPort(srv *corev1alpha1.WireguardServer) int32 {
+	if srv.Spec.ListenPort > 0 {
+		return srv.Spec.ListenPort
+	}
+
+	return 51820
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *WireguardServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1alpha1.WireguardServer{}).
+		Named("core-wireguardserver").
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Owns(&corev1.Secret{}).
+		Watches(&corev1alpha1.WireguardPeer{}, handler.EnqueueRequestsFromMapFunc(peerToWireguardServer)).
+		Complete(r)
+}
+
+// peerToWireguardServer enqueues the server a WireguardPeer connects to
+func peerToWireguardServer(_ context.Context, obj client.Object) []reconcile.Request {
+	peer, ok := obj.(*corev1alpha1.WireguardPeer)
+	if !ok {
+		return nil
+	}
+
+	return []reconcile.Request{{
+		NamespacedName: client.ObjectKey{
+			Namespace: peer.Namespace,
+			Name:      peer.Spec.ServerRef.Name,
+		},
+	}}
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+var _ = Describe("WireguardServer Controller", func() {
+	Context("When reconciling a resource", func() {
+		const resourceName = "test-server"
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		var (
+			server               *corev1alpha1.WireguardServer
+			controllerReconciler *WireguardServerReconciler
+		)
+
+		reconcileServer := func(ctx context.Context) {
+			GinkgoHelper()
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, server)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			server = &corev1alpha1.WireguardServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: corev1alpha1.WireguardServerSpec{
+					AddressPool: "10.13.13.0/24",
+				},
+			}
+
+			controllerReconciler = &WireguardServerReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+		})
+
+		JustBeforeEach(func(ctx context.Context) {
+			By("Creating the custom resource for the Kind WireguardServer")
+			err := k8sClient.Get(ctx, typeNamespacedName, server)
+			if err != nil && errors.IsNotFound(err) {
+				Expect(k8sClient.Create(ctx, server)).To(Succeed())
+			}
+		})
+
+		AfterEach(func(ctx context.Context) {
+			resource := &corev1alpha1.WireguardServer{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance WireguardServer")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			By("Deleting the owned resources")
+			for _, obj := range []client.Object{
+				&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"}},
+				&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: ServerKeySecretName(resource), Namespace: "default"}},
+			} {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, obj))).To(Succeed())
+			}
+		})
+
+		It("should run the server", func(ctx context.Context) {
+			By("Reconciling the created resource")
+			reconcileServer(ctx)
+
+			By("Checking the generated key")
+			keySecret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      ServerKeySecretName(server),
+				Namespace: "default",
+			}, keySecret)).To(Succeed())
+			Expect(metav1.IsControlledBy(keySecret, server)).To(BeTrue())
+			key, err := wireguard.ParseKey(string(keySecret.Data[corev1alpha1.WireguardKeyPairPrivateKey]))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(server.Status.PublicKey).To(Equal(key.PublicKey().String()))
+			Expect(server.Status.Address).To(Equal("10.13.13.1/24"))
+
+			By("Checking the rendered config")
+			configSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, configSecret)).To(Succeed())
+			config, err := wireguard.Parse(string(configSecret.Data[vpn.ConfigKey]))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Interface).To(Equal(wireguard.Interface{
+				PrivateKey: key.String(),
+				Address:    []string{"10.13.13.1/24"},
+				ListenPort: 51820,
+			}))
+
+			By("Checking the deployment")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(metav1.IsControlledBy(deployment, server)).To(BeTrue())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey(ConfigHashAnnotation))
+			Expect(deployment.Spec.Template.Spec.InitContainers).To(ConsistOf(
+				HaveField("Name", ServerInitContainerName),
+			))
+			Expect(deployment.Spec.Template.Spec.Containers).To(ConsistOf(
+				SatisfyAll(
+					HaveField("Image", DefaultWireguardImage),
+					HaveField("Ports", ConsistOf(SatisfyAll(
+						HaveField("ContainerPort", int32(51820)),
+						HaveField("Protocol", corev1.ProtocolUDP),
+					))),
+				),
+				HaveField("Name", ServerSyncContainerName),
+			))
+
+			By("Checking the service")
+			svc := &corev1.Service{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, svc)).To(Succeed())
+			Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
+			Expect(svc.Spec.Selector).To(Equal(ServerSelectorLabels(server)))
+
+			By("Waiting for the load balancer")
+			available := meta.FindStatusCondition(server.Status.Conditions, TypeAvailableWireguardServer)
+			Expect(available).NotTo(BeNil())
+			Expect(available.Reason).To(Equal("WaitingForEndpoint"))
+			Expect(server.Status.Endpoint).To(BeEmpty())
+
+			svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "192.0.2.10"}}
+			Expect(k8sClient.Status().Update(ctx, svc)).To(Succeed())
+			reconcileServer(ctx)
+			Expect(server.Status.Endpoint).To(Equal("192.0.2.10:51820"))
+
+			By("Keeping the key across reconciles")
+			reconcileServer(ctx)
+			Expect(server.Status.PublicKey).To(Equal(key.PublicKey().String()))
+		})
+
+		When("the server uses a NodePort service", func() {
+			BeforeEach(func() {
+				server.Spec.Endpoint = "vpn.example.com"
+				server.Spec.Service = corev1alpha1.WireguardServerService{
+					Type:     corev1.ServiceTypeNodePort,
+					NodePort: 31820,
+				}
+			})
+
+			It("should publish the node port", func(ctx context.Context) {
+				reconcileServer(ctx)
+
+				svc := &corev1.Service{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, svc)).To(Succeed())
+				Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+				Expect(svc.Spec.Ports).To(ConsistOf(HaveField("NodePort", int32(31820))))
+				Expect(server.Status.Endpoint).To(Equal("vpn.example.com:31820"))
+			})
+		})
+
+		When("the key secret exists and is not controlled by the server", func() {
+			keyName := types.NamespacedName{
+				Name:      resourceName + "-key",
+				Namespace: "default",
+			}
+
+			BeforeEach(func(ctx context.Context) {
+				By("Creating an unowned key secret")
+				Expect(k8sClient.Create(ctx, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      keyName.Name,
+						Namespace: keyName.Namespace,
+					},
+					StringData: map[string]string{"other": "value"},
+				})).To(Succeed())
+			})
+
+			It("should not adopt the secret", func(ctx context.Context) {
+				By("Reconciling the created resource")
+				result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(time.Minute))
+
+				By("Checking the secret is untouched")
+				secret := &corev1.Secret{}
+				Expect(k8sClient.Get(ctx, keyName, secret)).To(Succeed())
+				Expect(secret.OwnerReferences).To(BeEmpty())
+				Expect(secret.Data).To(Equal(map[string][]byte{"other": []byte("value")}))
+
+				By("Checking the conflict is reported")
+				Expect(k8sClient.Get(ctx, typeNamespacedName, server)).To(Succeed())
+				available := meta.FindStatusCondition(server.Status.Conditions, TypeAvailableWireguardServer)
+				Expect(available).NotTo(BeNil())
+				Expect(available.Status).To(Equal(metav1.ConditionFalse))
+				Expect(available.Reason).To(Equal("SecretConflict"))
+			})
+		})
+
+		It("should reject node ports on load balancers", func(ctx context.Context) {
+			server.Spec.Service.NodePort = 31820
+			err := k8sClient.Update(ctx, server)
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("nodePort requires a NodePort service"))
+		})
+
+		It("should reject address pool changes", func(ctx context.Context) {
+			server.Spec.AddressPool = "10.14.14.0/24"
+			err := k8sClient.Update(ctx, server)
+			Expect(errors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("addressPool is immutable"))
+		})
+	})
+})
+
+var _ = DescribeTable("ServerAddress",
+	func(pool, expected string) {
+		address, err := ServerAddress(pool)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(address.String()).To(Equal(expected))
+	},
+	Entry("an IPv4 pool", "10.13.13.0/24", "10.13.13.1/24"),
+	Entry("an unmasked pool", "10.13.13.7/24", "10.13.13.1/24"),
+	Entry("an IPv6 pool", "fd00:13::/64", "fd00:13::1/64"),
+)
@@ -0,0 +1,205 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mullvad
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mullvadv1alpha1 "github.com/unmango/thecluster-operator/api/mullvad/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	mullvadclient "github.com/unmango/thecluster-operator/internal/mullvad"
+	"github.com/unmango/thecluster-operator/internal/provider"
+)
+
+var (
+	TypeAvailableWireguardConfig = vpn.TypeAvailable
+	TypeErrorWireguardConfig     = vpn.TypeError
+	RegenerateAnnotation         = "mullvad.thecluster.io/regenerate"
+
+	// WireguardConfigFinalizer revokes the registered key when a config is
+	// deleted, so it doesn't count towards the account's device limit
+	WireguardConfigFinalizer = "wireguardconfig.mullvad.thecluster.io/finalizer"
+)
+
+// WireguardConfigReconciler reconciles a WireguardConfig object
+type WireguardConfigReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Provider generates configs. Defaults to the Mullvad API.
+	Provider provider.Provider
+}
+
+// +kubebuilder:rbac:groups=mullvad.thecluster.io,resources=wireguardconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mullvad.thecluster.io,resources=wireguardconfigs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mullvad.thecluster.io,resources=wireguardconfigs/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core,resources=configmaps;secrets,verbs=get;list;watch;create;update;patch;delete
+
+func (r *WireguardConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	wg := &mullvadv1alpha1.WireguardConfig{}
+	if err := r.Get(ctx, req.NamespacedName, wg); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if wg.DeletionTimestamp != nil {
+		if !controllerutil.ContainsFinalizer(wg, WireguardConfigFinalizer) {
+			return ctrl.Result{}, nil
+		}
+
+		if key := wg.Status.PublicKey; key != "" {
+			log.Info("Revoking registered key", "publicKey", key)
+			err := r.revoke(ctx, wg, key)
+			var invalid *vpn.InvalidError
+			if errors.As(err, &invalid) {
+				// Without an account number the key can't be revoked, don't block deletion
+				log.Info("Skipping key revocation", "reason", err.Error())
+			} else if err != nil {
+				log.Error(err, "Failed to revoke registered key")
+				return ctrl.Result{}, err
+			}
+		}
+
+		controllerutil.RemoveFinalizer(wg, WireguardConfigFinalizer)
+		if err := r.Update(ctx, wg); err != nil {
+			log.Error(err, "Failed to remove finalizer for WireguardConfig")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(wg, WireguardConfigFinalizer) {
+		log.Info("Adding finalizer for WireguardConfig")
+		controllerutil.AddFinalizer(wg, WireguardConfigFinalizer)
+		if err := r.Update(ctx, wg); err != nil {
+			log.Error(err, "Failed to update WireguardConfig with finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
+	configReconciler := &vpn.ConfigReconciler{
+		Client:               r.Client,
+		Scheme:               r.Scheme,
+		RegenerateAnnotation: RegenerateAnnotation,
+		Revoke: func(ctx context.Context, publicKey string) error {
+			return r.revoke(ctx, wg, publicKey)
+		},
+	}
+
+	return configReconciler.Reconcile(ctx, wg,
+		vpn.Status{
+			Conditions:         &wg.Status.Conditions,
+			ObservedRegenerate: &wg.Status.ObservedRegenerate,
+			SecretName:         &wg.Status.SecretName,
+			Server:             &wg.Status.Server,
+			PublicKey:          &wg.Status.PublicKey,
+		},
+		func(ctx context.Context) (*provider.Result, error) {
+			return r.generate(ctx, wg)
+		},
+	)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *WireguardConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mullvadv1alpha1.WireguardConfig{}).
+		Named("mullvad-wireguardconfig").
+		Owns(&corev1.Secret{}).
+		Complete(r)
+}
+
+func (r *WireguardConfigReconciler) generate(ctx context.Context, c *mullvadv1alpha1.WireguardConfig) (*provider.Result, error) {
+	account, err := r.account(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.Generate(ctx, r.provider(),
+		provider.Credentials{Username: account},
+		relayFilter(c.Spec.Relay),
+	)
+}
+
+// revoke removes publicKey from the account of c, so replaced configs
+// don't count towards the account's device limit
+func (r *WireguardConfigReconciler) revoke(ctx context.Context, c *mullvadv1alpha1.WireguardConfig, publicKey string) error {
+	revoker, ok := r.provider().(provider.KeyRevoker)
+	if !ok {
+		return nil
+	}
+
+	account, err := r.account(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	return revoker.RevokeKey(ctx, account, publicKey)
+}
+
+func (r *WireguardConfigReconciler) account(ctx context.Context, c *mullvadv1alpha1.WireguardConfig) (string, error) {
+	v := c.Spec.AccountNumber
+	account, err := vpn.ResolveValue(ctx, r, c.Namespace, v.Value, v.ConfigMapKeyRef, v.SecretKeyRef)
+	if err != nil {
+		return "", err
+	}
+	if account == "" {
+		return "", vpn.Invalid("Configuration is missing account number")
+	}
+
+	return account, nil
+}
+
+func (r *WireguardConfigReconciler) provider() provider.Provider {
+	if r.Provider != nil {
+		return r.Provider
+	}
+
+	return &mullvadclient.Client{}
+}
+
+// relayFilter matches servers against the non-empty fields of f
+func relayFilter(f *mullvadv1alpha1.RelayFilter) provider.ServerFilter {
+	if f == nil {
+		return nil
+	}
+
+	return func(s provider.Server) bool {
+		if f.Country != "" && s.Country != f.Country {
+			return false
+		}
+		if f.City != "" && !strings.HasSuffix(s.Region, "-"+f.City) {
+			return false
+		}
+		if f.Hostname != "" && s.Name != f.Hostname {
+			return false
+		}
+
+		return true
+	}
+}
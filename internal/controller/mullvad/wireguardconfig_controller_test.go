@@ -0,0 +1,469 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mullvad
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mullvadv1alpha1 "github.com/unmango/thecluster-operator/api/mullvad/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	mullvadclient "github.com/unmango/thecluster-operator/internal/mullvad"
+)
+
+var _ = Describe("WireguardConfig Controller", func() {
+	Context("When reconciling a resource", func() {
+		const (
+			resourceName = "test-resource"
+			account      = "1234567890123456"
+		)
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		var (
+			wireguardconfig      *mullvadv1alpha1.WireguardConfig
+			api                  *httptest.Server
+			registered           int
+			devices              []string
+			revoked              []string
+			controllerReconciler *WireguardConfigReconciler
+		)
+
+		BeforeEach(func() {
+			wireguardconfig = &mullvadv1alpha1.WireguardConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: mullvadv1alpha1.WireguardConfigSpec{
+					AccountNumber: mullvadv1alpha1.WireguardConfigValue{
+						Value: account,
+					},
+				},
+			}
+
+			By("Starting the Mullvad API stand-in")
+			registered, devices, revoked = 0, nil, nil
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /public/relays/wireguard/v1/", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"countries":[` +
+					`{"name":"Sweden","code":"se","cities":[{"name":"Gothenburg","code":"got","relays":[` +
+					`{"hostname":"se-got-wg-001","ipv4_addr_in":"192.0.2.1","public_key":"got-key"}]}]},` +
+					`{"name":"Canada","code":"ca","cities":[{"name":"Toronto","code":"tor","relays":[` +
+					`{"hostname":"ca-tor-wg-101","ipv4_addr_in":"192.0.2.2","public_key":"tor-key"}]}]}` +
+					`]}`))
+			})
+			mux.HandleFunc("POST /wg/", func(w http.ResponseWriter, r *http.Request) {
+				if r.FormValue("account") != account {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"code":"INVALID_ACCOUNT","error":"Invalid account"}`))
+					return
+				}
+
+				registered++
+				devices = append(devices, r.FormValue("pubkey"))
+				_, _ = w.Write([]byte("10.64.0.2/32,fc00:bbbb:bbbb:bb01::2/128\n"))
+			})
+			mux.HandleFunc("POST /auth/v1/token", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"access_token":"test-token"}`))
+			})
+			mux.HandleFunc("GET /accounts/v1/devices", func(w http.ResponseWriter, r *http.Request) {
+				list := []map[string]string{}
+				for i, key := range devices {
+					list = append(list, map[string]string{"id": strconv.Itoa(i), "pubkey": key})
+				}
+				_ = json.NewEncoder(w).Encode(list)
+			})
+			mux.HandleFunc("DELETE /accounts/v1/devices/{id}", func(w http.ResponseWriter, r *http.Request) {
+				i, _ := strconv.Atoi(r.PathValue("id"))
+				revoked = append(revoked, devices[i])
+				w.WriteHeader(http.StatusNoContent)
+			})
+			api = httptest.NewServer(mux)
+
+			controllerReconciler = &WireguardConfigReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Provider: &mullvadclient.Client{APIURL: api.URL},
+			}
+		})
+
+		JustBeforeEach(func() {
+			By("creating the custom resource for the Kind WireguardConfig")
+			err := k8sClient.Get(ctx, typeNamespacedName, wireguardconfig)
+			if err != nil && errors.IsNotFound(err) {
+				Expect(k8sClient.Create(ctx, wireguardconfig)).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			resource := &mullvadv1alpha1.WireguardConfig{}
+			err := k8sClient.Get(ctx, typeNamespacedName, resource)
+			if err == nil {
+				By("Cleanup the specific resource instance WireguardConfig")
+				Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+				By("Reconciling the deletion to remove the finalizer")
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, resource))).To(BeTrue())
+
+			api.Close()
+
+			By("Deleting any generated config secrets")
+			secret := &corev1.Secret{}
+			if err := k8sClient.Get(ctx, typeNamespacedName, secret); err == nil {
+				Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+			}
+		})
+
+		It("should generate the config into a secret", func() {
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(registered).To(Equal(1))
+
+			By("Fetching the config secret")
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			Expect(secret.OwnerReferences).To(ConsistOf(And(
+				HaveField("Kind", "WireguardConfig"),
+				HaveField("Name", resourceName),
+			)))
+			config := string(secret.Data[vpn.ConfigKey])
+			Expect(config).To(ContainSubstring("Address = 10.64.0.2/32, fc00:bbbb:bbbb:bb01::2/128\n"))
+			Expect(config).To(ContainSubstring("DNS = 10.64.0.1\n"))
+			Expect(config).To(ContainSubstring("PublicKey = got-key\n"))
+			Expect(config).To(ContainSubstring("Endpoint = 192.0.2.1:51820\n"))
+
+			By("Fetching the config resource")
+			resource := &mullvadv1alpha1.WireguardConfig{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.SecretName).To(Equal(resourceName))
+			Expect(resource.Status.Server).To(Equal("se-got-wg-001"))
+
+			available := meta.IsStatusConditionTrue(
+				resource.Status.Conditions,
+				TypeAvailableWireguardConfig,
+			)
+			Expect(available).To(BeTrueBecause("The config is available"))
+		})
+
+		It("should revoke the replaced key when regenerating", func() {
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			resource := &mullvadv1alpha1.WireguardConfig{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.PublicKey).To(Equal(devices[0]))
+
+			By("Requesting a new config")
+			resource.Annotations = map[string]string{RegenerateAnnotation: "1"}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(registered).To(Equal(2))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.PublicKey).To(Equal(devices[1]))
+			Expect(revoked).To(ConsistOf(devices[0]))
+		})
+
+		It("should revoke the registered key when deleted", func() {
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			resource := &mullvadv1alpha1.WireguardConfig{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Finalizers).To(ContainElement(WireguardConfigFinalizer))
+
+			By("Deleting the config resource")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revoked).To(ConsistOf(devices[0]))
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, resource))).To(BeTrue())
+		})
+
+		When("a relay filter is specified", func() {
+			BeforeEach(func() {
+				wireguardconfig.Spec.Relay = &mullvadv1alpha1.RelayFilter{
+					Country: "ca",
+					City:    "tor",
+				}
+			})
+
+			It("should generate the config for a matching relay", func() {
+				By("Reconciling the created resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				By("Fetching the config resource")
+				resource := &mullvadv1alpha1.WireguardConfig{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.Server).To(Equal("ca-tor-wg-101"))
+			})
+		})
+
+		When("the account number is provided in a secret", func() {
+			const accountKey = "mullvad-account"
+
+			secretName := types.NamespacedName{
+				Name:      "my-credentials",
+				Namespace: typeNamespacedName.Namespace,
+			}
+
+			BeforeEach(func(ctx context.Context) {
+				By("Creating the secret")
+				sec := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      secretName.Name,
+						Namespace: secretName.Namespace,
+					},
+					StringData: map[string]string{
+						accountKey: account,
+					},
+				}
+				Expect(k8sClient.Create(ctx, sec)).To(Succeed())
+
+				wireguardconfig.Spec.AccountNumber = mullvadv1alpha1.WireguardConfigValue{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: sec.Name,
+						},
+						Key: accountKey,
+					},
+				}
+			})
+
+			AfterEach(func(ctx context.Context) {
+				By("Cleaning up the secret")
+				sec := &corev1.Secret{}
+				if err := k8sClient.Get(ctx, secretName, sec); err == nil {
+					Expect(k8sClient.Delete(ctx, sec)).To(Succeed())
+				}
+			})
+
+			It("should register with the secret value", func() {
+				By("Reconciling the created resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(registered).To(Equal(1))
+			})
+		})
+
+		When("a config secret exists and is not controlled by the config", func() {
+			BeforeEach(func(ctx context.Context) {
+				By("Creating an unowned config secret")
+				secret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      typeNamespacedName.Name,
+						Namespace: typeNamespacedName.Namespace,
+					},
+					StringData: map[string]string{
+						vpn.ConfigKey: "user config",
+					},
+				}
+				Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+				wireguardconfig.Annotations = map[string]string{
+					RegenerateAnnotation: "1",
+				}
+			})
+
+			It("should report a conflict without adopting the secret", func() {
+				By("Reconciling the created resource")
+				result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(time.Minute))
+				Expect(registered).To(BeZero(), "a new config was generated")
+
+				By("Checking the secret is untouched")
+				secret := &corev1.Secret{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+				Expect(secret.OwnerReferences).To(BeEmpty())
+				Expect(string(secret.Data[vpn.ConfigKey])).To(Equal("user config"))
+
+				By("Fetching the config resource")
+				resource := &mullvadv1alpha1.WireguardConfig{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+				cond := meta.FindStatusCondition(
+					resource.Status.Conditions,
+					TypeAvailableWireguardConfig,
+				)
+				Expect(cond).NotTo(BeNil())
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal("SecretConflict"))
+			})
+		})
+
+		When("a matching config exists", func() {
+			JustBeforeEach(func(ctx context.Context) {
+				By("Creating a matching config secret controlled by the config")
+				secret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      typeNamespacedName.Name,
+						Namespace: typeNamespacedName.Namespace,
+					},
+					StringData: map[string]string{
+						vpn.ConfigKey: "existing config",
+					},
+				}
+				Expect(controllerutil.SetControllerReference(wireguardconfig, secret, k8sClient.Scheme())).To(Succeed())
+				Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+			})
+
+			It("Should be available", func() {
+				By("Reconciling the created resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(registered).To(BeZero(), "a new config was generated")
+
+				By("Fetching the config resource")
+				resource := &mullvadv1alpha1.WireguardConfig{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+				available := meta.IsStatusConditionTrue(
+					resource.Status.Conditions,
+					TypeAvailableWireguardConfig,
+				)
+				Expect(available).To(BeTrueBecause("The config is available"))
+			})
+
+			When("the regenerate annotation is set", func() {
+				const regenerate = "2025-07-01T00:00:00Z"
+
+				BeforeEach(func() {
+					wireguardconfig.Annotations = map[string]string{
+						RegenerateAnnotation: regenerate,
+					}
+				})
+
+				It("should replace the config once", func() {
+					By("Reconciling the created resource twice")
+					for range 2 {
+						_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+							NamespacedName: typeNamespacedName,
+						})
+						Expect(err).NotTo(HaveOccurred())
+					}
+					Expect(registered).To(Equal(1))
+
+					By("Checking that the existing config was replaced")
+					secret := &corev1.Secret{}
+					Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+					Expect(string(secret.Data[vpn.ConfigKey])).NotTo(Equal("existing config"))
+
+					By("Fetching the config resource")
+					resource := &mullvadv1alpha1.WireguardConfig{}
+					Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+					Expect(resource.Status.ObservedRegenerate).To(Equal(regenerate))
+				})
+			})
+		})
+
+		When("the account number is not provided", func() {
+			BeforeEach(func() {
+				wireguardconfig.Spec.AccountNumber.Value = ""
+			})
+
+			It("Should error", func() {
+				By("Reconciling the created resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(registered).To(BeZero())
+
+				By("Fetching the config resource")
+				resource := &mullvadv1alpha1.WireguardConfig{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+				errored := meta.IsStatusConditionTrue(
+					resource.Status.Conditions,
+					TypeErrorWireguardConfig,
+				)
+				Expect(errored).To(BeTrueBecause("The config is invalid"))
+			})
+		})
+
+		When("the account number is rejected", func() {
+			BeforeEach(func() {
+				wireguardconfig.Spec.AccountNumber.Value = "0000000000000000"
+			})
+
+			It("Should error", func() {
+				By("Reconciling the created resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).To(MatchError(ContainSubstring("Invalid account")))
+
+				By("Fetching the config resource")
+				resource := &mullvadv1alpha1.WireguardConfig{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+				errored := meta.IsStatusConditionTrue(
+					resource.Status.Conditions,
+					TypeErrorWireguardConfig,
+				)
+				Expect(errored).To(BeTrueBecause("The account was rejected"))
+			})
+		})
+	})
+})
@@ -18,7 +18,7 @@ package pia
 
 import (
 	"context"
-	"time"
+	"crypto/x509"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -29,88 +29,70 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	piav1alpha1 "github.com/unmango/thecluster-operator/api/pia/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	piaclient "github.com/unmango/thecluster-operator/internal/pia"
+	"github.com/unmango/thecluster-operator/internal/provider"
 )
 
 var (
-	TypeAvailableWireguardConfig  = "Available"
-	TypeErrorWireguardConfig      = "Error"
+	TypeAvailableWireguardConfig = vpn.TypeAvailable
+	TypeErrorWireguardConfig     = vpn.TypeError
+	WireguardConfigFinalizer     = "wireguardconfig.pia.thecluster.io/finalizer"
+	RegenerateAnnotation         = "pia.thecluster.io/regenerate"
+
+	// Deprecated: configs are no longer generated by a pod. The condition is
+	// removed from configs that were generated by one.
 	TypeGeneratingWireguardConfig = "Generating"
-	WireguardConfigFinalizer      = "wireguardconfig.pia.thecluster.io/finalizer"
 )
 
 // WireguardConfigReconciler reconciles a WireguardConfig object
 type WireguardConfigReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// NewProvider creates the provider used to generate configs, trusting WireGuard
+	// servers signed by roots. Defaults to the PIA API.
+	NewProvider func(roots *x509.CertPool) (provider.Provider, error)
+
+	// APIReader lists the generator pods of previous versions, which the
+	// manager doesn't cache. Defaults to the client.
+	APIReader client.Reader
 }
 
 // +kubebuilder:rbac:groups=pia.thecluster.io,resources=wireguardconfigs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=pia.thecluster.io,resources=wireguardconfigs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=pia.thecluster.io,resources=wireguardconfigs/finalizers,verbs=update
-// +kubebuilder:rbac:groups=core,resources=pods;configmaps;secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=configmaps;secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=list;delete
 
 func (r *WireguardConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := logf.FromContext(ctx)
-
 	wg := &piav1alpha1.WireguardConfig{}
 	if err := r.Get(ctx, req.NamespacedName, wg); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	if len(wg.Status.Conditions) == 0 {
-		_ = meta.SetStatusCondition(&wg.Status.Conditions,
-			metav1.Condition{
-				Type:    TypeAvailableWireguardConfig,
-				Status:  metav1.ConditionUnknown,
-				Reason:  "Reconciling",
-				Message: "Starting reconciliation",
-			},
-		)
-		if err := r.Status().Update(ctx, wg); err != nil {
-			log.Error(err, "Failed to update wireguard config status")
-			return ctrl.Result{}, err
-		}
-		if err := r.Get(ctx, req.NamespacedName, wg); err != nil {
-			log.Error(err, "Failed to re-fetch wireguard config")
-			return ctrl.Result{}, err
-		}
-	}
-
-	cm := &corev1.ConfigMap{}
-	if err := r.Get(ctx, req.NamespacedName, cm); err == nil {
-		_ = meta.SetStatusCondition(&wg.Status.Conditions,
-			metav1.Condition{
-				Type:    TypeAvailableWireguardConfig,
-				Status:  metav1.ConditionTrue,
-				Reason:  "Reconciling",
-				Message: "Config map exists",
-			},
-		)
-		if err := r.Status().Update(ctx, wg); err != nil {
-			log.Error(err, "Failed to update wireguard config status")
-			return ctrl.Result{}, err
-		} else {
-			log.Info("Found existing config map, nothing to do")
-			return ctrl.Result{}, nil
-		}
-	}
-
-	podList := &corev1.PodList{}
-	err := r.List(ctx, podList, client.MatchingLabels{
-		"app.kubernetes.io/name":   "thecluster-operator",
-		"pia.thecluster.io/config": wg.Name,
-	})
-	if err != nil {
-		log.Error(err, "Failed to list pods matching config labels")
+	if err := r.removeLegacyOutput(ctx, wg); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to remove config map generated by a previous version")
 		return ctrl.Result{}, err
 	}
 
-	if len(podList.Items) == 0 {
-		log.Info("Creating pod to generate wireguard config")
-		return r.createGenPod(ctx, wg)
+	configReconciler := &vpn.ConfigReconciler{
+		Client:               r.Client,
+		Scheme:               r.Scheme,
+		RegenerateAnnotation: RegenerateAnnotation,
 	}
 
-	return ctrl.Result{}, nil
+	return configReconciler.Reconcile(ctx, wg,
+		vpn.Status{
+			Conditions:         &wg.Status.Conditions,
+			ObservedRegenerate: &wg.Status.ObservedRegenerate,
+			SecretName:         &wg.Status.SecretName,
+			Server:             &wg.Status.Server,
+		},
+		func(ctx context.Context) (*provider.Result, error) {
+			return r.generate(ctx, wg)
+		},
+	)
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -118,151 +100,120 @@ func (r *WireguardConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&piav1alpha1.WireguardConfig{}).
 		Named("pia-wireguardconfig").
-		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.Secret{}).
 		Complete(r)
 }
 
-func (r *WireguardConfigReconciler) createGenPod(ctx context.Context, c *piav1alpha1.WireguardConfig) (ctrl.Result, error) {
+// removeLegacyOutput deletes the generator pods of configs that were generated
+// before configs were written to a secret. A config map with the config's name
+// is only deleted when the config controls it, any other was created by hand.
+func (r *WireguardConfigReconciler) removeLegacyOutput(ctx context.Context, c *piav1alpha1.WireguardConfig) error {
 	log := logf.FromContext(ctx)
 
-	if !hasValue(c.Spec.Username) {
-		_ = meta.SetStatusCondition(&c.Status.Conditions,
-			metav1.Condition{
-				Type:    TypeErrorWireguardConfig,
-				Status:  metav1.ConditionTrue,
-				Reason:  "Invalid",
-				Message: "Configuration is missing username",
-			},
-		)
-		if err := r.Status().Update(ctx, c); err != nil {
-			log.Error(err, "Failed to update wireguard config status")
-			return ctrl.Result{}, err
-		} else {
-			log.Info("Spec is missing username")
-			return ctrl.Result{}, nil
+	pods := &corev1.PodList{}
+	err := r.apiReader().List(ctx, pods,
+		client.InNamespace(c.Namespace),
+		client.MatchingLabels{
+			"app.kubernetes.io/name":   "thecluster-operator",
+			"pia.thecluster.io/config": c.Name,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		log.Info("Deleting generate pod of a previous version", "pod", pod.Name)
+		if err := r.Delete(ctx, &pod); client.IgnoreNotFound(err) != nil {
+			return err
 		}
 	}
+	_ = meta.RemoveStatusCondition(&c.Status.Conditions, TypeGeneratingWireguardConfig)
 
-	if !hasValue(c.Spec.Password) {
-		_ = meta.SetStatusCondition(&c.Status.Conditions,
-			metav1.Condition{
-				Type:    TypeErrorWireguardConfig,
-				Status:  metav1.ConditionTrue,
-				Reason:  "Invalid",
-				Message: "Configuration is missing password",
-			},
-		)
-		if err := r.Status().Update(ctx, c); err != nil {
-			log.Error(err, "Failed to update wireguard config status")
-			return ctrl.Result{}, err
-		} else {
-			log.Info("Spec is missing password")
-			return ctrl.Result{}, nil
-		}
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(c), cm); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(cm, c) {
+		return nil
 	}
 
-	pod := &corev1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: "generate-config-",
-			Namespace:    c.Namespace,
-			Labels: map[string]string{
-				"app.kubernetes.io/name":   "thecluster-operator",
-				"pia.thecluster.io/config": c.Name,
-			},
-		},
-		Spec: corev1.PodSpec{
-			Containers: []corev1.Container{
-				{
-					Name:  "generate-config",
-					Image: "unstoppablemango/pia-manual-connections:v0.2.0-pia2023-02-06r0",
-					Env: []corev1.EnvVar{
-						getEnvVar("PIA_USER", c.Spec.Username),
-						getEnvVar("PIA_PASS", c.Spec.Password),
-						{Name: "PIA_PF", Value: "false"},
-						{Name: "PIA_CONNECT", Value: "false"},
-						{Name: "PIA_CONF_PATH", Value: "/out/pia0.conf"},
-						{Name: "VPN_PROTOCOL", Value: "wireguard"},
-						{Name: "DISABLE_IPV6", Value: "no"},
-						{Name: "DIP_TOKEN", Value: "no"},
-						{Name: "AUTOCONNECT", Value: "true"},
-					},
-					VolumeMounts: []corev1.VolumeMount{{
-						Name:      "results",
-						MountPath: "/out",
-					}},
-				},
-				{
-					Name:    "results",
-					Image:   "busybox:latest",
-					Command: []string{"sh", "-c", "sleep infinity"},
-					VolumeMounts: []corev1.VolumeMount{{
-						Name:      "results",
-						ReadOnly:  true,
-						MountPath: "/out",
-					}},
-				},
-			},
-			Volumes: []corev1.Volume{{
-				Name: "results",
-				VolumeSource: corev1.VolumeSource{
-					EmptyDir: &corev1.EmptyDirVolumeSource{},
-				},
-			}},
-		},
+	log.Info("Deleting config map controlled by the config, the config is now written to a secret")
+	return client.IgnoreNotFound(r.Delete(ctx, cm))
+}
+
+func (r *WireguardConfigReconciler) apiReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
 	}
 
-	if err := ctrl.SetControllerReference(c, pod, r.Scheme); err != nil {
-		return ctrl.Result{}, err
+	return r.Client
+}
+
+func (r *WireguardConfigReconciler) generate(ctx context.Context, c *piav1alpha1.WireguardConfig) (*provider.Result, error) {
+	username, err := r.resolve(ctx, c, c.Spec.Username)
+	if err != nil {
+		return nil, err
 	}
-	if err := r.Create(ctx, pod); err != nil {
-		return ctrl.Result{}, err
+	if username == "" {
+		return nil, vpn.Invalid("Configuration is missing username")
+	}
+
+	password, err := r.resolve(ctx, c, c.Spec.Password)
+	if err != nil {
+		return nil, err
+	}
+	if password == "" {
+		return nil, vpn.Invalid("Configuration is missing password")
 	}
 
-	_ = meta.SetStatusCondition(&c.Status.Conditions,
-		metav1.Condition{
-			Type:    TypeGeneratingWireguardConfig,
-			Status:  metav1.ConditionTrue,
-			Reason:  "Reconciling",
-			Message: "Config generator script pod created",
+	roots, err := r.loadCACert(ctx, c)
+	if err != nil {
+		return nil, vpn.Invalid("Configuration CA certificate is invalid: %s", err)
+	}
+
+	p, err := r.provider(roots)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.Generate(ctx, p,
+		provider.Credentials{
+			Username: username,
+			Password: password,
+		},
+		func(s provider.Server) bool {
+			return c.Spec.Region == "" || s.Region == c.Spec.Region
 		},
 	)
-	if err := r.Status().Update(ctx, c); err != nil {
-		log.Error(err, "Failed to update wireguard config status")
-		return ctrl.Result{}, err
-	} else {
-		return ctrl.Result{RequeueAfter: 15 * time.Second}, nil
-	}
 }
 
-func hasValue(config piav1alpha1.WireguardClientConfigValue) bool {
-	if config.Value != "" {
-		return true
-	}
-	if config.SecretKeyRef != nil {
-		return true
+func (r *WireguardConfigReconciler) provider(roots *x509.CertPool) (provider.Provider, error) {
+	if r.NewProvider != nil {
+		return r.NewProvider(roots)
 	}
-	if config.ConfigMapKeyRef != nil {
-		return true
+	if roots == nil {
+		return nil, vpn.Invalid("Configuration is missing CA certificate and the operator was built without PIA's")
 	}
 
-	return false
+	return &piaclient.Client{RootCAs: roots}, nil
 }
 
-func getEnvVar(name string, config piav1alpha1.WireguardClientConfigValue) corev1.EnvVar {
-	env := corev1.EnvVar{
-		Name:  name,
-		Value: config.Value,
-	}
-	if ref := config.SecretKeyRef; ref != nil {
-		env.ValueFrom = &corev1.EnvVarSource{
-			SecretKeyRef: ref,
-		}
+func (r *WireguardConfigReconciler) resolve(ctx context.Context, c *piav1alpha1.WireguardConfig, v piav1alpha1.WireguardClientConfigValue) (string, error) {
+	return vpn.ResolveValue(ctx, r, c.Namespace, v.Value, v.ConfigMapKeyRef, v.SecretKeyRef)
+}
+
+// loadCACert reads the PIA certificate authority referenced by c, falling back
+// to the CA bundled with the operator when none is configured.
+func (r *WireguardConfigReconciler) loadCACert(ctx context.Context, c *piav1alpha1.WireguardConfig) (*x509.CertPool, error) {
+	ref := c.Spec.CACert
+	if ref == nil {
+		return piaclient.DefaultRootCAs()
 	}
-	if ref := config.ConfigMapKeyRef; ref != nil {
-		env.ValueFrom = &corev1.EnvVarSource{
-			ConfigMapKeyRef: ref,
-		}
+
+	value, err := vpn.ResolveValue(ctx, r, c.Namespace, "", ref, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return env
+	return piaclient.NewCertPool([]byte(value))
 }
@@ -18,6 +18,14 @@ package pia
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -25,14 +33,73 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	piav1alpha1 "github.com/unmango/thecluster-operator/api/pia/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/controller/vpn"
+	piaclient "github.com/unmango/thecluster-operator/internal/pia"
+	"github.com/unmango/thecluster-operator/internal/provider"
 )
 
+type fakeProvider struct {
+	creds      provider.Credentials
+	registered int
+}
+
+func (f *fakeProvider) Authenticate(_ context.Context, creds provider.Credentials) (string, error) {
+	f.creds = creds
+	return "test-token", nil
+}
+
+func (f *fakeProvider) ListServers(context.Context) ([]provider.Server, error) {
+	return []provider.Server{
+		{Name: "chicago403", Region: "us_chicago", IP: "192.0.2.1"},
+		{Name: "toronto401", Region: "ca_toronto", IP: "192.0.2.2"},
+	}, nil
+}
+
+func (f *fakeProvider) RegisterKey(_ context.Context, _ string, server provider.Server, _ string) (*provider.Registration, error) {
+	f.registered++
+	return &provider.Registration{
+		Address:   []string{"10.0.0.2"},
+		DNS:       []string{"10.0.0.243"},
+		Endpoint:  server.IP + ":1337",
+		PublicKey: "server-key",
+		Server:    server,
+	}, nil
+}
+
+func (f *fakeProvider) PortForward(context.Context, string, *provider.Registration) (*provider.PortForward, error) {
+	return nil, provider.ErrPortForwardUnsupported
+}
+
+// newCACert returns a PEM encoded self-signed CA certificate
+func newCACert() []byte {
+	GinkgoHelper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
 var _ = Describe("WireguardConfig Controller", func() {
 	Context("When reconciling a resource", func() {
 		const (
@@ -45,7 +112,11 @@ var _ = Describe("WireguardConfig Controller", func() {
 			Name:      resourceName,
 			Namespace: "default",
 		}
-		var wireguardconfig *piav1alpha1.WireguardConfig
+		var (
+			wireguardconfig      *piav1alpha1.WireguardConfig
+			fake                 *fakeProvider
+			controllerReconciler *WireguardConfigReconciler
+		)
 
 		BeforeEach(func() {
 			wireguardconfig = &piav1alpha1.WireguardConfig{
@@ -62,6 +133,15 @@ var _ = Describe("WireguardConfig Controller", func() {
 					},
 				},
 			}
+
+			fake = &fakeProvider{}
+			controllerReconciler = &WireguardConfigReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				NewProvider: func(*x509.CertPool) (provider.Provider, error) {
+					return fake, nil
+				},
+			}
 		})
 
 		JustBeforeEach(func() {
@@ -80,99 +160,67 @@ var _ = Describe("WireguardConfig Controller", func() {
 			By("Cleanup the specific resource instance WireguardConfig")
 			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
 
-			By("Deleting any generate pods")
-			podList := &corev1.PodList{}
-			err = k8sClient.List(ctx, podList, client.MatchingLabels{
-				"app.kubernetes.io/name":   "thecluster-operator",
-				"pia.thecluster.io/config": typeNamespacedName.Name,
-			})
-			Expect(err).NotTo(HaveOccurred())
-
-			for _, pod := range podList.Items {
-				Expect(k8sClient.Delete(ctx, &pod)).To(Succeed())
+			By("Deleting any generated config secrets")
+			secret := &corev1.Secret{}
+			if err := k8sClient.Get(ctx, typeNamespacedName, secret); err == nil {
+				Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
 			}
 		})
 
-		It("should create a job to generate the config", func() {
+		It("should generate the config into a secret", func() {
 			By("Reconciling the created resource")
-			controllerReconciler := &WireguardConfigReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
-			}
-
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 				NamespacedName: typeNamespacedName,
 			})
 			Expect(err).NotTo(HaveOccurred())
+			Expect(fake.creds).To(Equal(provider.Credentials{
+				Username: piaUser,
+				Password: piaPass,
+			}))
+
+			By("Fetching the config secret")
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+			Expect(secret.OwnerReferences).To(ConsistOf(And(
+				HaveField("Kind", "WireguardConfig"),
+				HaveField("Name", resourceName),
+			)))
+			Expect(secret.Data).To(HaveKey(vpn.ConfigKey))
+			config := string(secret.Data[vpn.ConfigKey])
+			Expect(config).To(ContainSubstring("Address = 10.0.0.2\n"))
+			Expect(config).To(ContainSubstring("PublicKey = server-key\n"))
+			Expect(config).To(ContainSubstring("Endpoint = 192.0.2.1:1337\n"))
 
 			By("Fetching the config resource")
 			resource := &piav1alpha1.WireguardConfig{}
-			err = k8sClient.Get(ctx, typeNamespacedName, resource)
-			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.SecretName).To(Equal(resourceName))
+			Expect(resource.Status.Server).To(Equal("chicago403"))
 
-			generating := meta.IsStatusConditionTrue(
+			available := meta.IsStatusConditionTrue(
 				resource.Status.Conditions,
-				TypeGeneratingWireguardConfig,
+				TypeAvailableWireguardConfig,
 			)
-			Expect(generating).To(BeTrueBecause("The config is generating"))
-
-			podList := &corev1.PodList{}
-			Eventually(func() error {
-				return k8sClient.List(ctx, podList, client.MatchingLabels{
-					"app.kubernetes.io/name":   "thecluster-operator",
-					"pia.thecluster.io/config": typeNamespacedName.Name,
-				})
-			}).Should(Succeed())
+			Expect(available).To(BeTrueBecause("The config is available"))
+		})
 
-			Expect(podList.Items).To(HaveLen(1))
-			pod := podList.Items[0]
+		When("a region is specified", func() {
+			BeforeEach(func() {
+				wireguardconfig.Spec.Region = "ca_toronto"
+			})
 
-			Expect(pod.Name).To(HavePrefix("generate-config-"))
-			Expect(pod.OwnerReferences).To(ConsistOf(And(
-				HaveField("Kind", "WireguardConfig"),
-				HaveField("Name", resourceName),
-			)))
+			It("should generate the config for the region", func() {
+				By("Reconciling the created resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
 
-			Expect(pod.Spec.Volumes).To(ConsistOf(
-				corev1.Volume{
-					Name: "results",
-					VolumeSource: corev1.VolumeSource{
-						EmptyDir: &corev1.EmptyDirVolumeSource{},
-					},
-				},
-			))
-
-			Expect(pod.Spec.Containers).To(ConsistOf(
-				And(
-					HaveField("Name", "generate-config"),
-					HaveField("Image", "unstoppablemango/pia-manual-connections:v0.2.0-pia2023-02-06r0"),
-					HaveField("Env", []corev1.EnvVar{
-						{Name: "PIA_USER", Value: piaUser},
-						{Name: "PIA_PASS", Value: piaPass},
-						{Name: "PIA_PF", Value: "false"},
-						{Name: "PIA_CONNECT", Value: "false"},
-						{Name: "PIA_CONF_PATH", Value: "/out/pia0.conf"},
-						{Name: "VPN_PROTOCOL", Value: "wireguard"},
-						{Name: "DISABLE_IPV6", Value: "no"},
-						{Name: "DIP_TOKEN", Value: "no"},
-						{Name: "AUTOCONNECT", Value: "true"},
-					}),
-					HaveField("VolumeMounts", []corev1.VolumeMount{{
-						Name:      "results",
-						MountPath: "/out",
-					}}),
-				),
-				And(
-					HaveField("Name", "results"),
-					HaveField("Image", "busybox:latest"),
-					HaveField("Command", []string{"sh", "-c", "sleep infinity"}),
-					HaveField("VolumeMounts", []corev1.VolumeMount{{
-						Name:      "results",
-						ReadOnly:  true,
-						MountPath: "/out",
-					}}),
-				),
-			))
+				By("Fetching the config resource")
+				resource := &piav1alpha1.WireguardConfig{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.Server).To(Equal("toronto401"))
+			})
 		})
 
 		When("username is provided in a secret", func() {
@@ -191,7 +239,7 @@ var _ = Describe("WireguardConfig Controller", func() {
 						Namespace: secretName.Namespace,
 					},
 					StringData: map[string]string{
-						usernameKey: piaUser,
+						usernameKey: "secret-user",
 					},
 				}
 				Expect(k8sClient.Create(ctx, sec)).To(Succeed())
@@ -214,91 +262,68 @@ var _ = Describe("WireguardConfig Controller", func() {
 				}
 			})
 
-			It("should create a job to generate the config", func() {
+			It("should authenticate with the secret value", func() {
 				By("Reconciling the created resource")
-				controllerReconciler := &WireguardConfigReconciler{
-					Client: k8sClient,
-					Scheme: k8sClient.Scheme(),
-				}
-
 				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 					NamespacedName: typeNamespacedName,
 				})
 				Expect(err).NotTo(HaveOccurred())
+				Expect(fake.creds).To(Equal(provider.Credentials{
+					Username: "secret-user",
+					Password: piaPass,
+				}))
+			})
+		})
 
-				By("Fetching the config resource")
-				resource := &piav1alpha1.WireguardConfig{}
-				err = k8sClient.Get(ctx, typeNamespacedName, resource)
-				Expect(err).NotTo(HaveOccurred())
+		When("password is provided in a config map", func() {
+			const passwordKey = "pia-password"
 
-				generating := meta.IsStatusConditionTrue(
-					resource.Status.Conditions,
-					TypeGeneratingWireguardConfig,
-				)
-				Expect(generating).To(BeTrueBecause("The config is generating"))
+			cmName := types.NamespacedName{
+				Name:      "my-credentials",
+				Namespace: typeNamespacedName.Namespace,
+			}
 
-				podList := &corev1.PodList{}
-				Eventually(func() error {
-					return k8sClient.List(ctx, podList, client.MatchingLabels{
-						"app.kubernetes.io/name":   "thecluster-operator",
-						"pia.thecluster.io/config": typeNamespacedName.Name,
-					})
-				}).Should(Succeed())
-
-				Expect(podList.Items).To(HaveLen(1))
-				pod := podList.Items[0]
-
-				Expect(pod.Name).To(HavePrefix("generate-config-"))
-				Expect(pod.OwnerReferences).To(ConsistOf(And(
-					HaveField("Kind", "WireguardConfig"),
-					HaveField("Name", resourceName),
-				)))
-
-				Expect(pod.Spec.Volumes).To(ConsistOf(
-					corev1.Volume{
-						Name: "results",
-						VolumeSource: corev1.VolumeSource{
-							EmptyDir: &corev1.EmptyDirVolumeSource{},
+			BeforeEach(func(ctx context.Context) {
+				By("Creating the config map")
+				cm := &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      cmName.Name,
+						Namespace: cmName.Namespace,
+					},
+					Data: map[string]string{
+						passwordKey: "config-map-password",
+					},
+				}
+				Expect(k8sClient.Create(ctx, cm)).To(Succeed())
+
+				wireguardconfig.Spec.Password = piav1alpha1.WireguardClientConfigValue{
+					ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: cm.Name,
 						},
+						Key: passwordKey,
 					},
-				))
-
-				Expect(pod.Spec.Containers).To(ConsistOf(
-					And(
-						HaveField("Name", "generate-config"),
-						HaveField("Image", "unstoppablemango/pia-manual-connections:v0.2.0-pia2023-02-06r0"),
-						HaveField("Env", []corev1.EnvVar{
-							{
-								Name: "PIA_USER",
-								ValueFrom: &corev1.EnvVarSource{
-									SecretKeyRef: resource.Spec.Username.SecretKeyRef,
-								},
-							},
-							{Name: "PIA_PASS", Value: piaPass},
-							{Name: "PIA_PF", Value: "false"},
-							{Name: "PIA_CONNECT", Value: "false"},
-							{Name: "PIA_CONF_PATH", Value: "/out/pia0.conf"},
-							{Name: "VPN_PROTOCOL", Value: "wireguard"},
-							{Name: "DISABLE_IPV6", Value: "no"},
-							{Name: "DIP_TOKEN", Value: "no"},
-							{Name: "AUTOCONNECT", Value: "true"},
-						}),
-						HaveField("VolumeMounts", []corev1.VolumeMount{{
-							Name:      "results",
-							MountPath: "/out",
-						}}),
-					),
-					And(
-						HaveField("Name", "results"),
-						HaveField("Image", "busybox:latest"),
-						HaveField("Command", []string{"sh", "-c", "sleep infinity"}),
-						HaveField("VolumeMounts", []corev1.VolumeMount{{
-							Name:      "results",
-							ReadOnly:  true,
-							MountPath: "/out",
-						}}),
-					),
-				))
+				}
+			})
+
+			AfterEach(func(ctx context.Context) {
+				By("Cleaning up the config map")
+				cm := &corev1.ConfigMap{}
+				if err := k8sClient.Get(ctx, cmName, cm); err == nil {
+					Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
+				}
+			})
+
+			It("should authenticate with the config map value", func() {
+				By("Reconciling the created resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fake.creds).To(Equal(provider.Credentials{
+					Username: piaUser,
+					Password: "config-map-password",
+				}))
 			})
 		})
 
@@ -318,7 +343,7 @@ var _ = Describe("WireguardConfig Controller", func() {
 						Namespace: secretName.Namespace,
 					},
 					StringData: map[string]string{
-						passwordKey: piaPass,
+						passwordKey: "secret-password",
 					},
 				}
 				Expect(k8sClient.Create(ctx, sec)).To(Succeed())
@@ -341,98 +366,23 @@ var _ = Describe("WireguardConfig Controller", func() {
 				}
 			})
 
-			It("should create a job to generate the config", func() {
+			It("should authenticate with the secret value", func() {
 				By("Reconciling the created resource")
-				controllerReconciler := &WireguardConfigReconciler{
-					Client: k8sClient,
-					Scheme: k8sClient.Scheme(),
-				}
-
 				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 					NamespacedName: typeNamespacedName,
 				})
 				Expect(err).NotTo(HaveOccurred())
-
-				By("Fetching the config resource")
-				resource := &piav1alpha1.WireguardConfig{}
-				err = k8sClient.Get(ctx, typeNamespacedName, resource)
-				Expect(err).NotTo(HaveOccurred())
-
-				generating := meta.IsStatusConditionTrue(
-					resource.Status.Conditions,
-					TypeGeneratingWireguardConfig,
-				)
-				Expect(generating).To(BeTrueBecause("The config is generating"))
-
-				podList := &corev1.PodList{}
-				Eventually(func() error {
-					return k8sClient.List(ctx, podList, client.MatchingLabels{
-						"app.kubernetes.io/name":   "thecluster-operator",
-						"pia.thecluster.io/config": typeNamespacedName.Name,
-					})
-				}).Should(Succeed())
-
-				Expect(podList.Items).To(HaveLen(1))
-				pod := podList.Items[0]
-
-				Expect(pod.Name).To(HavePrefix("generate-config-"))
-				Expect(pod.OwnerReferences).To(ConsistOf(And(
-					HaveField("Kind", "WireguardConfig"),
-					HaveField("Name", resourceName),
-				)))
-
-				Expect(pod.Spec.Volumes).To(ConsistOf(
-					corev1.Volume{
-						Name: "results",
-						VolumeSource: corev1.VolumeSource{
-							EmptyDir: &corev1.EmptyDirVolumeSource{},
-						},
-					},
-				))
-
-				Expect(pod.Spec.Containers).To(ConsistOf(
-					And(
-						HaveField("Name", "generate-config"),
-						HaveField("Image", "unstoppablemango/pia-manual-connections:v0.2.0-pia2023-02-06r0"),
-						HaveField("Env", []corev1.EnvVar{
-							{Name: "PIA_USER", Value: piaUser},
-							{
-								Name: "PIA_PASS",
-								ValueFrom: &corev1.EnvVarSource{
-									SecretKeyRef: resource.Spec.Password.SecretKeyRef,
-								},
-							},
-							{Name: "PIA_PF", Value: "false"},
-							{Name: "PIA_CONNECT", Value: "false"},
-							{Name: "PIA_CONF_PATH", Value: "/out/pia0.conf"},
-							{Name: "VPN_PROTOCOL", Value: "wireguard"},
-							{Name: "DISABLE_IPV6", Value: "no"},
-							{Name: "DIP_TOKEN", Value: "no"},
-							{Name: "AUTOCONNECT", Value: "true"},
-						}),
-						HaveField("VolumeMounts", []corev1.VolumeMount{{
-							Name:      "results",
-							MountPath: "/out",
-						}}),
-					),
-					And(
-						HaveField("Name", "results"),
-						HaveField("Image", "busybox:latest"),
-						HaveField("Command", []string{"sh", "-c", "sleep infinity"}),
-						HaveField("VolumeMounts", []corev1.VolumeMount{{
-							Name:      "results",
-							ReadOnly:  true,
-							MountPath: "/out",
-						}}),
-					),
-				))
+				Expect(fake.creds).To(Equal(provider.Credentials{
+					Username: piaUser,
+					Password: "secret-password",
+				}))
 			})
 		})
 
 		When("username is provided in a config map", func() {
 			const usernameKey = "pia-username"
 
-			configMapName := types.NamespacedName{
+			cmName := types.NamespacedName{
 				Name:      "my-credentials",
 				Namespace: typeNamespacedName.Namespace,
 			}
@@ -441,11 +391,11 @@ var _ = Describe("WireguardConfig Controller", func() {
 				By("Creating the config map")
 				cm := &corev1.ConfigMap{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      configMapName.Name,
-						Namespace: configMapName.Namespace,
+						Name:      cmName.Name,
+						Namespace: cmName.Namespace,
 					},
 					Data: map[string]string{
-						usernameKey: piaUser,
+						usernameKey: "config-map-user",
 					},
 				}
 				Expect(k8sClient.Create(ctx, cm)).To(Succeed())
@@ -463,313 +413,350 @@ var _ = Describe("WireguardConfig Controller", func() {
 			AfterEach(func(ctx context.Context) {
 				By("Cleaning up the config map")
 				cm := &corev1.ConfigMap{}
-				if err := k8sClient.Get(ctx, configMapName, cm); err == nil {
+				if err := k8sClient.Get(ctx, cmName, cm); err == nil {
 					Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
 				}
 			})
 
-			It("should create a job to generate the config", func() {
+			It("should authenticate with the config map value", func() {
 				By("Reconciling the created resource")
-				controllerReconciler := &WireguardConfigReconciler{
-					Client: k8sClient,
-					Scheme: k8sClient.Scheme(),
-				}
-
 				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 					NamespacedName: typeNamespacedName,
 				})
 				Expect(err).NotTo(HaveOccurred())
+				Expect(fake.creds).To(Equal(provider.Credentials{
+					Username: "config-map-user",
+					Password: piaPass,
+				}))
+			})
+		})
 
-				By("Fetching the config resource")
-				resource := &piav1alpha1.WireguardConfig{}
-				err = k8sClient.Get(ctx, typeNamespacedName, resource)
-				Expect(err).NotTo(HaveOccurred())
-
-				generating := meta.IsStatusConditionTrue(
-					resource.Status.Conditions,
-					TypeGeneratingWireguardConfig,
-				)
-				Expect(generating).To(BeTrueBecause("The config is generating"))
+		When("a generate pod of a previous version exists", func() {
+			const genPodName = "generate-config-fjdsk"
 
-				podList := &corev1.PodList{}
-				Eventually(func() error {
-					return k8sClient.List(ctx, podList, client.MatchingLabels{
-						"app.kubernetes.io/name":   "thecluster-operator",
-						"pia.thecluster.io/config": typeNamespacedName.Name,
-					})
-				}).Should(Succeed())
-
-				Expect(podList.Items).To(HaveLen(1))
-				pod := podList.Items[0]
-
-				Expect(pod.Name).To(HavePrefix("generate-config-"))
-				Expect(pod.OwnerReferences).To(ConsistOf(And(
-					HaveField("Kind", "WireguardConfig"),
-					HaveField("Name", resourceName),
-				)))
-
-				Expect(pod.Spec.Volumes).To(ConsistOf(
-					corev1.Volume{
-						Name: "results",
-						VolumeSource: corev1.VolumeSource{
-							EmptyDir: &corev1.EmptyDirVolumeSource{},
+			BeforeEach(func() {
+				By("creating a generate pod")
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      genPodName,
+						Namespace: typeNamespacedName.Namespace,
+						Labels: map[string]string{
+							"app.kubernetes.io/name":   "thecluster-operator",
+							"pia.thecluster.io/config": typeNamespacedName.Name,
 						},
 					},
-				))
-
-				Expect(pod.Spec.Containers).To(ConsistOf(
-					And(
-						HaveField("Name", "generate-config"),
-						HaveField("Image", "unstoppablemango/pia-manual-connections:v0.2.0-pia2023-02-06r0"),
-						HaveField("Env", []corev1.EnvVar{
-							{
-								Name: "PIA_USER",
-								ValueFrom: &corev1.EnvVarSource{
-									ConfigMapKeyRef: resource.Spec.Username.ConfigMapKeyRef,
-								},
-							},
-							{Name: "PIA_PASS", Value: piaPass},
-							{Name: "PIA_PF", Value: "false"},
-							{Name: "PIA_CONNECT", Value: "false"},
-							{Name: "PIA_CONF_PATH", Value: "/out/pia0.conf"},
-							{Name: "VPN_PROTOCOL", Value: "wireguard"},
-							{Name: "DISABLE_IPV6", Value: "no"},
-							{Name: "DIP_TOKEN", Value: "no"},
-							{Name: "AUTOCONNECT", Value: "true"},
-						}),
-						HaveField("VolumeMounts", []corev1.VolumeMount{{
-							Name:      "results",
-							MountPath: "/out",
-						}}),
-					),
-					And(
-						HaveField("Name", "results"),
-						HaveField("Image", "busybox:latest"),
-						HaveField("Command", []string{"sh", "-c", "sleep infinity"}),
-						HaveField("VolumeMounts", []corev1.VolumeMount{{
-							Name:      "results",
-							ReadOnly:  true,
-							MountPath: "/out",
-						}}),
-					),
-				))
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{
+							Name:  "stub",
+							Image: "busybox",
+						}},
+					},
+				}
+				Expect(k8sClient.Create(ctx, pod)).To(Succeed())
 			})
-		})
 
-		When("password is provided in a config map", func() {
-			const passwordKey = "pia-password"
+			It("should delete the pod", func() {
+				By("Reconciling the created resource")
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
 
-			configMapName := types.NamespacedName{
-				Name:      "my-credentials",
-				Namespace: typeNamespacedName.Namespace,
-			}
+				By("Listing the pods with matching labels")
+				podList := &corev1.PodList{}
+				err = k8sClient.List(ctx, podList, client.MatchingLabels{
+					"app.kubernetes.io/name":   "thecluster-operator",
+					"pia.thecluster.io/config": typeNamespacedName.Name,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				// envtest has no garbage collector, so the pod lingers with a deletion timestamp
+				for _, pod := range podList.Items {
+					Expect(pod.DeletionTimestamp).NotTo(BeNil())
+				}
+			})
+		})
+
+		When("a config map with the same name exists", func() {
+			var (
+				cm         *corev1.ConfigMap
+				controlled bool
+			)
 
 			BeforeEach(func(ctx context.Context) {
-				By("Creating the config map")
-				cm := &corev1.ConfigMap{
+				By("Creating a matching config map")
+				cm = &corev1.ConfigMap{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      configMapName.Name,
-						Namespace: configMapName.Namespace,
+						Name:      typeNamespacedName.Name,
+						Namespace: typeNamespacedName.Namespace,
 					},
 					Data: map[string]string{
-						passwordKey: piaPass,
+						"wg0.conf": "user config",
 					},
 				}
-				Expect(k8sClient.Create(ctx, cm)).To(Succeed())
+				controlled = false
+			})
 
-				wireguardconfig.Spec.Password = piav1alpha1.WireguardClientConfigValue{
-					ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: cm.Name,
-						},
-						Key: passwordKey,
-					},
+			JustBeforeEach(func(ctx context.Context) {
+				if controlled {
+					Expect(controllerutil.SetControllerReference(wireguardconfig, cm, k8sClient.Scheme())).To(Succeed())
 				}
+				Expect(k8sClient.Create(ctx, cm)).To(Succeed())
 			})
 
 			AfterEach(func(ctx context.Context) {
 				By("Cleaning up the config map")
-				cm := &corev1.ConfigMap{}
-				if err := k8sClient.Get(ctx, configMapName, cm); err == nil {
+				if err := k8sClient.Get(ctx, typeNamespacedName, cm); err == nil {
 					Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
 				}
 			})
 
-			It("should create a job to generate the config", func() {
+			It("should keep it and generate the config into a secret", func() {
 				By("Reconciling the created resource")
-				controllerReconciler := &WireguardConfigReconciler{
-					Client: k8sClient,
-					Scheme: k8sClient.Scheme(),
-				}
-
 				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 					NamespacedName: typeNamespacedName,
 				})
 				Expect(err).NotTo(HaveOccurred())
 
+				existing := &corev1.ConfigMap{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, existing)).To(Succeed())
+				Expect(existing.Data).To(HaveKeyWithValue("wg0.conf", "user config"))
+
 				By("Fetching the config resource")
 				resource := &piav1alpha1.WireguardConfig{}
 				err = k8sClient.Get(ctx, typeNamespacedName, resource)
 				Expect(err).NotTo(HaveOccurred())
+				Expect(resource.Status.SecretName).To(Equal(resourceName))
 
-				generating := meta.IsStatusConditionTrue(
+				available := meta.IsStatusConditionTrue(
 					resource.Status.Conditions,
-					TypeGeneratingWireguardConfig,
+					TypeAvailableWireguardConfig,
 				)
-				Expect(generating).To(BeTrueBecause("The config is generating"))
+				Expect(available).To(BeTrueBecause("The config is available"))
+			})
 
-				podList := &corev1.PodList{}
-				Eventually(func() error {
-					return k8sClient.List(ctx, podList, client.MatchingLabels{
-						"app.kubernetes.io/name":   "thecluster-operator",
-						"pia.thecluster.io/config": typeNamespacedName.Name,
+			When("the config map is controlled by the config", func() {
+				BeforeEach(func() {
+					controlled = true
+				})
+
+				It("should delete it", func() {
+					By("Reconciling the created resource")
+					_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+						NamespacedName: typeNamespacedName,
 					})
-				}).Should(Succeed())
-
-				Expect(podList.Items).To(HaveLen(1))
-				pod := podList.Items[0]
-
-				Expect(pod.Name).To(HavePrefix("generate-config-"))
-				Expect(pod.OwnerReferences).To(ConsistOf(And(
-					HaveField("Kind", "WireguardConfig"),
-					HaveField("Name", resourceName),
-				)))
-
-				Expect(pod.Spec.Volumes).To(ConsistOf(
-					corev1.Volume{
-						Name: "results",
-						VolumeSource: corev1.VolumeSource{
-							EmptyDir: &corev1.EmptyDirVolumeSource{},
-						},
-					},
-				))
-
-				Expect(pod.Spec.Containers).To(ConsistOf(
-					And(
-						HaveField("Name", "generate-config"),
-						HaveField("Image", "unstoppablemango/pia-manual-connections:v0.2.0-pia2023-02-06r0"),
-						HaveField("Env", []corev1.EnvVar{
-							{Name: "PIA_USER", Value: piaUser},
-							{
-								Name: "PIA_PASS",
-								ValueFrom: &corev1.EnvVarSource{
-									ConfigMapKeyRef: resource.Spec.Password.ConfigMapKeyRef,
-								},
-							},
-							{Name: "PIA_PF", Value: "false"},
-							{Name: "PIA_CONNECT", Value: "false"},
-							{Name: "PIA_CONF_PATH", Value: "/out/pia0.conf"},
-							{Name: "VPN_PROTOCOL", Value: "wireguard"},
-							{Name: "DISABLE_IPV6", Value: "no"},
-							{Name: "DIP_TOKEN", Value: "no"},
-							{Name: "AUTOCONNECT", Value: "true"},
-						}),
-						HaveField("VolumeMounts", []corev1.VolumeMount{{
-							Name:      "results",
-							MountPath: "/out",
-						}}),
-					),
-					And(
-						HaveField("Name", "results"),
-						HaveField("Image", "busybox:latest"),
-						HaveField("Command", []string{"sh", "-c", "sleep infinity"}),
-						HaveField("VolumeMounts", []corev1.VolumeMount{{
-							Name:      "results",
-							ReadOnly:  true,
-							MountPath: "/out",
-						}}),
-					),
-				))
+					Expect(err).NotTo(HaveOccurred())
+
+					existing := &corev1.ConfigMap{}
+					err = k8sClient.Get(ctx, typeNamespacedName, existing)
+					if err == nil {
+						// envtest has no garbage collector, finalizers may keep it around
+						Expect(existing.DeletionTimestamp).NotTo(BeNil())
+					} else {
+						Expect(errors.IsNotFound(err)).To(BeTrue())
+					}
+				})
 			})
 		})
 
-		When("a matching generate pod exists", func() {
-			const genPodName = "generate-config-fjdsk"
-
-			BeforeEach(func() {
-				By("creating a generate pod")
-				pod := &corev1.Pod{
+		When("a config secret exists and is not controlled by the config", func() {
+			BeforeEach(func(ctx context.Context) {
+				By("Creating an unowned config secret")
+				secret := &corev1.Secret{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      genPodName,
+						Name:      typeNamespacedName.Name,
 						Namespace: typeNamespacedName.Namespace,
-						Labels: map[string]string{
-							"app.kubernetes.io/name":   "thecluster-operator",
-							"pia.thecluster.io/config": typeNamespacedName.Name,
-						},
 					},
-					Spec: corev1.PodSpec{
-						Containers: []corev1.Container{{
-							Name:  "stub",
-							Image: "busybox",
-						}},
+					StringData: map[string]string{
+						vpn.ConfigKey: "user config",
 					},
 				}
-				Expect(k8sClient.Create(ctx, pod)).To(Succeed())
-			})
+				Expect(k8sClient.Create(ctx, secret)).To(Succeed())
 
-			AfterEach(func() {
-				By("cleaning up the generate pod")
-				podName := types.NamespacedName{
-					Name:      genPodName,
-					Namespace: typeNamespacedName.Namespace,
-				}
-				pod := &corev1.Pod{}
-				if err := k8sClient.Get(ctx, podName, pod); err == nil {
-					Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+				wireguardconfig.Annotations = map[string]string{
+					RegenerateAnnotation: "1",
 				}
 			})
 
-			It("should not create any new pods", func() {
+			It("should report a conflict without adopting the secret", func() {
 				By("Reconciling the created resource")
-				controllerReconciler := &WireguardConfigReconciler{
-					Client: k8sClient,
-					Scheme: k8sClient.Scheme(),
+				result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(time.Minute))
+				Expect(fake.registered).To(BeZero(), "a new config was generated")
+
+				By("Checking the secret is untouched")
+				secret := &corev1.Secret{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+				Expect(secret.OwnerReferences).To(BeEmpty())
+				Expect(string(secret.Data[vpn.ConfigKey])).To(Equal("user config"))
+
+				By("Fetching the config resource")
+				resource := &piav1alpha1.WireguardConfig{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+				cond := meta.FindStatusCondition(
+					resource.Status.Conditions,
+					TypeAvailableWireguardConfig,
+				)
+				Expect(cond).NotTo(BeNil())
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal("SecretConflict"))
+			})
+		})
+
+		When("a matching config exists", func() {
+			JustBeforeEach(func(ctx context.Context) {
+				By("Creating a matching config secret controlled by the config")
+				secret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      typeNamespacedName.Name,
+						Namespace: typeNamespacedName.Namespace,
+					},
+					StringData: map[string]string{
+						vpn.ConfigKey: "existing config",
+					},
 				}
+				Expect(controllerutil.SetControllerReference(wireguardconfig, secret, k8sClient.Scheme())).To(Succeed())
+				Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+			})
 
+			It("Should be available", func() {
+				By("Reconciling the created resource")
 				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 					NamespacedName: typeNamespacedName,
 				})
 				Expect(err).NotTo(HaveOccurred())
+				Expect(fake.registered).To(BeZero(), "a new config was generated")
 
-				By("Listing the pods with matching labels")
-				podList := &corev1.PodList{}
-				err = k8sClient.List(ctx, podList, client.MatchingLabels{
-					"app.kubernetes.io/name":   "thecluster-operator",
-					"pia.thecluster.io/config": typeNamespacedName.Name,
-				})
+				By("Fetching the config resource")
+				resource := &piav1alpha1.WireguardConfig{}
+				err = k8sClient.Get(ctx, typeNamespacedName, resource)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(podList.Items).To(HaveLen(1), "too many pods created")
+
+				available := meta.IsStatusConditionTrue(
+					resource.Status.Conditions,
+					TypeAvailableWireguardConfig,
+				)
+				Expect(available).To(BeTrueBecause("The config is available"))
+			})
+
+			When("the regenerate annotation is set", func() {
+				const regenerate = "2025-07-01T00:00:00Z"
+
+				BeforeEach(func() {
+					wireguardconfig.Annotations = map[string]string{
+						RegenerateAnnotation: regenerate,
+					}
+				})
+
+				It("should replace the config", func() {
+					By("Reconciling the created resource")
+					_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+						NamespacedName: typeNamespacedName,
+					})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(fake.registered).To(Equal(1))
+
+					By("Checking that the existing config was replaced")
+					secret := &corev1.Secret{}
+					Expect(k8sClient.Get(ctx, typeNamespacedName, secret)).To(Succeed())
+					Expect(string(secret.Data[vpn.ConfigKey])).NotTo(Equal("existing config"))
+
+					By("Fetching the config resource")
+					resource := &piav1alpha1.WireguardConfig{}
+					err = k8sClient.Get(ctx, typeNamespacedName, resource)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(resource.Status.ObservedRegenerate).To(Equal(regenerate))
+				})
+
+				It("should only regenerate once", func() {
+					By("Reconciling the created resource twice")
+					for range 2 {
+						_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+							NamespacedName: typeNamespacedName,
+						})
+						Expect(err).NotTo(HaveOccurred())
+					}
+
+					Expect(fake.registered).To(Equal(1), "too many configs generated")
+				})
 			})
 		})
 
-		When("a matching config exists", func() {
+		When("the CA certificate config map exists", func() {
+			var (
+				caCert []byte
+				roots  *x509.CertPool
+			)
+
+			cmName := types.NamespacedName{
+				Name:      "pia-ca",
+				Namespace: typeNamespacedName.Namespace,
+			}
+
 			BeforeEach(func(ctx context.Context) {
-				By("Creating a matching config map")
-				cm := &corev1.ConfigMap{
+				caCert = newCACert()
+
+				By("Creating the CA config map")
+				Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      typeNamespacedName.Name,
-						Namespace: typeNamespacedName.Namespace,
+						Name:      cmName.Name,
+						Namespace: cmName.Namespace,
 					},
-					Data: map[string]string{},
+					Data: map[string]string{
+						"ca.crt": string(caCert),
+					},
+				})).To(Succeed())
+
+				wireguardconfig.Spec.CACert = &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: cmName.Name,
+					},
+					Key: "ca.crt",
+				}
+				controllerReconciler.NewProvider = func(pool *x509.CertPool) (provider.Provider, error) {
+					roots = pool
+					return fake, nil
 				}
-				Expect(k8sClient.Create(ctx, cm)).To(Succeed())
 			})
 
 			AfterEach(func(ctx context.Context) {
-				By("Cleaning up the config map")
+				By("Cleaning up the CA config map")
 				cm := &corev1.ConfigMap{}
-				if err := k8sClient.Get(ctx, typeNamespacedName, cm); err == nil {
+				if err := k8sClient.Get(ctx, cmName, cm); err == nil {
 					Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
 				}
 			})
 
-			It("Should be available", func() {
+			It("should trust the configured CA", func() {
 				By("Reconciling the created resource")
-				controllerReconciler := &WireguardConfigReconciler{
-					Client: k8sClient,
-					Scheme: k8sClient.Scheme(),
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fake.registered).To(Equal(1))
+
+				expected, err := piaclient.NewCertPool(caCert)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(roots).NotTo(BeNil())
+				Expect(roots.Equal(expected)).To(BeTrueBecause("The configured CA is used"))
+			})
+		})
+
+		When("the CA certificate config map does not exist", func() {
+			BeforeEach(func() {
+				wireguardconfig.Spec.CACert = &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: "does-not-exist",
+					},
+					Key: "ca.crt",
 				}
+			})
 
+			It("Should error", func() {
+				By("Reconciling the created resource")
 				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 					NamespacedName: typeNamespacedName,
 				})
@@ -780,11 +767,11 @@ var _ = Describe("WireguardConfig Controller", func() {
 				err = k8sClient.Get(ctx, typeNamespacedName, resource)
 				Expect(err).NotTo(HaveOccurred())
 
-				available := meta.IsStatusConditionTrue(
+				errored := meta.IsStatusConditionTrue(
 					resource.Status.Conditions,
-					TypeAvailableWireguardConfig,
+					TypeErrorWireguardConfig,
 				)
-				Expect(available).To(BeTrueBecause("The config is available"))
+				Expect(errored).To(BeTrueBecause("The CA certificate is invalid"))
 			})
 		})
 
@@ -795,15 +782,11 @@ var _ = Describe("WireguardConfig Controller", func() {
 
 			It("Should error", func() {
 				By("Reconciling the created resource")
-				controllerReconciler := &WireguardConfigReconciler{
-					Client: k8sClient,
-					Scheme: k8sClient.Scheme(),
-				}
-
 				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 					NamespacedName: typeNamespacedName,
 				})
 				Expect(err).NotTo(HaveOccurred())
+				Expect(fake.registered).To(BeZero())
 
 				By("Fetching the config resource")
 				resource := &piav1alpha1.WireguardConfig{}
@@ -825,15 +808,11 @@ var _ = Describe("WireguardConfig Controller", func() {
 
 			It("Should error", func() {
 				By("Reconciling the created resource")
-				controllerReconciler := &WireguardConfigReconciler{
-					Client: k8sClient,
-					Scheme: k8sClient.Scheme(),
-				}
-
 				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 					NamespacedName: typeNamespacedName,
 				})
 				Expect(err).NotTo(HaveOccurred())
+				Expect(fake.registered).To(BeZero())
 
 				By("Fetching the config resource")
 				resource := &piav1alpha1.WireguardConfig{}
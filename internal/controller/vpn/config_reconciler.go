@@ -0,0 +1,322 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/unmango/thecluster-operator/internal/provider"
+)
+
+var (
+	TypeAvailable = "Available"
+	TypeError     = "Error"
+
+	// ConfigKey is the key generated configs are written to in the config secret
+	ConfigKey = "wg0.conf"
+)
+
+// InvalidError indicates a spec that can't be used to generate a config.
+// Reconciling an invalid spec is reported in status rather than retried.
+type InvalidError struct {
+	Message string
+}
+
+func (e *InvalidError) Error() string {
+	return e.Message
+}
+
+// Invalid creates an [InvalidError] with a formatted message
+func Invalid(format string, args ...any) error {
+	return &InvalidError{Message: fmt.Sprintf(format, args...)}
+}
+
+// SecretConflictError indicates a secret that would be written exists and is
+// controlled by something else. Adopting it could overwrite keys or configs
+// that something else depends on, so it is reported instead.
+type SecretConflictError struct {
+	Secret string
+	Owner  string
+}
+
+func (e *SecretConflictError) Error() string {
+	return fmt.Sprintf("secret %s exists and is not controlled by %s", e.Secret, e.Owner)
+}
+
+// CheckSecretOwner returns a [SecretConflictError] when secret exists and is
+// not controlled by owner. Secrets that haven't been created yet pass.
+func CheckSecretOwner(owner, secret metav1.Object) error {
+	if secret.GetUID() == "" || metav1.IsControlledBy(secret, owner) {
+		return nil
+	}
+
+	return &SecretConflictError{Secret: secret.GetName(), Owner: owner.GetName()}
+}
+
+// Status points to the status fields shared by generated VPN config kinds
+type Status struct {
+	Conditions         *[]metav1.Condition
+	ObservedRegenerate *string
+	SecretName         *string
+	Server             *string
+
+	// The public key registered for the current config, optional
+	PublicKey *string
+}
+
+// GenerateFunc generates a new config for the resource being reconciled
+type GenerateFunc func(ctx context.Context) (*provider.Result, error)
+
+// RevokeFunc revokes a public key that was replaced by a newly generated config
+type RevokeFunc func(ctx context.Context, publicKey string) error
+
+// ConfigReconciler holds the reconcile logic shared by kinds that generate
+// a wg-quick config into an owned Secret with the same name as the resource.
+type ConfigReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// The annotation that triggers regeneration when its value changes
+	RegenerateAnnotation string
+
+	// Revoke revokes the key of a replaced config. Requires [Status.PublicKey].
+	Revoke RevokeFunc
+}
+
+// Reconcile generates a config for obj using generate when its config secret
+// is missing or the regenerate annotation has changed.
+func (r *ConfigReconciler) Reconcile(ctx context.Context, obj client.Object, status Status, generate GenerateFunc) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	key := client.ObjectKeyFromObject(obj)
+
+	if len(*status.Conditions) == 0 {
+		_ = meta.SetStatusCondition(status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailable,
+				Status:  metav1.ConditionUnknown,
+				Reason:  "Reconciling",
+				Message: "Starting reconciliation",
+			},
+		)
+		if err := r.Status().Update(ctx, obj); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+		if err := r.Get(ctx, key, obj); err != nil {
+			log.Error(err, "Failed to re-fetch resource")
+			return ctrl.Result{}, err
+		}
+	}
+
+	regenerate, ok := obj.GetAnnotations()[r.RegenerateAnnotation]
+	regenerating := ok && regenerate != *status.ObservedRegenerate
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, key, secret)
+	if err == nil {
+		// Adopting the secret would overwrite a config something else depends on
+		if err := CheckSecretOwner(obj, secret); err != nil {
+			return r.secretConflict(ctx, obj, status, err)
+		}
+	}
+	if err == nil && !regenerating {
+		*status.SecretName = secret.Name
+		_ = meta.SetStatusCondition(status.Conditions,
+			metav1.Condition{
+				Type:    TypeAvailable,
+				Status:  metav1.ConditionTrue,
+				Reason:  "Reconciling",
+				Message: "Config secret exists",
+			},
+		)
+		if err := r.Status().Update(ctx, obj); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		} else {
+			log.Info("Found existing config secret, nothing to do")
+			return ctrl.Result{}, nil
+		}
+	} else if client.IgnoreNotFound(err) != nil {
+		log.Error(err, "Failed to get config secret")
+		return ctrl.Result{}, err
+	}
+
+	if regenerating {
+		log.Info("Regenerating wireguard config", "regenerate", regenerate)
+	} else {
+		log.Info("Generating wireguard config")
+	}
+
+	res, err := generate(ctx)
+	if err != nil {
+		return r.generateFailed(ctx, obj, status, err)
+	}
+
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if err := CheckSecretOwner(obj, secret); err != nil {
+			return err
+		}
+		secret.Data = map[string][]byte{
+			ConfigKey: []byte(res.Config.String()),
+		}
+
+		return ctrl.SetControllerReference(obj, secret, r.Scheme)
+	})
+	var conflict *SecretConflictError
+	if errors.As(err, &conflict) {
+		// The secret was created since it was checked, the new key is never used
+		if r.Revoke != nil && res.PublicKey != "" {
+			if err := r.Revoke(ctx, res.PublicKey); err != nil {
+				log.Error(err, "Failed to revoke unused key", "publicKey", res.PublicKey)
+			}
+		}
+
+		return r.secretConflict(ctx, obj, status, err)
+	}
+	if err != nil {
+		log.Error(err, "Failed to write config secret")
+		return ctrl.Result{}, err
+	}
+
+	if ok {
+		*status.ObservedRegenerate = regenerate
+	}
+	previousKey := ""
+	if status.PublicKey != nil {
+		previousKey = *status.PublicKey
+		*status.PublicKey = res.PublicKey
+	}
+	*status.SecretName = secret.Name
+	*status.Server = res.Registration.Server.Name
+	_ = meta.SetStatusCondition(status.Conditions,
+		metav1.Condition{
+			Type:    TypeError,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Generated",
+			Message: "Config generated successfully",
+		},
+	)
+	_ = meta.SetStatusCondition(status.Conditions,
+		metav1.Condition{
+			Type:    TypeAvailable,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Reconciling",
+			Message: "Config generated successfully",
+		},
+	)
+	if err := r.Status().Update(ctx, obj); err != nil {
+		log.Error(err, "Failed to update status")
+		return ctrl.Result{}, err
+	}
+
+	// The new key is recorded first, so a failed revocation never causes another registration
+	if r.Revoke != nil && previousKey != "" && previousKey != res.PublicKey {
+		if err := r.Revoke(ctx, previousKey); err != nil {
+			log.Error(err, "Failed to revoke replaced key", "publicKey", previousKey)
+		} else {
+			log.Info("Revoked replaced key", "publicKey", previousKey)
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ConfigReconciler) secretConflict(ctx context.Context, obj client.Object, status Status, err error) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+	log.Info("Config secret exists and is not controlled by the resource")
+
+	_ = meta.SetStatusCondition(status.Conditions,
+		metav1.Condition{
+			Type:    TypeError,
+			Status:  metav1.ConditionTrue,
+			Reason:  "SecretConflict",
+			Message: err.Error(),
+		},
+	)
+	_ = meta.SetStatusCondition(status.Conditions,
+		metav1.Condition{
+			Type:    TypeAvailable,
+			Status:  metav1.ConditionFalse,
+			Reason:  "SecretConflict",
+			Message: err.Error(),
+		},
+	)
+	if err := r.Status().Update(ctx, obj); err != nil {
+		log.Error(err, "Failed to update status")
+		return ctrl.Result{}, err
+	}
+
+	// Unowned secrets aren't watched, check again in case it was removed
+	return ctrl.Result{RequeueAfter: time.Minute}, nil
+}
+
+func (r *ConfigReconciler) generateFailed(ctx context.Context, obj client.Object, status Status, err error) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var invalid *InvalidError
+	reason := "GenerateFailed"
+	if errors.As(err, &invalid) {
+		reason = "Invalid"
+	}
+
+	_ = meta.SetStatusCondition(status.Conditions,
+		metav1.Condition{
+			Type:    TypeError,
+			Status:  metav1.ConditionTrue,
+			Reason:  reason,
+			Message: err.Error(),
+		},
+	)
+	_ = meta.SetStatusCondition(status.Conditions,
+		metav1.Condition{
+			Type:    TypeAvailable,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: "Failed to generate config",
+		},
+	)
+	if err := r.Status().Update(ctx, obj); err != nil {
+		log.Error(err, "Failed to update status")
+		return ctrl.Result{}, err
+	}
+
+	if invalid != nil {
+		log.Info("Spec is invalid", "reason", invalid.Message)
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	log.Error(err, "Failed to generate wireguard config")
+	return ctrl.Result{}, err
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vpn
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ResolveValue returns value when it is set, otherwise the value of the referenced
+// config map or secret key in namespace. Missing references are reported as
+// [InvalidError]s.
+func ResolveValue(
+	ctx context.Context,
+	c client.Reader,
+	namespace string,
+	value string,
+	configMapKeyRef *corev1.ConfigMapKeySelector,
+	secretKeyRef *corev1.SecretKeySelector,
+) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+
+	if ref := secretKeyRef; ref != nil {
+		secret := &corev1.Secret{}
+		key := client.ObjectKey{Namespace: namespace, Name: ref.Name}
+		if err := c.Get(ctx, key, secret); errors.IsNotFound(err) {
+			return "", Invalid("secret %s not found", ref.Name)
+		} else if err != nil {
+			return "", err
+		}
+
+		if v, ok := secret.Data[ref.Key]; ok {
+			return string(v), nil
+		}
+
+		return "", Invalid("key %s not found in secret %s", ref.Key, ref.Name)
+	}
+
+	if ref := configMapKeyRef; ref != nil {
+		cm := &corev1.ConfigMap{}
+		key := client.ObjectKey{Namespace: namespace, Name: ref.Name}
+		if err := c.Get(ctx, key, cm); errors.IsNotFound(err) {
+			return "", Invalid("config map %s not found", ref.Name)
+		} else if err != nil {
+			return "", err
+		}
+
+		if v, ok := cm.Data[ref.Key]; ok {
+			return v, nil
+		}
+
+		return "", Invalid("key %s not found in config map %s", ref.Key, ref.Name)
+	}
+
+	return "", nil
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package generic registers WireGuard keys with self-hosted control planes
+// that implement a small HTTP contract.
+//
+// The client POSTs a JSON request with the peer's public key to the configured URL:
+//
+//	POST <url>
+//	Authorization: Bearer <token>    (when a token is configured)
+//	Content-Type: application/json
+//
+//	{"publicKey": "<base64 public key>"}
+//
+// A 200 or 201 response describes the peer configuration:
+//
+//	{
+//	  "server": "wg-1",                      // optional, a name for the server
+//	  "endpoint": "203.0.113.1:51820",       // required, host:port of the server
+//	  "serverPublicKey": "<base64 key>",     // required
+//	  "addresses": ["10.8.0.2/32"],          // required, peer addresses in CIDR notation
+//	  "allowedIPs": ["10.8.0.0/24"],         // optional, defaults to 0.0.0.0/0
+//	  "dns": ["10.8.0.1"]                    // optional
+//	}
+//
+// Any other status is an error, optionally described by {"error": "<message>"}.
+//
+// Every field of the response is parsed before it is used, the response is
+// written into a wg-quick file and must not be able to add directives to it.
+// The URL must use https unless AllowHTTP is set.
+package generic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+// RegisterRequest is the body POSTed to the control plane
+type RegisterRequest struct {
+	PublicKey string `json:"publicKey"`
+}
+
+// RegisterResponse is the peer configuration returned by the control plane
+type RegisterResponse struct {
+	Server          string   `json:"server,omitempty"`
+	Endpoint        string   `json:"endpoint"`
+	ServerPublicKey string   `json:"serverPublicKey"`
+	Addresses       []string `json:"addresses"`
+	AllowedIPs      []string `json:"allowedIPs,omitempty"`
+	DNS             []string `json:"dns,omitempty"`
+}
+
+// Client talks to a control plane implementing the generic contract
+type Client struct {
+	// The URL keys are registered with
+	URL string
+
+	// The HTTP client used for requests, defaults to a client with the system roots
+	HTTPClient *http.Client
+
+	// Timeout for a single request, defaults to 30 seconds
+	Timeout time.Duration
+
+	// Allow registering over plain http, the response is not authenticated
+	AllowHTTP bool
+}
+
+// Register registers publicKey with the control plane, authenticating
+// with token when it is not empty.
+func (c *Client) Register(ctx context.Context, token, publicKey string) (*RegisterResponse, error) {
+	if err := c.checkURL(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(RegisterRequest{PublicKey: publicKey})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("registering key: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	data, err = io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading register response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		body := struct {
+			Error string `json:"error"`
+		}{}
+		if json.Unmarshal(data, &body) == nil && body.Error != "" {
+			return nil, fmt.Errorf("registering key: %s: %s", res.Status, body.Error)
+		}
+
+		return nil, fmt.Errorf("registering key: unexpected status %s", res.Status)
+	}
+
+	body := &RegisterResponse{}
+	if err := json.Unmarshal(data, body); err != nil {
+		return nil, fmt.Errorf("decoding register response: %w", err)
+	}
+	if err := body.validate(); err != nil {
+		return nil, fmt.Errorf("invalid register response: %w", err)
+	}
+
+	return body, nil
+}
+
+func (r *RegisterResponse) validate() error {
+	if r.Endpoint == "" {
+		return errors.New("missing endpoint")
+	}
+	if err := validateEndpoint(r.Endpoint); err != nil {
+		return fmt.Errorf("endpoint: %w", err)
+	}
+	if r.ServerPublicKey == "" {
+		return errors.New("missing serverPublicKey")
+	}
+	if _, err := wireguard.ParseKey(r.ServerPublicKey); err != nil {
+		return fmt.Errorf("serverPublicKey: %w", err)
+	}
+	if strings.ContainsAny(r.Server, "\r\n") {
+		return errors.New("server: contains a line break")
+	}
+	if len(r.Addresses) == 0 {
+		return errors.New("missing addresses")
+	}
+	for _, a := range r.Addresses {
+		if _, err := netip.ParsePrefix(a); err != nil {
+			return fmt.Errorf("addresses: %w", err)
+		}
+	}
+	for _, a := range r.AllowedIPs {
+		if _, err := netip.ParsePrefix(a); err != nil {
+			return fmt.Errorf("allowedIPs: %w", err)
+		}
+	}
+	for _, a := range r.DNS {
+		if _, err := netip.ParseAddr(a); err != nil {
+			return fmt.Errorf("dns: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateEndpoint checks that endpoint is a host:port pair
+func validateEndpoint(endpoint string) error {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		return errors.New("missing host")
+	}
+	if strings.ContainsFunc(host, func(r rune) bool {
+		return r <= ' ' || r == 0x7f
+	}) {
+		return fmt.Errorf("invalid host %q", host)
+	}
+	if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+
+	return nil
+}
+
+// checkURL refuses to register over plain http unless it was allowed
+func (c *Client) checkURL() error {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if c.AllowHTTP {
+			return nil
+		}
+		return fmt.Errorf("refusing to register over http without AllowHTTP: %s", c.URL)
+	default:
+		return fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+}
+
+// host returns the host of the client URL, used to name the server
+func (c *Client) host() string {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return c.URL
+	}
+
+	return u.Hostname()
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+
+	return &http.Client{Timeout: c.timeout()}
+}
+
+func (c *Client) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+
+	return 30 * time.Second
+}
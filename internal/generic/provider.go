@@ -0,0 +1,63 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"context"
+
+	"github.com/unmango/thecluster-operator/internal/provider"
+)
+
+var _ provider.Provider = &Client{}
+
+// Authenticate implements [provider.Provider]. The password, if any, is
+// used as a bearer token.
+func (c *Client) Authenticate(_ context.Context, creds provider.Credentials) (string, error) {
+	return creds.Password, nil
+}
+
+// ListServers implements [provider.Provider]. The control plane picks the
+// server, so a single placeholder named after the URL host is returned.
+func (c *Client) ListServers(context.Context) ([]provider.Server, error) {
+	return []provider.Server{{Name: c.host()}}, nil
+}
+
+// RegisterKey implements [provider.Provider]
+func (c *Client) RegisterKey(ctx context.Context, token string, server provider.Server, publicKey string) (*provider.Registration, error) {
+	res, err := c.Register(ctx, token, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Server != "" {
+		server.Name = res.Server
+	}
+
+	return &provider.Registration{
+		Address:    res.Addresses,
+		DNS:        res.DNS,
+		Endpoint:   res.Endpoint,
+		PublicKey:  res.ServerPublicKey,
+		AllowedIPs: res.AllowedIPs,
+		Server:     server,
+	}, nil
+}
+
+// PortForward implements [provider.Provider]
+func (c *Client) PortForward(context.Context, string, *provider.Registration) (*provider.PortForward, error) {
+	return nil, provider.ErrPortForwardUnsupported
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/unmango/thecluster-operator/internal/generic"
+	"github.com/unmango/thecluster-operator/internal/provider"
+)
+
+var _ = Describe("Provider", func() {
+	const serverKey = "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="
+
+	var (
+		api      *httptest.Server
+		response any
+		received generic.RegisterRequest
+		client   *generic.Client
+	)
+
+	BeforeEach(func() {
+		response = generic.RegisterResponse{
+			Server:          "wg-1",
+			Endpoint:        "203.0.113.1:51820",
+			ServerPublicKey: serverKey,
+			Addresses:       []string{"10.8.0.2/32"},
+			AllowedIPs:      []string{"10.8.0.0/24"},
+			DNS:             []string{"10.8.0.1"},
+		}
+
+		api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer test-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"bad token"}`))
+				return
+			}
+
+			Expect(json.NewDecoder(r.Body).Decode(&received)).To(Succeed())
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(response)
+		}))
+
+		client = &generic.Client{URL: api.URL + "/register", AllowHTTP: true}
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	It("should list a single server named after the URL host", func(ctx context.Context) {
+		servers, err := client.ListServers(ctx)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(servers).To(ConsistOf(provider.Server{Name: "127.0.0.1"}))
+	})
+
+	It("should register a key", func(ctx context.Context) {
+		reg, err := client.RegisterKey(ctx, "test-token", provider.Server{Name: "127.0.0.1"}, "public-key")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(received.PublicKey).To(Equal("public-key"))
+		Expect(reg).To(Equal(&provider.Registration{
+			Address:    []string{"10.8.0.2/32"},
+			DNS:        []string{"10.8.0.1"},
+			Endpoint:   "203.0.113.1:51820",
+			PublicKey:  serverKey,
+			AllowedIPs: []string{"10.8.0.0/24"},
+			Server:     provider.Server{Name: "wg-1"},
+		}))
+	})
+
+	It("should report errors", func(ctx context.Context) {
+		_, err := client.RegisterKey(ctx, "wrong", provider.Server{}, "public-key")
+
+		Expect(err).To(MatchError(ContainSubstring("bad token")))
+	})
+
+	It("should reject incomplete responses", func(ctx context.Context) {
+		response = map[string]any{"endpoint": "203.0.113.1:51820"}
+
+		_, err := client.RegisterKey(ctx, "test-token", provider.Server{}, "public-key")
+
+		Expect(err).To(MatchError(ContainSubstring("serverPublicKey")))
+	})
+
+	It("should refuse http unless it is allowed", func(ctx context.Context) {
+		client.AllowHTTP = false
+
+		_, err := client.RegisterKey(ctx, "test-token", provider.Server{}, "public-key")
+
+		Expect(err).To(MatchError(ContainSubstring("refusing to register over http")))
+	})
+
+	DescribeTable("should reject malformed responses",
+		func(ctx context.Context, mutate func(*generic.RegisterResponse), message string) {
+			res := response.(generic.RegisterResponse)
+			mutate(&res)
+			response = res
+
+			_, err := client.RegisterKey(ctx, "test-token", provider.Server{}, "public-key")
+
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("endpoint without a port", func(r *generic.RegisterResponse) {
+			r.Endpoint = "203.0.113.1"
+		}, "endpoint"),
+		Entry("endpoint with a line break", func(r *generic.RegisterResponse) {
+			r.Endpoint = "203.0.113.1:51820\nPostUp = touch /tmp/pwned"
+		}, "endpoint"),
+		Entry("invalid server public key", func(r *generic.RegisterResponse) {
+			r.ServerPublicKey = "server-key"
+		}, "serverPublicKey"),
+		Entry("address that is not a prefix", func(r *generic.RegisterResponse) {
+			r.Addresses = []string{"10.8.0.2/32\nPostUp = touch /tmp/pwned"}
+		}, "addresses"),
+		Entry("allowed IP that is not a prefix", func(r *generic.RegisterResponse) {
+			r.AllowedIPs = []string{"10.8.0.0"}
+		}, "allowedIPs"),
+		Entry("DNS server that is not an address", func(r *generic.RegisterResponse) {
+			r.DNS = []string{"dns.example.com"}
+		}, "dns"),
+	)
+})
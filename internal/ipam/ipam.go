@@ -0,0 +1,64 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ipam allocates host addresses from a CIDR pool
+package ipam
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// ErrExhausted is returned when a pool has no free addresses left
+var ErrExhausted = errors.New("address pool exhausted")
+
+// Allocate returns the lowest free host address of pool. The network address,
+// the IPv4 broadcast address, and the used addresses are never allocated.
+func Allocate(pool netip.Prefix, used ...netip.Addr) (netip.Addr, error) {
+	pool = pool.Masked()
+	taken := make(map[netip.Addr]bool, len(used))
+	for _, addr := range used {
+		taken[addr] = true
+	}
+
+	for addr := pool.Addr().Next(); Usable(pool, addr); addr = addr.Next() {
+		if !taken[addr] {
+			return addr, nil
+		}
+	}
+
+	return netip.Addr{}, fmt.Errorf("%w: %s", ErrExhausted, pool)
+}
+
+// Usable reports whether addr is a host address of pool
+func Usable(pool netip.Prefix, addr netip.Addr) bool {
+	pool = pool.Masked()
+	if !addr.IsValid() || !pool.Contains(addr) || addr == pool.Addr() {
+		return false
+	}
+	if addr.Is4() && pool.Bits() < 31 && addr.Next().IsValid() && !pool.Contains(addr.Next()) {
+		// The broadcast address
+		return false
+	}
+
+	return true
+}
+
+// HostPrefix returns the single address prefix of addr, e.g. 10.0.0.2/32
+func HostPrefix(addr netip.Addr) netip.Prefix {
+	return netip.PrefixFrom(addr, addr.BitLen())
+}
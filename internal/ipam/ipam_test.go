@@ -0,0 +1,77 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam_test
+
+import (
+	"net/netip"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/unmango/thecluster-operator/internal/ipam"
+)
+
+var _ = Describe("Allocate", func() {
+	pool := netip.MustParsePrefix("10.13.13.0/24")
+
+	It("should allocate the first host address", func() {
+		Expect(ipam.Allocate(pool)).To(Equal(netip.MustParseAddr("10.13.13.1")))
+	})
+
+	It("should skip used addresses", func() {
+		addr, err := ipam.Allocate(pool,
+			netip.MustParseAddr("10.13.13.1"),
+			netip.MustParseAddr("10.13.13.2"),
+			netip.MustParseAddr("10.13.13.4"),
+		)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(addr).To(Equal(netip.MustParseAddr("10.13.13.3")))
+	})
+
+	It("should mask the pool", func() {
+		Expect(ipam.Allocate(netip.MustParsePrefix("10.13.13.7/24"))).To(Equal(netip.MustParseAddr("10.13.13.1")))
+	})
+
+	It("should allocate from IPv6 pools", func() {
+		Expect(ipam.Allocate(netip.MustParsePrefix("fd00:13::/64"), netip.MustParseAddr("fd00:13::1"))).To(
+			Equal(netip.MustParseAddr("fd00:13::2")),
+		)
+	})
+
+	It("should report exhausted pools", func() {
+		small := netip.MustParsePrefix("10.13.13.0/30")
+		_, err := ipam.Allocate(small,
+			netip.MustParseAddr("10.13.13.1"),
+			netip.MustParseAddr("10.13.13.2"),
+		)
+
+		Expect(err).To(MatchError(ipam.ErrExhausted))
+	})
+})
+
+var _ = DescribeTable("Usable",
+	func(pool, addr string, expected bool) {
+		Expect(ipam.Usable(netip.MustParsePrefix(pool), netip.MustParseAddr(addr))).To(Equal(expected))
+	},
+	Entry("a host address", "10.13.13.0/24", "10.13.13.7", true),
+	Entry("the network address", "10.13.13.0/24", "10.13.13.0", false),
+	Entry("the broadcast address", "10.13.13.0/24", "10.13.13.255", false),
+	Entry("an address outside the pool", "10.13.13.0/24", "10.13.14.1", false),
+	Entry("the last IPv6 address", "fd00:13::/120", "fd00:13::ff", true),
+	Entry("the last address of a /31", "10.13.13.0/31", "10.13.13.1", true),
+)
@@ -0,0 +1,283 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mullvad
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAPIURL is the base URL of the Mullvad API
+	DefaultAPIURL = "https://api.mullvad.net"
+
+	// DefaultPort is the port Mullvad WireGuard relays listen on
+	DefaultPort = 51820
+
+	// DefaultDNS is the in-tunnel DNS server of every Mullvad relay
+	DefaultDNS = "10.64.0.1"
+)
+
+// RelayList is the list of Mullvad WireGuard relays grouped by location
+type RelayList struct {
+	Countries []Country `json:"countries"`
+}
+
+// Country is a country in a [RelayList]
+type Country struct {
+	Name   string `json:"name"`
+	Code   string `json:"code"`
+	Cities []City `json:"cities"`
+}
+
+// City is a city in a [Country]
+type City struct {
+	Name   string  `json:"name"`
+	Code   string  `json:"code"`
+	Relays []Relay `json:"relays"`
+}
+
+// Relay is a Mullvad WireGuard server
+type Relay struct {
+	Hostname   string `json:"hostname"`
+	IPv4AddrIn string `json:"ipv4_addr_in"`
+	IPv6AddrIn string `json:"ipv6_addr_in"`
+	PublicKey  string `json:"public_key"`
+}
+
+// Client talks to the Mullvad API
+type Client struct {
+	// The base URL of the API, defaults to [DefaultAPIURL]
+	APIURL string
+
+	// The HTTP client used for requests, defaults to a client with the system roots
+	HTTPClient *http.Client
+
+	// Timeout for a single request, defaults to 30 seconds
+	Timeout time.Duration
+}
+
+// Relays lists the Mullvad WireGuard relays
+func (c *Client) Relays(ctx context.Context) (*RelayList, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.apiURL()+"/public/relays/wireguard/v1/",
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting relay list: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("requesting relay list: unexpected status %s", res.Status)
+	}
+
+	body := &RelayList{}
+	if err := json.NewDecoder(res.Body).Decode(body); err != nil {
+		return nil, fmt.Errorf("decoding relay list: %w", err)
+	}
+
+	return body, nil
+}
+
+// AddKey registers publicKey with account and returns the tunnel addresses
+// assigned to it in CIDR notation. The key is usable on every relay.
+func (c *Client) AddKey(ctx context.Context, account, publicKey string) ([]string, error) {
+	form := url.Values{}
+	form.Set("account", account)
+	form.Set("pubkey", publicKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.apiURL()+"/wg/",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("adding key: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading add key response: %w", err)
+	}
+
+	// Errors are reported as JSON, successful responses are a comma
+	// separated list of addresses
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		body := struct {
+			Code  string `json:"code"`
+			Error string `json:"error"`
+		}{}
+		if json.Unmarshal(data, &body) == nil && body.Error != "" {
+			return nil, fmt.Errorf("adding key: %s: %s", body.Code, body.Error)
+		}
+
+		return nil, fmt.Errorf("adding key: unexpected status %s", res.Status)
+	}
+
+	addresses := []string{}
+	for _, a := range strings.Split(strings.TrimSpace(string(data)), ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			addresses = append(addresses, a)
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, errors.New("adding key: no addresses assigned")
+	}
+
+	return addresses, nil
+}
+
+// Device is a key registered with a Mullvad account
+type Device struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	PubKey string `json:"pubkey"`
+}
+
+// RevokeKey removes the device registered with publicKey from account. Keys
+// that aren't registered are ignored, so revoking a key twice succeeds.
+func (c *Client) RevokeKey(ctx context.Context, account, publicKey string) error {
+	token, err := c.accessToken(ctx, account)
+	if err != nil {
+		return fmt.Errorf("revoking key: %w", err)
+	}
+
+	devices := []Device{}
+	if err := c.do(ctx, http.MethodGet, "/accounts/v1/devices", token, nil, &devices); err != nil {
+		return fmt.Errorf("listing devices: %w", err)
+	}
+
+	for _, d := range devices {
+		if d.PubKey != publicKey {
+			continue
+		}
+		if err := c.do(ctx, http.MethodDelete, "/accounts/v1/devices/"+url.PathEscape(d.ID), token, nil, nil); err != nil {
+			return fmt.Errorf("revoking key: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// accessToken exchanges account for a token accepted by the account API
+func (c *Client) accessToken(ctx context.Context, account string) (string, error) {
+	body := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	err := c.do(ctx, http.MethodPost, "/auth/v1/token", "",
+		map[string]string{"account_number": account},
+		&body,
+	)
+	if err != nil {
+		return "", fmt.Errorf("requesting access token: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", errors.New("requesting access token: no token returned")
+	}
+
+	return body.AccessToken, nil
+}
+
+// do sends a JSON request to the account API and decodes the response into out
+func (c *Client) do(ctx context.Context, method, path, token string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL()+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		e := struct {
+			Code   string `json:"code"`
+			Detail string `json:"detail"`
+		}{}
+		if json.NewDecoder(res.Body).Decode(&e) == nil && e.Detail != "" {
+			return fmt.Errorf("%s: %s", e.Code, e.Detail)
+		}
+
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+func (c *Client) apiURL() string {
+	if c.APIURL != "" {
+		return strings.TrimSuffix(c.APIURL, "/")
+	}
+
+	return DefaultAPIURL
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+
+	return &http.Client{Timeout: c.timeout()}
+}
+
+func (c *Client) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+
+	return 30 * time.Second
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mullvad
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/unmango/thecluster-operator/internal/provider"
+)
+
+var (
+	_ provider.Provider   = &Client{}
+	_ provider.KeyRevoker = &Client{}
+)
+
+// Authenticate implements [provider.Provider]. Mullvad authenticates each
+// request with the account number, which is returned as the token.
+func (c *Client) Authenticate(_ context.Context, creds provider.Credentials) (string, error) {
+	if creds.Username == "" {
+		return "", errors.New("missing account number")
+	}
+
+	return creds.Username, nil
+}
+
+// ListServers implements [provider.Provider]. Regions are "<country>-<city>"
+// location codes, e.g. "se-got".
+func (c *Client) ListServers(ctx context.Context) ([]provider.Server, error) {
+	relays, err := c.Relays(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	servers := []provider.Server{}
+	for _, country := range relays.Countries {
+		for _, city := range country.Cities {
+			for _, r := range city.Relays {
+				servers = append(servers, provider.Server{
+					Name:      r.Hostname,
+					Region:    country.Code + "-" + city.Code,
+					Country:   country.Code,
+					IP:        r.IPv4AddrIn,
+					Port:      DefaultPort,
+					PublicKey: r.PublicKey,
+				})
+			}
+		}
+	}
+
+	return servers, nil
+}
+
+// RegisterKey implements [provider.Provider]
+func (c *Client) RegisterKey(ctx context.Context, token string, server provider.Server, publicKey string) (*provider.Registration, error) {
+	addresses, err := c.AddKey(ctx, token, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	port := server.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+
+	return &provider.Registration{
+		Address:    addresses,
+		DNS:        []string{DefaultDNS},
+		Endpoint:   net.JoinHostPort(server.IP, strconv.Itoa(port)),
+		PublicKey:  server.PublicKey,
+		AllowedIPs: []string{"0.0.0.0/0", "::/0"},
+		Server:     server,
+	}, nil
+}
+
+// PortForward implements [provider.Provider]. Mullvad no longer offers port forwarding.
+func (c *Client) PortForward(context.Context, string, *provider.Registration) (*provider.PortForward, error) {
+	return nil, provider.ErrPortForwardUnsupported
+}
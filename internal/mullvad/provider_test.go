@@ -0,0 +1,185 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mullvad_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/unmango/thecluster-operator/internal/mullvad"
+	"github.com/unmango/thecluster-operator/internal/provider"
+)
+
+var _ = Describe("Provider", func() {
+	var (
+		api     *httptest.Server
+		client  *mullvad.Client
+		revoked []string
+	)
+
+	BeforeEach(func() {
+		revoked = nil
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /public/relays/wireguard/v1/", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"countries":[` +
+				`{"name":"Sweden","code":"se","cities":[{"name":"Gothenburg","code":"got","relays":[` +
+				`{"hostname":"se-got-wg-001","ipv4_addr_in":"192.0.2.1","public_key":"got-key"}]}]},` +
+				`{"name":"Canada","code":"ca","cities":[{"name":"Toronto","code":"tor","relays":[` +
+				`{"hostname":"ca-tor-wg-101","ipv4_addr_in":"192.0.2.2","public_key":"tor-key"}]}]}` +
+				`]}`))
+		})
+		mux.HandleFunc("POST /wg/", func(w http.ResponseWriter, r *http.Request) {
+			if r.FormValue("account") != "1234567890123456" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"code":"INVALID_ACCOUNT","error":"Invalid account"}`))
+				return
+			}
+
+			_, _ = w.Write([]byte("10.64.0.2/32,fc00:bbbb:bbbb:bb01::2/128\n"))
+		})
+		mux.HandleFunc("POST /auth/v1/token", func(w http.ResponseWriter, r *http.Request) {
+			body := map[string]string{}
+			if json.NewDecoder(r.Body).Decode(&body) != nil || body["account_number"] != "1234567890123456" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"code":"INVALID_ACCOUNT","detail":"Invalid account"}`))
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"access_token":"test-token"}`))
+		})
+		mux.HandleFunc("GET /accounts/v1/devices", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer test-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			_, _ = w.Write([]byte(`[` +
+				`{"id":"device-1","name":"happy otter","pubkey":"old-key"},` +
+				`{"id":"device-2","name":"brave fox","pubkey":"new-key"}` +
+				`]`))
+		})
+		mux.HandleFunc("DELETE /accounts/v1/devices/{id}", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer test-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			revoked = append(revoked, r.PathValue("id"))
+			w.WriteHeader(http.StatusNoContent)
+		})
+		api = httptest.NewServer(mux)
+
+		client = &mullvad.Client{APIURL: api.URL}
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	It("should use the account number as the token", func(ctx context.Context) {
+		token, err := client.Authenticate(ctx, provider.Credentials{
+			Username: "1234567890123456",
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).To(Equal("1234567890123456"))
+	})
+
+	It("should require an account number", func(ctx context.Context) {
+		_, err := client.Authenticate(ctx, provider.Credentials{})
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should list relays", func(ctx context.Context) {
+		servers, err := client.ListServers(ctx)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(servers).To(ConsistOf(
+			provider.Server{
+				Name:      "se-got-wg-001",
+				Region:    "se-got",
+				Country:   "se",
+				IP:        "192.0.2.1",
+				Port:      mullvad.DefaultPort,
+				PublicKey: "got-key",
+			},
+			provider.Server{
+				Name:      "ca-tor-wg-101",
+				Region:    "ca-tor",
+				Country:   "ca",
+				IP:        "192.0.2.2",
+				Port:      mullvad.DefaultPort,
+				PublicKey: "tor-key",
+			},
+		))
+	})
+
+	It("should register a key", func(ctx context.Context) {
+		s := provider.Server{
+			Name:      "se-got-wg-001",
+			IP:        "192.0.2.1",
+			PublicKey: "got-key",
+		}
+
+		reg, err := client.RegisterKey(ctx, "1234567890123456", s, "public-key")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reg.Address).To(Equal([]string{"10.64.0.2/32", "fc00:bbbb:bbbb:bb01::2/128"}))
+		Expect(reg.DNS).To(Equal([]string{mullvad.DefaultDNS}))
+		Expect(reg.Endpoint).To(Equal("192.0.2.1:51820"))
+		Expect(reg.PublicKey).To(Equal("got-key"))
+		Expect(reg.Server).To(Equal(s))
+	})
+
+	It("should report API errors", func(ctx context.Context) {
+		_, err := client.RegisterKey(ctx, "wrong", provider.Server{}, "public-key")
+
+		Expect(err).To(MatchError(ContainSubstring("Invalid account")))
+	})
+
+	It("should revoke a key", func(ctx context.Context) {
+		err := client.RevokeKey(ctx, "1234567890123456", "old-key")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revoked).To(ConsistOf("device-1"))
+	})
+
+	It("should ignore keys that aren't registered", func(ctx context.Context) {
+		err := client.RevokeKey(ctx, "1234567890123456", "unknown-key")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revoked).To(BeEmpty())
+	})
+
+	It("should report revocation errors", func(ctx context.Context) {
+		err := client.RevokeKey(ctx, "wrong", "old-key")
+
+		Expect(err).To(MatchError(ContainSubstring("Invalid account")))
+	})
+
+	It("should not support port forwarding", func(ctx context.Context) {
+		_, err := client.PortForward(ctx, "", &provider.Registration{})
+
+		Expect(err).To(MatchError(provider.ErrPortForwardUnsupported))
+	})
+})
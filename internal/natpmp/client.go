@@ -0,0 +1,232 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package natpmp implements the client side of NAT-PMP, as described in
+// RFC 6886, for requesting port mappings from a VPN gateway.
+package natpmp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"time"
+)
+
+// DefaultPort is the port NAT-PMP gateways listen on
+const DefaultPort = 5351
+
+const (
+	version = 0
+
+	opExternalAddress = 0
+	opResponse        = 128
+
+	// The initial retransmission interval, doubled after each attempt
+	initialTimeout = 250 * time.Millisecond
+
+	// The maximum number of attempts before giving up
+	maxAttempts = 9
+)
+
+// Protocol is the transport protocol of a mapping
+type Protocol uint8
+
+const (
+	UDP Protocol = 1
+	TCP Protocol = 2
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case UDP:
+		return "udp"
+	case TCP:
+		return "tcp"
+	default:
+		return "Protocol(" + strconv.Itoa(int(p)) + ")"
+	}
+}
+
+// ResultError is a non-zero result code returned by the gateway
+type ResultError uint16
+
+func (e ResultError) Error() string {
+	switch e {
+	case 1:
+		return "unsupported version"
+	case 2:
+		return "not authorized or refused"
+	case 3:
+		return "network failure"
+	case 4:
+		return "out of resources"
+	case 5:
+		return "unsupported opcode"
+	default:
+		return "result code " + strconv.Itoa(int(e))
+	}
+}
+
+// Mapping is a port mapping granted by the gateway
+type Mapping struct {
+	Protocol     Protocol
+	InternalPort uint16
+	ExternalPort uint16
+	Lifetime     time.Duration
+
+	// Seconds since the gateway's port mapping table was initialized
+	Epoch uint32
+}
+
+// Client sends NAT-PMP requests to a gateway
+type Client struct {
+	// The address of the gateway, with an optional port defaulting to [DefaultPort]
+	Gateway string
+}
+
+// ExternalAddress requests the public IPv4 address of the gateway
+func (c *Client) ExternalAddress(ctx context.Context) (netip.Addr, error) {
+	res, err := c.request(ctx, []byte{version, opExternalAddress}, 12)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("requesting external address: %w", err)
+	}
+
+	return netip.AddrFrom4([4]byte(res[8:12])), nil
+}
+
+// AddMapping requests a mapping of internalPort, suggesting externalPort.
+// The gateway may grant a different external port or lifetime. Mappings
+// must be renewed before their lifetime expires, and a lifetime of zero
+// deletes the mapping.
+func (c *Client) AddMapping(ctx context.Context, protocol Protocol, internalPort, externalPort uint16, lifetime time.Duration) (*Mapping, error) {
+	req := make([]byte, 12)
+	req[0] = version
+	req[1] = byte(protocol)
+	binary.BigEndian.PutUint16(req[4:], internalPort)
+	binary.BigEndian.PutUint16(req[6:], externalPort)
+	binary.BigEndian.PutUint32(req[8:], uint32(lifetime/time.Second))
+
+	res, err := c.request(ctx, req, 16)
+	if err != nil {
+		return nil, fmt.Errorf("mapping %s port: %w", protocol, err)
+	}
+
+	return &Mapping{
+		Protocol:     protocol,
+		Epoch:        binary.BigEndian.Uint32(res[4:]),
+		InternalPort: binary.BigEndian.Uint16(res[8:]),
+		ExternalPort: binary.BigEndian.Uint16(res[10:]),
+		Lifetime:     time.Duration(binary.BigEndian.Uint32(res[12:])) * time.Second,
+	}, nil
+}
+
+// ForwardPort requests or renews UDP and TCP mappings for the same port, the
+// way ProtonVPN gateways expect them, suggesting externalPort. It returns the
+// external port and the lifetime the gateway granted, the shorter of the two
+// mappings. A gateway granting different ports for UDP and TCP is an error.
+func (c *Client) ForwardPort(ctx context.Context, externalPort uint16, lifetime time.Duration) (uint16, time.Duration, error) {
+	// ProtonVPN ignores the internal port and maps to the same port inside the tunnel
+	udp, err := c.AddMapping(ctx, UDP, 1, externalPort, lifetime)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tcp, err := c.AddMapping(ctx, TCP, 1, udp.ExternalPort, lifetime)
+	if err != nil {
+		return 0, 0, err
+	}
+	if tcp.ExternalPort != udp.ExternalPort {
+		return 0, 0, fmt.Errorf("gateway mapped udp port %d and tcp port %d", udp.ExternalPort, tcp.ExternalPort)
+	}
+
+	granted := min(udp.Lifetime, tcp.Lifetime)
+	if granted <= 0 {
+		return 0, 0, errors.New("gateway granted a lifetime of zero")
+	}
+
+	return udp.ExternalPort, granted, nil
+}
+
+// request sends req until a matching response of size bytes is received,
+// doubling the timeout after each attempt as recommended by the RFC.
+func (c *Client) request(ctx context.Context, req []byte, size int) ([]byte, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", c.gateway())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	res := make([]byte, 16)
+	timeout := initialTimeout
+	for range maxAttempts {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+
+		deadline := time.Now().Add(timeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+
+		n, err := conn.Read(res)
+		if isTimeout(err) {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			timeout *= 2
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Ignore responses to other requests
+		if n < 4 || res[0] != version || res[1] != opResponse+req[1] {
+			continue
+		}
+		if code := binary.BigEndian.Uint16(res[2:]); code != 0 {
+			return nil, ResultError(code)
+		}
+		if n < size {
+			return nil, fmt.Errorf("short response: %d bytes", n)
+		}
+
+		return res[:size], nil
+	}
+
+	return nil, errors.New("gateway did not respond")
+}
+
+func (c *Client) gateway() string {
+	if _, _, err := net.SplitHostPort(c.Gateway); err == nil {
+		return c.Gateway
+	}
+
+	return net.JoinHostPort(c.Gateway, strconv.Itoa(DefaultPort))
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package natpmp_test
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/unmango/thecluster-operator/internal/natpmp"
+	"github.com/unmango/thecluster-operator/internal/natpmp/natpmptest"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		server *natpmptest.Server
+		client *natpmp.Client
+	)
+
+	BeforeEach(func() {
+		server = natpmptest.NewServer(41234)
+		client = &natpmp.Client{Gateway: server.Addr}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should request the external address", func(ctx context.Context) {
+		addr, err := client.ExternalAddress(ctx)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(addr).To(Equal(netip.MustParseAddr("192.0.2.1")))
+	})
+
+	It("should map a port", func(ctx context.Context) {
+		m, err := client.AddMapping(ctx, natpmp.TCP, 1, 0, time.Minute)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m).To(Equal(&natpmp.Mapping{
+			Protocol:     natpmp.TCP,
+			InternalPort: 1,
+			ExternalPort: 41234,
+			Lifetime:     time.Minute,
+		}))
+		Expect(server.Requests()).To(ConsistOf(natpmptest.Request{
+			Protocol:     uint8(natpmp.TCP),
+			InternalPort: 1,
+			Lifetime:     60,
+		}))
+	})
+
+	It("should forward the same port for UDP and TCP", func(ctx context.Context) {
+		port, lifetime, err := client.ForwardPort(ctx, 0, time.Minute)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal(uint16(41234)))
+		Expect(lifetime).To(Equal(time.Minute))
+		Expect(server.Requests()).To(HaveExactElements(
+			natpmptest.Request{Protocol: uint8(natpmp.UDP), InternalPort: 1, Lifetime: 60},
+			natpmptest.Request{Protocol: uint8(natpmp.TCP), InternalPort: 1, ExternalPort: 41234, Lifetime: 60},
+		))
+	})
+
+	It("should return the granted lifetime", func(ctx context.Context) {
+		server.SetMaxLifetime(30)
+
+		_, lifetime, err := client.ForwardPort(ctx, 0, time.Minute)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lifetime).To(Equal(30 * time.Second))
+	})
+
+	It("should fail when UDP and TCP are mapped to different ports", func(ctx context.Context) {
+		server.SetTCPExternalPort(41235)
+
+		_, _, err := client.ForwardPort(ctx, 0, time.Minute)
+
+		Expect(err).To(MatchError(ContainSubstring("udp port 41234 and tcp port 41235")))
+	})
+
+	It("should report result codes", func(ctx context.Context) {
+		server.SetResultCode(2)
+
+		_, err := client.AddMapping(ctx, natpmp.UDP, 1, 0, time.Minute)
+
+		Expect(err).To(MatchError(natpmp.ResultError(2)))
+	})
+
+	It("should give up when the context is done", func() {
+		server.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := client.AddMapping(ctx, natpmp.UDP, 1, 0, time.Minute)
+
+		Expect(err).To(HaveOccurred())
+	})
+})
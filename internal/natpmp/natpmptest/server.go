@@ -0,0 +1,162 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package natpmptest provides a NAT-PMP gateway for tests.
+package natpmptest
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+)
+
+// Server is a NAT-PMP gateway listening on a local UDP port. It maps every
+// request to ExternalPort, or the suggested port when ExternalPort is zero,
+// and grants the requested lifetime up to an optional maximum.
+type Server struct {
+	// The address the server is listening on
+	Addr string
+
+	// The external port granted to every mapping request
+	ExternalPort uint16
+
+	conn        net.PacketConn
+	mu          sync.Mutex
+	resultCode  uint16
+	maxLifetime uint32
+	tcpPort     uint16
+	requests    []Request
+}
+
+// Request is a mapping request received by a [Server]
+type Request struct {
+	Protocol     uint8
+	InternalPort uint16
+	ExternalPort uint16
+	Lifetime     uint32
+}
+
+// NewServer starts a server granting externalPort
+func NewServer(externalPort uint16) *Server {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Server{
+		Addr:         conn.LocalAddr().String(),
+		ExternalPort: externalPort,
+		conn:         conn,
+	}
+	go s.serve()
+
+	return s
+}
+
+// SetResultCode makes the server fail every request with code
+func (s *Server) SetResultCode(code uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resultCode = code
+}
+
+// SetMaxLifetime limits the lifetime granted to mappings to seconds
+func (s *Server) SetMaxLifetime(seconds uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxLifetime = seconds
+}
+
+// SetTCPExternalPort makes the server grant port to TCP mappings instead of ExternalPort
+func (s *Server) SetTCPExternalPort(port uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tcpPort = port
+}
+
+// Requests returns the mapping requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request{}, s.requests...)
+}
+
+// Close stops the server
+func (s *Server) Close() {
+	_ = s.conn.Close()
+}
+
+func (s *Server) serve() {
+	buf := make([]byte, 12)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if n < 2 {
+			continue
+		}
+
+		s.mu.Lock()
+		code, maxLifetime, tcpPort := s.resultCode, s.maxLifetime, s.tcpPort
+		s.mu.Unlock()
+
+		var res []byte
+		switch op := buf[1]; {
+		case op == 0:
+			res = make([]byte, 12)
+			copy(res[8:], []byte{192, 0, 2, 1})
+		case (op == 1 || op == 2) && n >= 12:
+			req := Request{
+				Protocol:     op,
+				InternalPort: binary.BigEndian.Uint16(buf[4:]),
+				ExternalPort: binary.BigEndian.Uint16(buf[6:]),
+				Lifetime:     binary.BigEndian.Uint32(buf[8:]),
+			}
+			s.mu.Lock()
+			s.requests = append(s.requests, req)
+			s.mu.Unlock()
+
+			external := s.ExternalPort
+			if external == 0 {
+				external = req.ExternalPort
+			}
+			if op == 2 && tcpPort != 0 {
+				external = tcpPort
+			}
+			lifetime := req.Lifetime
+			if maxLifetime != 0 {
+				lifetime = min(lifetime, maxLifetime)
+			}
+
+			res = make([]byte, 16)
+			binary.BigEndian.PutUint16(res[8:], req.InternalPort)
+			binary.BigEndian.PutUint16(res[10:], external)
+			binary.BigEndian.PutUint32(res[12:], lifetime)
+		default:
+			res = make([]byte, 8)
+			code = 5
+		}
+
+		res[1] = 128 + buf[1]
+		binary.BigEndian.PutUint16(res[2:], code)
+		_, _ = s.conn.WriteTo(res, addr)
+	}
+}
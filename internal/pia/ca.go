@@ -0,0 +1,55 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pia
+
+import (
+	"crypto/x509"
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+// bundledCAs holds the certificate authorities PIA signs its WireGuard servers with,
+// see ca/README.md
+//
+//go:embed ca
+var bundledCAs embed.FS
+
+// DefaultRootCAs returns a pool of the bundled PIA certificate authorities, or nil
+// when the operator was built without them
+func DefaultRootCAs() (*x509.CertPool, error) {
+	files, err := fs.Glob(bundledCAs, "ca/*.crt")
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	pool := x509.NewCertPool()
+	for _, name := range files {
+		data, err := bundledCAs.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no valid PEM certificates found in %s", name)
+		}
+	}
+
+	return pool, nil
+}
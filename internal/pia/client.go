@@ -0,0 +1,443 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pia
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAPIPort is the port the per-server WireGuard API listens on
+	DefaultAPIPort = 1337
+
+	// DefaultPortForwardPort is the port the in-tunnel port forwarding API listens on
+	DefaultPortForwardPort = 19999
+
+	// DefaultTokenURL is the endpoint used to exchange credentials for a token
+	DefaultTokenURL = "https://www.privateinternetaccess.com/api/client/v2/token"
+
+	// DefaultServerListURL is the endpoint listing PIA regions and servers
+	DefaultServerListURL = "https://serverlist.piaservers.net/vpninfo/servers/v6"
+)
+
+// Server identifies a PIA WireGuard server
+type Server struct {
+	// The IP address of the server
+	IP string
+
+	// The common name of the server certificate, e.g. "chicago403"
+	CommonName string
+}
+
+// Region is a PIA region from the server list
+type Region struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Country     string `json:"country"`
+	PortForward bool   `json:"port_forward"`
+	Offline     bool   `json:"offline"`
+	Servers     struct {
+		WG []RegionServer `json:"wg"`
+	} `json:"servers"`
+}
+
+// RegionServer is a server in a [Region]
+type RegionServer struct {
+	IP string `json:"ip"`
+	CN string `json:"cn"`
+}
+
+type apiStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Signature is a signed port forwarding payload
+type Signature struct {
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+
+	// The decoded payload
+	Decoded struct {
+		Port      int       `json:"port"`
+		ExpiresAt time.Time `json:"expires_at"`
+	} `json:"-"`
+}
+
+// AddKeyResponse is the result of registering a public key with a server
+type AddKeyResponse struct {
+	Status     string   `json:"status"`
+	Message    string   `json:"message,omitempty"`
+	ServerKey  string   `json:"server_key"`
+	ServerPort int      `json:"server_port"`
+	ServerIP   string   `json:"server_ip"`
+	ServerVIP  string   `json:"server_vip"`
+	PeerIP     string   `json:"peer_ip"`
+	PeerPubkey string   `json:"peer_pubkey"`
+	DNSServers []string `json:"dns_servers"`
+}
+
+// Client talks to the PIA WireGuard API. Requests to WireGuard servers are
+// verified against a pinned CA rather than the system roots.
+type Client struct {
+	// The PIA certificate authority used to verify WireGuard servers
+	RootCAs *x509.CertPool
+
+	// The port the per-server WireGuard API listens on, defaults to [DefaultAPIPort]
+	APIPort int
+
+	// The port the in-tunnel port forwarding API listens on, defaults to [DefaultPortForwardPort]
+	PortForwardPort int
+
+	// The token endpoint, defaults to [DefaultTokenURL]
+	TokenURL string
+
+	// The server list endpoint, defaults to [DefaultServerListURL]
+	ServerListURL string
+
+	// The HTTP client used for the publicly trusted token and server list
+	// endpoints, defaults to a client with the system roots
+	HTTPClient *http.Client
+
+	// Timeout for a single request, defaults to 30 seconds
+	Timeout time.Duration
+}
+
+// NewClient creates a client that pins the PEM encoded CA certificate(s) in caCert
+func NewClient(caCert []byte) (*Client, error) {
+	roots, err := NewCertPool(caCert)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{RootCAs: roots}, nil
+}
+
+// NewCertPool parses the PEM encoded certificates in caCert into a pool
+func NewCertPool(caCert []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("no valid PEM certificates found")
+	}
+
+	return pool, nil
+}
+
+// Token exchanges username and password for an auth token
+func (c *Client) Token(ctx context.Context, username, password string) (string, error) {
+	form := url.Values{}
+	form.Set("username", username)
+	form.Set("password", password)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		valueOr(c.TokenURL, DefaultTokenURL),
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting token: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("requesting token: unexpected status %s", res.Status)
+	}
+
+	body := struct {
+		Token string `json:"token"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding token response: %w", err)
+	}
+	if body.Token == "" {
+		return "", errors.New("requesting token: empty token")
+	}
+
+	return body.Token, nil
+}
+
+// Regions lists the PIA regions and their WireGuard servers
+func (c *Client) Regions(ctx context.Context) ([]Region, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		valueOr(c.ServerListURL, DefaultServerListURL),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting server list: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("requesting server list: unexpected status %s", res.Status)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading server list: %w", err)
+	}
+
+	// The list is a line of JSON followed by its signature
+	data, _, _ = bytes.Cut(data, []byte("\n"))
+
+	body := struct {
+		Regions []Region `json:"regions"`
+	}{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("decoding server list: %w", err)
+	}
+
+	return body.Regions, nil
+}
+
+// AddKey registers publicKey with server using the auth token and returns
+// the peer configuration assigned by the server.
+func (c *Client) AddKey(ctx context.Context, server Server, token, publicKey string) (*AddKeyResponse, error) {
+	q := url.Values{}
+	q.Set("pt", token)
+	q.Set("pubkey", publicKey)
+
+	u := url.URL{
+		Scheme:   "https",
+		Host:     net.JoinHostPort(server.IP, strconv.Itoa(c.apiPort())),
+		Path:     "/addKey",
+		RawQuery: q.Encode(),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.serverClient(server).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("adding key to %s: %w", server.CommonName, err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("adding key to %s: unexpected status %s", server.CommonName, res.Status)
+	}
+
+	body := &AddKeyResponse{}
+	if err := json.NewDecoder(res.Body).Decode(body); err != nil {
+		return nil, fmt.Errorf("decoding add key response: %w", err)
+	}
+	if body.Status != "OK" {
+		return nil, fmt.Errorf("adding key to %s: %s %s", server.CommonName, body.Status, body.Message)
+	}
+
+	return body, nil
+}
+
+// GetSignature requests a signed port forwarding payload from the gateway of
+// server. The gateway is only reachable from inside the tunnel.
+func (c *Client) GetSignature(ctx context.Context, server Server, gateway, token string) (*Signature, error) {
+	q := url.Values{}
+	q.Set("token", token)
+
+	body := &Signature{}
+	if err := c.portForwardRequest(ctx, server, gateway, "/getSignature", q, body); err != nil {
+		return nil, fmt.Errorf("getting signature: %w", err)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(body.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decoding payload: %w", err)
+	}
+	if err := json.Unmarshal(payload, &body.Decoded); err != nil {
+		return nil, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	return body, nil
+}
+
+// BindPort binds or refreshes the port in sig. PIA releases the port
+// if it is not refreshed every few minutes.
+func (c *Client) BindPort(ctx context.Context, server Server, gateway string, sig *Signature) error {
+	q := url.Values{}
+	q.Set("payload", sig.Payload)
+	q.Set("signature", sig.Signature)
+
+	if err := c.portForwardRequest(ctx, server, gateway, "/bindPort", q, nil); err != nil {
+		return fmt.Errorf("binding port: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) portForwardRequest(ctx context.Context, server Server, gateway, path string, q url.Values, out any) error {
+	u := url.URL{
+		Scheme:   "https",
+		Host:     net.JoinHostPort(gateway, strconv.Itoa(c.portForwardPort())),
+		Path:     path,
+		RawQuery: q.Encode(),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.serverClient(server).Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	status := apiStatus{}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return err
+	}
+	if status.Status != "OK" {
+		return fmt.Errorf("%s %s", status.Status, status.Message)
+	}
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+// serverClient creates an HTTP client for talking to server directly by IP
+// while still verifying its certificate against the pinned CA and its CN.
+func (c *Client) serverClient(server Server) *http.Client {
+	return &http.Client{
+		Timeout: c.timeout(),
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				ServerName: server.CommonName,
+				MinVersion: tls.VersionTLS12,
+				// PIA server certificates carry the hostname in the CN only, which
+				// the standard verifier ignores. VerifyConnection below performs the
+				// full chain and hostname verification instead.
+				InsecureSkipVerify: true,
+				VerifyConnection:   VerifyConnection(c.RootCAs, server.CommonName),
+			},
+		},
+	}
+}
+
+func (c *Client) apiPort() int {
+	if c.APIPort > 0 {
+		return c.APIPort
+	}
+
+	return DefaultAPIPort
+}
+
+func (c *Client) portForwardPort() int {
+	if c.PortForwardPort > 0 {
+		return c.PortForwardPort
+	}
+
+	return DefaultPortForwardPort
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+
+	return &http.Client{Timeout: c.timeout()}
+}
+
+func (c *Client) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+
+	return 30 * time.Second
+}
+
+// VerifyConnection verifies the peer certificate chains to roots and
+// that the leaf certificate was issued for commonName.
+func VerifyConnection(roots *x509.CertPool, commonName string) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if roots == nil {
+			return errors.New("no CA certificate configured")
+		}
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("server presented no certificates")
+		}
+
+		leaf := cs.PeerCertificates[0]
+		intermediates := x509.NewCertPool()
+		for _, cert := range cs.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		_, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+		if err != nil {
+			return err
+		}
+
+		if leaf.Subject.CommonName == commonName {
+			return nil
+		}
+		if err := leaf.VerifyHostname(commonName); err != nil {
+			return fmt.Errorf("certificate common name %q does not match %q",
+				leaf.Subject.CommonName, commonName,
+			)
+		}
+
+		return nil
+	}
+}
+
+func valueOr(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+
+	return fallback
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pia_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/unmango/thecluster-operator/internal/pia"
+)
+
+var _ = Describe("Client", func() {
+	const (
+		serverCN  = "chicago403"
+		token     = "test-token"
+		publicKey = "test-public-key"
+	)
+
+	var (
+		caPEM  []byte
+		server *httptest.Server
+		query  chan map[string]string
+	)
+
+	BeforeEach(func() {
+		var ca *x509.Certificate
+		var caKey *ecdsa.PrivateKey
+		ca, caKey, caPEM = newCA("Test PIA CA")
+
+		query = make(chan map[string]string, 1)
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.URL.Path).To(Equal("/addKey"))
+			query <- map[string]string{
+				"pt":     r.URL.Query().Get("pt"),
+				"pubkey": r.URL.Query().Get("pubkey"),
+			}
+
+			Expect(json.NewEncoder(w).Encode(pia.AddKeyResponse{
+				Status:     "OK",
+				ServerKey:  "server-key",
+				ServerPort: 1337,
+				ServerIP:   "10.0.0.1",
+				ServerVIP:  "10.0.0.2",
+				PeerIP:     "10.0.0.3",
+				PeerPubkey: publicKey,
+				DNSServers: []string{"10.0.0.243"},
+			})).To(Succeed())
+		}))
+		server.TLS = &tls.Config{
+			Certificates: []tls.Certificate{newServerCert(ca, caKey, serverCN)},
+		}
+		server.StartTLS()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newClient := func(ca []byte) *pia.Client {
+		client, err := pia.NewClient(ca)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+		client.APIPort, err = strconv.Atoi(port)
+		Expect(err).NotTo(HaveOccurred())
+
+		return client
+	}
+
+	It("should register the key with a server signed by the pinned CA", func(ctx context.Context) {
+		client := newClient(caPEM)
+
+		res, err := client.AddKey(ctx, pia.Server{IP: "127.0.0.1", CommonName: serverCN}, token, publicKey)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.ServerKey).To(Equal("server-key"))
+		Expect(res.PeerIP).To(Equal("10.0.0.3"))
+		Expect(res.DNSServers).To(ConsistOf("10.0.0.243"))
+		Expect(query).To(Receive(Equal(map[string]string{
+			"pt":     token,
+			"pubkey": publicKey,
+		})))
+	})
+
+	It("should reject a server with a different common name", func(ctx context.Context) {
+		client := newClient(caPEM)
+
+		_, err := client.AddKey(ctx, pia.Server{IP: "127.0.0.1", CommonName: "newyork401"}, token, publicKey)
+
+		Expect(err).To(MatchError(ContainSubstring("does not match")))
+		Expect(query).NotTo(Receive())
+	})
+
+	It("should reject a server signed by a different CA", func(ctx context.Context) {
+		_, _, otherPEM := newCA("Other CA")
+		client := newClient(otherPEM)
+
+		_, err := client.AddKey(ctx, pia.Server{IP: "127.0.0.1", CommonName: serverCN}, token, publicKey)
+
+		Expect(err).To(MatchError(ContainSubstring("certificate signed by unknown authority")))
+		Expect(query).NotTo(Receive())
+	})
+
+	It("should reject an invalid CA certificate", func() {
+		_, err := pia.NewClient([]byte("not a certificate"))
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should parse the bundled CA certificates", func() {
+		_, err := pia.DefaultRootCAs()
+
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+func newCA(cn string) (*x509.Certificate, *ecdsa.PrivateKey, []byte) {
+	GinkgoHelper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// newServerCert issues a certificate with cn and no SANs, like PIA's server certificates
+func newServerCert(ca *x509.Certificate, caKey *ecdsa.PrivateKey, cn string) tls.Certificate {
+	GinkgoHelper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	Expect(err).NotTo(HaveOccurred())
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pia
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"github.com/unmango/thecluster-operator/internal/provider"
+)
+
+var _ provider.Provider = &Client{}
+
+// Authenticate implements [provider.Provider]
+func (c *Client) Authenticate(ctx context.Context, creds provider.Credentials) (string, error) {
+	return c.Token(ctx, creds.Username, creds.Password)
+}
+
+// ListServers implements [provider.Provider]. Servers in offline regions are omitted.
+func (c *Client) ListServers(ctx context.Context) ([]provider.Server, error) {
+	regions, err := c.Regions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	servers := []provider.Server{}
+	for _, r := range regions {
+		if r.Offline {
+			continue
+		}
+
+		for _, s := range r.Servers.WG {
+			servers = append(servers, provider.Server{
+				Name:        s.CN,
+				Region:      r.ID,
+				Country:     r.Country,
+				IP:          s.IP,
+				PortForward: r.PortForward,
+			})
+		}
+	}
+
+	return servers, nil
+}
+
+// RegisterKey implements [provider.Provider]
+func (c *Client) RegisterKey(ctx context.Context, token string, server provider.Server, publicKey string) (*provider.Registration, error) {
+	res, err := c.AddKey(ctx, Server{IP: server.IP, CommonName: server.Name}, token, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider.Registration{
+		Address:   []string{res.PeerIP},
+		DNS:       res.DNSServers,
+		Endpoint:  net.JoinHostPort(res.ServerIP, strconv.Itoa(res.ServerPort)),
+		PublicKey: res.ServerKey,
+		Gateway:   res.ServerVIP,
+		Server:    server,
+	}, nil
+}
+
+// PortForward implements [provider.Provider]. It must be called from inside
+// the tunnel, and the port must be refreshed with [Client.BindPort].
+func (c *Client) PortForward(ctx context.Context, token string, reg *provider.Registration) (*provider.PortForward, error) {
+	server := Server{IP: reg.Server.IP, CommonName: reg.Server.Name}
+	sig, err := c.GetSignature(ctx, server, reg.Gateway, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.BindPort(ctx, server, reg.Gateway, sig); err != nil {
+		return nil, err
+	}
+
+	return &provider.PortForward{
+		Port:      sig.Decoded.Port,
+		ExpiresAt: sig.Decoded.ExpiresAt,
+	}, nil
+}
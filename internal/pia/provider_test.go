@@ -0,0 +1,153 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pia_test
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/unmango/thecluster-operator/internal/pia"
+	"github.com/unmango/thecluster-operator/internal/provider"
+)
+
+var _ = Describe("Provider", func() {
+	var (
+		api    *httptest.Server
+		client *pia.Client
+	)
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+			if r.FormValue("username") != "user" || r.FormValue("password") != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"token":"test-token"}`))
+		})
+		mux.HandleFunc("GET /servers", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"regions":[` +
+				`{"id":"us_chicago","name":"US Chicago","country":"US","port_forward":false,"offline":false,` +
+				`"servers":{"wg":[{"ip":"192.0.2.1","cn":"chicago403"}]}},` +
+				`{"id":"ca_toronto","name":"CA Toronto","country":"CA","port_forward":true,"offline":true,` +
+				`"servers":{"wg":[{"ip":"192.0.2.2","cn":"toronto401"}]}}` +
+				"]}\n\nc2lnbmF0dXJl"))
+		})
+		api = httptest.NewServer(mux)
+
+		client = &pia.Client{
+			TokenURL:      api.URL + "/token",
+			ServerListURL: api.URL + "/servers",
+		}
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	It("should exchange credentials for a token", func(ctx context.Context) {
+		token, err := client.Authenticate(ctx, provider.Credentials{
+			Username: "user",
+			Password: "pass",
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).To(Equal("test-token"))
+	})
+
+	It("should reject invalid credentials", func(ctx context.Context) {
+		_, err := client.Authenticate(ctx, provider.Credentials{
+			Username: "user",
+			Password: "wrong",
+		})
+
+		Expect(err).To(MatchError(ContainSubstring("401")))
+	})
+
+	It("should list servers in online regions", func(ctx context.Context) {
+		servers, err := client.ListServers(ctx)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(servers).To(ConsistOf(provider.Server{
+			Name:    "chicago403",
+			Region:  "us_chicago",
+			Country: "US",
+			IP:      "192.0.2.1",
+		}))
+	})
+
+	When("registering a key", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			ca, caKey, caPEM := newCA("Test PIA CA")
+			server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(pia.AddKeyResponse{
+					Status:     "OK",
+					ServerKey:  "server-key",
+					ServerPort: 1337,
+					ServerIP:   "192.0.2.1",
+					ServerVIP:  "10.0.0.1",
+					PeerIP:     "10.0.0.3",
+					DNSServers: []string{"10.0.0.243"},
+				})
+			}))
+			server.TLS = &tls.Config{
+				Certificates: []tls.Certificate{newServerCert(ca, caKey, "chicago403")},
+			}
+			server.StartTLS()
+
+			var err error
+			client.RootCAs, err = pia.NewCertPool(caPEM)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+			client.APIPort, err = strconv.Atoi(port)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should return the peer configuration", func(ctx context.Context) {
+			s := provider.Server{Name: "chicago403", IP: "127.0.0.1"}
+
+			reg, err := client.RegisterKey(ctx, "test-token", s, "public-key")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reg).To(Equal(&provider.Registration{
+				Address:   []string{"10.0.0.3"},
+				DNS:       []string{"10.0.0.243"},
+				Endpoint:  "192.0.2.1:1337",
+				PublicKey: "server-key",
+				Gateway:   "10.0.0.1",
+				Server:    s,
+			}))
+		})
+	})
+})
@@ -0,0 +1,138 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package portforward keeps the port of a ProtonVPNConfig forwarded. It runs in
+// a sidecar of the tunnel pod, the only place the in-tunnel NAT-PMP gateway can be
+// reached from, and reports the forwarded port in the status of the config.
+package portforward
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/natpmp"
+)
+
+const (
+	// DefaultGateway is the in-tunnel address of ProtonVPN's NAT-PMP gateway
+	DefaultGateway = "10.2.0.1"
+
+	// DefaultLifetime is the lifetime requested for mappings when the config doesn't set one
+	DefaultLifetime = 60 * time.Second
+
+	// DefaultRetryInterval is how long the forwarder waits before retrying a failed renewal
+	DefaultRetryInterval = 10 * time.Second
+
+	// How long to wait for the gateway to respond to a mapping request
+	natpmpTimeout = 10 * time.Second
+)
+
+// Forwarder requests the port of a ProtonVPNConfig and renews it before it
+// expires. The previously forwarded port is requested again, so it stays stable
+// across renewals and restarts of the sidecar.
+type Forwarder struct {
+	// Client reads the config and writes its status
+	Client client.Client
+
+	// The ProtonVPNConfig to forward the port of
+	Key client.ObjectKey
+
+	// The port NAT-PMP gateways listen on, defaults to [natpmp.DefaultPort]
+	NATPMPPort int
+
+	// How long to wait before retrying a failed renewal, defaults to [DefaultRetryInterval]
+	RetryInterval time.Duration
+}
+
+// Start renews the forwarded port until ctx is cancelled. Failures are logged
+// and retried, so that a gateway hiccup doesn't restart the tunnel pod.
+func (f *Forwarder) Start(ctx context.Context) error {
+	log := log.FromContext(ctx).WithValues("protonvpnconfig", f.Key)
+
+	retry := f.RetryInterval
+	if retry == 0 {
+		retry = DefaultRetryInterval
+	}
+
+	for {
+		wait, err := f.Renew(ctx)
+		if err != nil {
+			log.Error(err, "Failed to forward port, retrying", "after", retry)
+			wait = retry
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Renew requests or renews the forwarded port and records it in the status of
+// the config. It returns how long to wait before renewing again.
+func (f *Forwarder) Renew(ctx context.Context) (time.Duration, error) {
+	pc := &corev1alpha1.ProtonVPNConfig{}
+	if err := f.Client.Get(ctx, f.Key, pc); err != nil {
+		return 0, fmt.Errorf("getting ProtonVPN config: %w", err)
+	}
+	if pc.Spec.PortForwarding == nil {
+		return 0, fmt.Errorf("port forwarding is not enabled for %s", f.Key)
+	}
+
+	gateway := pc.Spec.PortForwarding.Gateway
+	if gateway == "" {
+		gateway = DefaultGateway
+	}
+
+	port := natpmp.DefaultPort
+	if f.NATPMPPort > 0 {
+		port = f.NATPMPPort
+	}
+
+	lifetime := DefaultLifetime
+	if s := pc.Spec.PortForwarding.LifetimeSeconds; s > 0 {
+		lifetime = time.Duration(s) * time.Second
+	}
+
+	mapCtx, cancel := context.WithTimeout(ctx, natpmpTimeout)
+	defer cancel()
+
+	c := &natpmp.Client{Gateway: net.JoinHostPort(gateway, strconv.Itoa(port))}
+	// The gateway may grant a shorter lifetime than requested
+	forwarded, granted, err := c.ForwardPort(mapCtx, uint16(pc.Status.ForwardedPort), lifetime)
+	if err != nil {
+		return 0, err
+	}
+
+	patch := client.MergeFrom(pc.DeepCopy())
+	pc.Status.ForwardedPort = int32(forwarded)
+	pc.Status.PortExpiresAt = &metav1.Time{Time: time.Now().Add(granted)}
+	if err := f.Client.Status().Patch(ctx, pc, patch); err != nil {
+		return 0, fmt.Errorf("reporting forwarded port: %w", err)
+	}
+
+	// Renew the mapping well before it expires
+	return granted * 3 / 4, nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward_test
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	"github.com/unmango/thecluster-operator/internal/natpmp"
+	"github.com/unmango/thecluster-operator/internal/natpmp/natpmptest"
+	"github.com/unmango/thecluster-operator/internal/portforward"
+)
+
+var _ = Describe("Forwarder", func() {
+	var (
+		pc        *corev1alpha1.ProtonVPNConfig
+		gateway   *natpmptest.Server
+		c         client.Client
+		forwarder *portforward.Forwarder
+	)
+
+	BeforeEach(func() {
+		pc = &corev1alpha1.ProtonVPNConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-protonvpn",
+				Namespace: "default",
+			},
+			Spec: corev1alpha1.ProtonVPNConfigSpec{
+				SecretRef: corev1alpha1.ProtonVPNConfigSecretRef{Name: "test-protonvpn"},
+				PortForwarding: &corev1alpha1.ProtonVPNPortForwarding{
+					Gateway:         "127.0.0.1",
+					LifetimeSeconds: 60,
+				},
+			},
+		}
+
+		By("Starting the NAT-PMP gateway stand-in")
+		gateway = natpmptest.NewServer(41234)
+		DeferCleanup(gateway.Close)
+		_, port, err := net.SplitHostPort(gateway.Addr)
+		Expect(err).NotTo(HaveOccurred())
+
+		forwarder = &portforward.Forwarder{Key: client.ObjectKeyFromObject(pc)}
+		forwarder.NATPMPPort, err = strconv.Atoi(port)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		c = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(pc).
+			WithStatusSubresource(pc).
+			Build()
+		forwarder.Client = c
+	})
+
+	It("should report the forwarded port", func(ctx context.Context) {
+		wait, err := forwarder.Renew(ctx)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wait).To(Equal(45 * time.Second))
+		Expect(gateway.Requests()).To(ConsistOf(
+			natpmptest.Request{Protocol: uint8(natpmp.UDP), InternalPort: 1, Lifetime: 60},
+			natpmptest.Request{Protocol: uint8(natpmp.TCP), InternalPort: 1, ExternalPort: 41234, Lifetime: 60},
+		))
+
+		actual := &corev1alpha1.ProtonVPNConfig{}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(pc), actual)).To(Succeed())
+		Expect(actual.Status.ForwardedPort).To(Equal(int32(41234)))
+		Expect(actual.Status.PortExpiresAt).NotTo(BeNil())
+	})
+
+	It("should renew the forwarded port", func(ctx context.Context) {
+		for range 2 {
+			_, err := forwarder.Renew(ctx)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		requests := gateway.Requests()
+		Expect(requests).To(HaveLen(4))
+		Expect(requests[2].ExternalPort).To(Equal(uint16(41234)))
+	})
+
+	When("the gateway grants a shorter lifetime", func() {
+		BeforeEach(func() {
+			gateway.SetMaxLifetime(20)
+		})
+
+		It("should renew and expire the port by the granted lifetime", func(ctx context.Context) {
+			wait, err := forwarder.Renew(ctx)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wait).To(Equal(15 * time.Second))
+
+			actual := &corev1alpha1.ProtonVPNConfig{}
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(pc), actual)).To(Succeed())
+			Expect(actual.Status.PortExpiresAt.Time).To(BeTemporally("~", time.Now().Add(20*time.Second), 5*time.Second))
+		})
+	})
+
+	When("the gateway refuses the mapping", func() {
+		BeforeEach(func() {
+			gateway.SetResultCode(2)
+		})
+
+		It("should not report a port", func(ctx context.Context) {
+			_, err := forwarder.Renew(ctx)
+			Expect(err).To(MatchError(natpmp.ResultError(2)))
+
+			actual := &corev1alpha1.ProtonVPNConfig{}
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(pc), actual)).To(Succeed())
+			Expect(actual.Status.ForwardedPort).To(BeZero())
+		})
+	})
+
+	When("port forwarding is disabled", func() {
+		BeforeEach(func() {
+			pc.Spec.PortForwarding = nil
+		})
+
+		It("should not request a port", func(ctx context.Context) {
+			_, err := forwarder.Renew(ctx)
+			Expect(err).To(HaveOccurred())
+			Expect(gateway.Requests()).To(BeEmpty())
+		})
+	})
+})
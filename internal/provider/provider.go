@@ -0,0 +1,196 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+var (
+	// ErrNoServers is returned when no server matches the requested filter
+	ErrNoServers = errors.New("no matching servers")
+
+	// ErrPortForwardUnsupported is returned by providers that can't forward ports
+	ErrPortForwardUnsupported = errors.New("port forwarding is not supported")
+)
+
+// Credentials authenticate an account with a provider
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Server is a WireGuard server offered by a provider
+type Server struct {
+	// The hostname or certificate common name of the server
+	Name string
+
+	// The provider specific region of the server
+	Region string
+
+	// The ISO 3166-1 alpha-2 country code of the server, when known
+	Country string
+
+	// The IP address of the server
+	IP string
+
+	// The WireGuard port of the server, when known before registration
+	Port int
+
+	// The WireGuard public key of the server, when known before registration
+	PublicKey string
+
+	// Whether the server supports port forwarding
+	PortForward bool
+}
+
+// Registration is the peer configuration returned after registering a key
+type Registration struct {
+	// The addresses assigned to the peer in CIDR notation
+	Address []string
+
+	// The DNS servers to use inside the tunnel
+	DNS []string
+
+	// The host:port of the server WireGuard endpoint
+	Endpoint string
+
+	// The WireGuard public key of the server
+	PublicKey string
+
+	// The IPs to route through the tunnel
+	AllowedIPs []string
+
+	// The in-tunnel address of the server's gateway, if any
+	Gateway string
+
+	// The server the key was registered with
+	Server Server
+}
+
+// PortForward is a port forwarded to the peer by the provider
+type PortForward struct {
+	Port      int
+	ExpiresAt time.Time
+}
+
+// Provider generates WireGuard configs for a commercial VPN
+type Provider interface {
+	// Authenticate exchanges credentials for a token used by later calls
+	Authenticate(ctx context.Context, creds Credentials) (string, error)
+
+	// ListServers lists the WireGuard servers offered by the provider
+	ListServers(ctx context.Context) ([]Server, error)
+
+	// RegisterKey registers publicKey with server and returns the peer configuration
+	RegisterKey(ctx context.Context, token string, server Server, publicKey string) (*Registration, error)
+
+	// PortForward requests a forwarded port for reg. Most providers only
+	// allow this from inside the tunnel.
+	PortForward(ctx context.Context, token string, reg *Registration) (*PortForward, error)
+}
+
+// KeyRevoker is implemented by providers that can revoke registered keys
+type KeyRevoker interface {
+	// RevokeKey revokes publicKey, which was registered with token
+	RevokeKey(ctx context.Context, token string, publicKey string) error
+}
+
+// ServerFilter selects the servers a config may be generated for
+type ServerFilter func(Server) bool
+
+// Result is a generated config and the registration it was created from
+type Result struct {
+	Config       *wireguard.Config
+	Registration *Registration
+
+	// The public key registered with the provider
+	PublicKey string
+}
+
+// Generate authenticates with p, picks the first server matching filter,
+// registers a new key pair with it and renders the resulting config.
+func Generate(ctx context.Context, p Provider, creds Credentials, filter ServerFilter) (*Result, error) {
+	token, err := p.Authenticate(ctx, creds)
+	if err != nil {
+		return nil, fmt.Errorf("authenticating: %w", err)
+	}
+
+	servers, err := p.ListServers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing servers: %w", err)
+	}
+
+	server, err := SelectServer(servers, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := wireguard.GeneratePrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("generating key: %w", err)
+	}
+
+	reg, err := p.RegisterKey(ctx, token, server, key.PublicKey().String())
+	if err != nil {
+		return nil, fmt.Errorf("registering key: %w", err)
+	}
+
+	return &Result{
+		Config:       NewConfig(key, reg),
+		Registration: reg,
+		PublicKey:    key.PublicKey().String(),
+	}, nil
+}
+
+// SelectServer returns the first server matching filter
+func SelectServer(servers []Server, filter ServerFilter) (Server, error) {
+	for _, s := range servers {
+		if filter == nil || filter(s) {
+			return s, nil
+		}
+	}
+
+	return Server{}, ErrNoServers
+}
+
+// NewConfig renders a wg-quick config for the peer with private key key
+func NewConfig(key wireguard.Key, reg *Registration) *wireguard.Config {
+	allowedIPs := reg.AllowedIPs
+	if len(allowedIPs) == 0 {
+		allowedIPs = []string{"0.0.0.0/0"}
+	}
+
+	return &wireguard.Config{
+		Interface: wireguard.Interface{
+			PrivateKey: key.String(),
+			Address:    reg.Address,
+			DNS:        reg.DNS,
+		},
+		Peers: []wireguard.Peer{{
+			PublicKey:           reg.PublicKey,
+			Endpoint:            reg.Endpoint,
+			AllowedIPs:          allowedIPs,
+			PersistentKeepalive: 25,
+		}},
+	}
+}
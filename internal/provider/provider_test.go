@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/unmango/thecluster-operator/internal/provider"
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+type fakeProvider struct {
+	servers   []provider.Server
+	publicKey string
+	server    provider.Server
+}
+
+func (f *fakeProvider) Authenticate(_ context.Context, creds provider.Credentials) (string, error) {
+	if creds.Username != "user" {
+		return "", errors.New("unauthorized")
+	}
+
+	return "token", nil
+}
+
+func (f *fakeProvider) ListServers(context.Context) ([]provider.Server, error) {
+	return f.servers, nil
+}
+
+func (f *fakeProvider) RegisterKey(_ context.Context, token string, server provider.Server, publicKey string) (*provider.Registration, error) {
+	Expect(token).To(Equal("token"))
+	f.publicKey = publicKey
+	f.server = server
+
+	return &provider.Registration{
+		Address:   []string{"10.0.0.2"},
+		DNS:       []string{"10.0.0.1"},
+		Endpoint:  server.IP + ":51820",
+		PublicKey: "server-key",
+		Server:    server,
+	}, nil
+}
+
+func (f *fakeProvider) PortForward(context.Context, string, *provider.Registration) (*provider.PortForward, error) {
+	return nil, provider.ErrPortForwardUnsupported
+}
+
+var _ = Describe("Generate", func() {
+	var p *fakeProvider
+
+	BeforeEach(func() {
+		p = &fakeProvider{servers: []provider.Server{
+			{Name: "first", Region: "a", IP: "192.0.2.1"},
+			{Name: "second", Region: "b", IP: "192.0.2.2"},
+		}}
+	})
+
+	It("should register a new key with the first matching server", func(ctx context.Context) {
+		res, err := provider.Generate(ctx, p, provider.Credentials{Username: "user"},
+			func(s provider.Server) bool { return s.Region == "b" },
+		)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.server.Name).To(Equal("second"))
+		Expect(res.Registration.Server.Name).To(Equal("second"))
+
+		key, err := wireguard.ParseKey(res.Config.Interface.PrivateKey)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(key.PublicKey().String()).To(Equal(p.publicKey))
+		Expect(res.PublicKey).To(Equal(p.publicKey))
+
+		Expect(res.Config.Interface.Address).To(ConsistOf("10.0.0.2"))
+		Expect(res.Config.Interface.DNS).To(ConsistOf("10.0.0.1"))
+		Expect(res.Config.Peers).To(ConsistOf(wireguard.Peer{
+			PublicKey:           "server-key",
+			Endpoint:            "192.0.2.2:51820",
+			AllowedIPs:          []string{"0.0.0.0/0"},
+			PersistentKeepalive: 25,
+		}))
+	})
+
+	It("should fail when no servers match", func(ctx context.Context) {
+		_, err := provider.Generate(ctx, p, provider.Credentials{Username: "user"},
+			func(s provider.Server) bool { return false },
+		)
+
+		Expect(err).To(MatchError(provider.ErrNoServers))
+	})
+
+	It("should fail when authentication fails", func(ctx context.Context) {
+		_, err := provider.Generate(ctx, p, provider.Credentials{Username: "nobody"}, nil)
+
+		Expect(err).To(MatchError(ContainSubstring("unauthorized")))
+	})
+})
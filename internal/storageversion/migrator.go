@@ -0,0 +1,166 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package storageversion rewrites the objects of a custom resource in its storage version.
+package storageversion
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// DefaultRetryInterval is how long the migrator waits before retrying a failed migration
+const DefaultRetryInterval = time.Minute
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions/status,verbs=update;patch
+
+// Migrator rewrites every object of a CRD in its storage version, then removes the
+// other versions from the CRD's stored versions, so that they can be dropped from
+// the CRD. Objects are rewritten with an empty patch, which the API server persists
+// in the storage version.
+//
+// The CRD must serve its stored versions, converting them if they differ, for the
+// duration of the migration.
+type Migrator struct {
+	// Client writes the objects and the CRD status
+	Client client.Client
+
+	// Reader reads the objects and the CRD, it should read from the API server so
+	// the migrator doesn't start an informer for every object it rewrites
+	Reader client.Reader
+
+	// The name of the CRD to migrate, e.g. wireguardclients.core.thecluster.io
+	CRD string
+
+	// How long to wait before retrying a failed migration, defaults to [DefaultRetryInterval]
+	RetryInterval time.Duration
+}
+
+var (
+	_ manager.Runnable               = &Migrator{}
+	_ manager.LeaderElectionRunnable = &Migrator{}
+)
+
+// NeedLeaderElection implements [manager.LeaderElectionRunnable], only one replica should rewrite objects
+func (m *Migrator) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements [manager.Runnable]. It retries the migration until it succeeds
+// or ctx is cancelled, without failing the manager.
+func (m *Migrator) Start(ctx context.Context) error {
+	log := log.FromContext(ctx).WithValues("crd", m.CRD)
+
+	interval := m.RetryInterval
+	if interval == 0 {
+		interval = DefaultRetryInterval
+	}
+
+	err := wait.PollUntilContextCancel(ctx, interval, true, func(ctx context.Context) (bool, error) {
+		if err := m.Migrate(ctx); err != nil {
+			log.Error(err, "Failed to migrate storage version, retrying", "after", interval)
+			return false, nil
+		}
+
+		return true, nil
+	})
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	return nil
+}
+
+// Migrate rewrites the objects of the CRD in its storage version, and records
+// the storage version as the only stored version. It does nothing when the
+// storage version is already the only stored version.
+func (m *Migrator) Migrate(ctx context.Context) error {
+	log := log.FromContext(ctx).WithValues("crd", m.CRD)
+
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := m.Reader.Get(ctx, client.ObjectKey{Name: m.CRD}, crd); err != nil {
+		return fmt.Errorf("getting crd: %w", err)
+	}
+
+	storage := StorageVersion(crd)
+	if storage == "" {
+		return fmt.Errorf("crd %s has no storage version", m.CRD)
+	}
+	if slices.Equal(crd.Status.StoredVersions, []string{storage}) {
+		return nil
+	}
+
+	log.Info("Migrating storage version", "from", crd.Status.StoredVersions, "to", storage)
+
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(crd.Spec.Group + "/" + storage)
+	list.SetKind(crd.Spec.Names.ListKind)
+
+	for {
+		if err := m.Reader.List(ctx, list, client.Limit(500), client.Continue(list.GetContinue())); err != nil {
+			return fmt.Errorf("listing %s: %w", crd.Spec.Names.Plural, err)
+		}
+
+		for _, obj := range list.Items {
+			if err := m.rewrite(ctx, &obj); err != nil {
+				return fmt.Errorf("rewriting %s %s/%s: %w",
+					crd.Spec.Names.Singular, obj.GetNamespace(), obj.GetName(), err)
+			}
+		}
+
+		if list.GetContinue() == "" {
+			break
+		}
+	}
+
+	// Objects created since listing are written in the storage version already
+	crd.Status.StoredVersions = []string{storage}
+	if err := m.Client.Status().Update(ctx, crd); err != nil {
+		return fmt.Errorf("updating stored versions: %w", err)
+	}
+
+	log.Info("Migrated storage version", "to", storage)
+	return nil
+}
+
+// rewrite persists obj in the storage version. An empty patch doesn't change
+// the object's content, so it can't conflict with other writers.
+func (m *Migrator) rewrite(ctx context.Context, obj *unstructured.Unstructured) error {
+	patch := client.RawPatch(types.MergePatchType, []byte("{}"))
+	return client.IgnoreNotFound(m.Client.Patch(ctx, obj, patch))
+}
+
+// StorageVersion returns the name of the version crd is stored in
+func StorageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			return v.Name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageversion_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/unmango/thecluster-operator/internal/storageversion"
+)
+
+var _ = Describe("Migrator", func() {
+	const crdName = "widgets.example.com"
+
+	var (
+		crd      *apiextensionsv1.CustomResourceDefinition
+		widget   *unstructured.Unstructured
+		c        client.Client
+		migrator *storageversion.Migrator
+	)
+
+	BeforeEach(func() {
+		crd = &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: crdName},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Group: "example.com",
+				Names: apiextensionsv1.CustomResourceDefinitionNames{
+					Plural:   "widgets",
+					Singular: "widget",
+					Kind:     "Widget",
+					ListKind: "WidgetList",
+				},
+				Scope: apiextensionsv1.NamespaceScoped,
+				Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+					{Name: "v1alpha1", Served: true},
+					{Name: "v1beta1", Served: true, Storage: true},
+				},
+			},
+			Status: apiextensionsv1.CustomResourceDefinitionStatus{
+				StoredVersions: []string{"v1alpha1", "v1beta1"},
+			},
+		}
+
+		widget = &unstructured.Unstructured{}
+		widget.SetAPIVersion("example.com/v1beta1")
+		widget.SetKind("Widget")
+		widget.SetNamespace("default")
+		widget.SetName("test-widget")
+		widget.SetResourceVersion("1")
+
+		scheme := runtime.NewScheme()
+		Expect(apiextensionsv1.AddToScheme(scheme)).To(Succeed())
+
+		mapper := meta.NewDefaultRESTMapper(nil)
+		mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1beta1", Kind: "Widget"}, meta.RESTScopeNamespace)
+
+		c = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithRESTMapper(mapper).
+			WithObjects(crd, widget).
+			WithStatusSubresource(crd).
+			Build()
+
+		migrator = &storageversion.Migrator{
+			Client: c,
+			Reader: c,
+			CRD:    crdName,
+		}
+	})
+
+	It("should return the storage version", func() {
+		Expect(storageversion.StorageVersion(crd)).To(Equal("v1beta1"))
+	})
+
+	It("should rewrite objects in the storage version", func(ctx context.Context) {
+		Expect(migrator.Migrate(ctx)).To(Succeed())
+
+		actual := &unstructured.Unstructured{}
+		actual.SetAPIVersion("example.com/v1beta1")
+		actual.SetKind("Widget")
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(widget), actual)).To(Succeed())
+		Expect(actual.GetResourceVersion()).NotTo(Equal("1"))
+	})
+
+	It("should drop the old stored versions", func(ctx context.Context) {
+		Expect(migrator.Migrate(ctx)).To(Succeed())
+
+		actual := &apiextensionsv1.CustomResourceDefinition{}
+		Expect(c.Get(ctx, client.ObjectKey{Name: crdName}, actual)).To(Succeed())
+		Expect(actual.Status.StoredVersions).To(ConsistOf("v1beta1"))
+	})
+
+	It("should do nothing once migrated", func(ctx context.Context) {
+		Expect(migrator.Migrate(ctx)).To(Succeed())
+		Expect(migrator.Migrate(ctx)).To(Succeed())
+
+		actual := &unstructured.Unstructured{}
+		actual.SetAPIVersion("example.com/v1beta1")
+		actual.SetKind("Widget")
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(widget), actual)).To(Succeed())
+		Expect(actual.GetResourceVersion()).To(Equal("2"))
+	})
+
+	It("should fail without a storage version", func(ctx context.Context) {
+		crd.Spec.Versions[1].Storage = false
+		Expect(c.Update(ctx, crd)).To(Succeed())
+
+		Expect(migrator.Migrate(ctx)).To(MatchError(ContainSubstring("no storage version")))
+	})
+})
@@ -0,0 +1,243 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	corecontroller "github.com/unmango/thecluster-operator/internal/controller/core"
+)
+
+const (
+	// InjectAnnotation names the WireguardClient whose tunnel a pod is routed through.
+	// It only has an effect in namespaces labeled with [InjectionLabel]. The sidecar
+	// connects with the client's keys, so the client must be sidecar only and only
+	// one injected pod can hold the tunnel at a time.
+	InjectAnnotation = "core.thecluster.io/wireguard-client"
+
+	// InjectionLabel opts a namespace in to the pod webhook when set to [InjectionEnabled].
+	// The webhook only sees pods of namespaces that opted in, so it can't hold up
+	// pod creation anywhere else.
+	InjectionLabel = "core.thecluster.io/wireguard-injection"
+
+	// InjectionEnabled is the value of [InjectionLabel] that opts a namespace in
+	InjectionEnabled = "enabled"
+
+	// GatewayAnnotation names the gateway WireguardClient a namespace routes its pods
+	// through, as "<namespace>/<name>", or "<name>" for a gateway in the namespace itself.
+	// The gateway must also select the namespace. Only the namespace can choose its
+	// gateway, so a WireguardClient can't claim another tenant's pods.
+	GatewayAnnotation = "core.thecluster.io/wireguard-gateway"
+
+	// SidecarName is the name of the injected wireguard container
+	SidecarName = "wireguard"
+
+	// sidecarVolumePrefix keeps the config volumes from colliding with the pod's own
+	sidecarVolumePrefix = "wireguard-"
+)
+
+// log is for logging in this package.
+var podlog = logf.Log.WithName("pod-resource")
+
+// SetupPodWebhookWithManager registers the webhook for Pod in the manager.
+func SetupPodWebhookWithManager(mgr ctrl.Manager, defaultImage string) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&corev1.Pod{}).
+		WithDefaulter(&PodCustomDefaulter{
+			Client: mgr.GetClient(),
+			// Read through to the API server, pods are often created right after their client
+			APIReader:    mgr.GetAPIReader(),
+			DefaultImage: defaultImage,
+		}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate--v1-pod,mutating=true,failurePolicy=fail,sideEffects=None,groups="",resources=pods,verbs=create,versions=v1,name=mpod-v1.kb.io,admissionReviewVersions=v1
+// +kubebuilder:rbac:groups=core.thecluster.io,resources=wireguardclients,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get
+
+// PodCustomDefaulter injects a wireguard sidecar into pods annotated with
+// [InjectAnnotation]. The sidecar shares the pod's network namespace, so the
+// traffic of the app containers goes through the tunnel. Other pods are routed
+// through the gateway named by their namespace's [GatewayAnnotation], if any.
+type PodCustomDefaulter struct {
+	// Client reads namespaces and gateways from the cache
+	Client client.Reader
+
+	// APIReader reads the clients named by [InjectAnnotation], and their
+	// deployments, from the API server
+	APIReader client.Reader
+
+	// The image used for clients that don't specify one
+	DefaultImage string
+}
+
+var _ webhook.CustomDefaulter = &PodCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind Pod.
+func (d *PodCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return fmt.Errorf("expected an Pod object but got %T", obj)
+	}
+
+	// The pod's namespace isn't set yet when it is created from a template
+	namespace := pod.Namespace
+	if req, err := admission.RequestFromContext(ctx); err == nil && req.Namespace != "" {
+		namespace = req.Namespace
+	}
+
+	name := pod.Annotations[InjectAnnotation]
+	if name == "" {
+		return d.routeThroughGateway(ctx, pod, namespace)
+	}
+
+	podlog.Info("Injecting wireguard sidecar", "namespace", namespace, "name", pod.GetName(), "client", name)
+
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+	wg := &corev1alpha1.WireguardClient{}
+	if err := d.APIReader.Get(ctx, key, wg); err != nil {
+		// Admitting the pod without the sidecar would bypass the tunnel
+		return fmt.Errorf("getting wireguard client %s: %w", name, err)
+	}
+
+	// The sidecar connects with the client's keys, a running client pod would take the tunnel over
+	if !wg.Spec.SidecarOnly {
+		return fmt.Errorf("wireguard client %s runs its own pod, set sidecarOnly to inject it", name)
+	}
+	deployment := &appsv1.Deployment{}
+	if err := d.APIReader.Get(ctx, key, deployment); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("getting deployment of wireguard client %s: %w", name, err)
+	} else if err == nil && metav1.IsControlledBy(deployment, wg) && deployment.Status.Replicas > 0 {
+		return fmt.Errorf("deployment of wireguard client %s is still running", name)
+	}
+
+	return InjectSidecar(pod, wg, corecontroller.WireguardImage(wg, d.DefaultImage))
+}
+
+// routeThroughGateway routes pod through the gateway named by its namespace's
+// [GatewayAnnotation], if any
+func (d *PodCustomDefaulter) routeThroughGateway(ctx context.Context, pod *corev1.Pod, namespace string) error {
+	// Gateway pods can't be routed through themselves
+	if _, ok := pod.Labels[corecontroller.WireguardClientUIDLabel]; ok {
+		return nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := d.Client.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return fmt.Errorf("getting namespace %s: %w", namespace, err)
+	}
+
+	ref := ns.Annotations[GatewayAnnotation]
+	if ref == "" {
+		return nil
+	}
+
+	key := client.ObjectKey{Namespace: namespace, Name: ref}
+	if i := strings.Index(ref, "/"); i >= 0 {
+		key = client.ObjectKey{Namespace: ref[:i], Name: ref[i+1:]}
+	}
+
+	// Admitting the pod without routing it would bypass the tunnel
+	gateway := &corev1alpha1.WireguardClient{}
+	if err := d.Client.Get(ctx, key, gateway); err != nil {
+		return fmt.Errorf("getting gateway %s: %w", key, err)
+	}
+	if gateway.Spec.Gateway == nil || !gateway.DeletionTimestamp.IsZero() {
+		return fmt.Errorf("wireguard client %s is not a gateway", key)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&gateway.Spec.Gateway.NamespaceSelector)
+	if err != nil {
+		return fmt.Errorf("gateway %s has an invalid namespace selector: %w", key, err)
+	}
+	if selector.Empty() || !selector.Matches(labels.Set(ns.Labels)) {
+		return fmt.Errorf("gateway %s does not select namespace %s", key, namespace)
+	}
+
+	podlog.Info("Routing pod through gateway", "namespace", namespace, "name", pod.GetName(),
+		"gateway", key)
+
+	InjectGatewayClient(pod, gateway)
+	return nil
+}
+
+// InjectGatewayClient adds the containers that route pod through the gateway of
+// wg ahead of the pod's own init containers, so that they are routed as well.
+// Pods that are already routed are left alone.
+func InjectGatewayClient(pod *corev1.Pod, wg *corev1alpha1.WireguardClient) {
+	if slices.ContainsFunc(pod.Spec.InitContainers, func(c corev1.Container) bool {
+		return c.Name == corecontroller.GatewayInitContainerName
+	}) {
+		return
+	}
+
+	init, sidecar, volume := corecontroller.NewGatewayClientContainers(wg)
+	pod.Spec.InitContainers = append([]corev1.Container{init, sidecar}, pod.Spec.InitContainers...)
+	pod.Spec.Volumes = append(pod.Spec.Volumes, volume)
+}
+
+// InjectSidecar adds the wireguard container of wg to pod as a native sidecar,
+// so that it starts before, and outlives, the app containers. Pods that already
+// have the sidecar are left alone.
+func InjectSidecar(pod *corev1.Pod, wg *corev1alpha1.WireguardClient, image string) error {
+	isSidecar := func(c corev1.Container) bool { return c.Name == SidecarName }
+	if slices.ContainsFunc(pod.Spec.InitContainers, isSidecar) {
+		return nil
+	}
+	if slices.ContainsFunc(pod.Spec.Containers, isSidecar) {
+		return fmt.Errorf("pod already has a container named %s", SidecarName)
+	}
+
+	container, volumes, err := corecontroller.NewWireguardContainer(wg, image)
+	if err != nil {
+		return err
+	}
+
+	for i := range volumes {
+		volumes[i].Name = sidecarVolumePrefix + volumes[i].Name
+	}
+	for i := range container.VolumeMounts {
+		container.VolumeMounts[i].Name = sidecarVolumePrefix + container.VolumeMounts[i].Name
+	}
+
+	// The tunnel doesn't accept connections, so the sidecar doesn't publish a port
+	container.Ports = nil
+	container.RestartPolicy = ptr.To(corev1.ContainerRestartPolicyAlways)
+
+	pod.Spec.InitContainers = append([]corev1.Container{container}, pod.Spec.InitContainers...)
+	pod.Spec.Volumes = append(pod.Spec.Volumes, volumes...)
+	pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, wg.Spec.ImagePullSecrets...)
+
+	return nil
+}
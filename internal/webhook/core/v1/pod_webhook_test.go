@@ -0,0 +1,301 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	corecontroller "github.com/unmango/thecluster-operator/internal/controller/core"
+)
+
+var _ = Describe("Pod Webhook", func() {
+	var (
+		obj       *corev1.Pod
+		wg        *corev1alpha1.WireguardClient
+		defaulter PodCustomDefaulter
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		wg = &corev1alpha1.WireguardClient{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-sidecar",
+				Namespace: "default",
+			},
+			Spec: corev1alpha1.WireguardClientSpec{
+				PUID: 1000,
+				PGID: 1000,
+				TZ:   "America/Chicago",
+				Configs: []corev1alpha1.WireguardClientConfig{{
+					Name: "wg0",
+					ValueFrom: &corev1alpha1.WireguardClientConfigSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "test-sidecar"},
+							Key:                  "wg0.conf",
+						},
+					},
+				}},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+				SidecarOnly:      true,
+			},
+		}
+		Expect(k8sClient.Create(ctx, wg)).To(Succeed())
+
+		obj = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "default",
+				Annotations: map[string]string{
+					InjectAnnotation: wg.Name,
+				},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:  "app",
+					Image: "example.com/app:latest",
+				}},
+				Volumes: []corev1.Volume{{
+					Name: "wg0",
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{},
+					},
+				}},
+			},
+		}
+		defaulter = PodCustomDefaulter{Client: k8sClient, APIReader: k8sClient}
+	})
+
+	AfterEach(func(ctx context.Context) {
+		Expect(k8sClient.Delete(ctx, wg)).To(Succeed())
+	})
+
+	Context("When creating Pod under Defaulting Webhook", func() {
+		It("Should inject the wireguard sidecar", func(ctx context.Context) {
+			By("calling the Default method")
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+
+			By("checking that the sidecar starts before the app")
+			Expect(obj.Spec.InitContainers).To(HaveLen(1))
+			sidecar := obj.Spec.InitContainers[0]
+			Expect(sidecar.Name).To(Equal(SidecarName))
+			Expect(sidecar.Image).To(Equal(corecontroller.DefaultWireguardImage))
+			Expect(sidecar.RestartPolicy).To(HaveValue(Equal(corev1.ContainerRestartPolicyAlways)))
+			Expect(sidecar.SecurityContext.Capabilities.Add).To(ContainElement(corev1.Capability("NET_ADMIN")))
+			Expect(sidecar.VolumeMounts).To(ConsistOf(corev1.VolumeMount{
+				Name:      "wireguard-wg0",
+				MountPath: "/config/wg0.conf",
+			}))
+
+			By("checking that the configs don't collide with the pod's volumes")
+			Expect(obj.Spec.Volumes).To(HaveLen(2))
+			Expect(obj.Spec.Volumes).To(ContainElement(SatisfyAll(
+				HaveField("Name", "wireguard-wg0"),
+				HaveField("Secret.SecretName", "test-sidecar"),
+			)))
+			Expect(obj.Spec.ImagePullSecrets).To(ConsistOf(corev1.LocalObjectReference{Name: "registry"}))
+
+			By("calling the Default method again")
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.InitContainers).To(HaveLen(1))
+		})
+
+		It("Should leave pods without the annotation alone", func(ctx context.Context) {
+			obj.Annotations = nil
+
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.InitContainers).To(BeEmpty())
+			Expect(obj.Spec.Volumes).To(HaveLen(1))
+		})
+
+		It("Should use the configured default image", func(ctx context.Context) {
+			defaulter.DefaultImage = "example.com/wireguard:1.0"
+
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.InitContainers[0].Image).To(Equal("example.com/wireguard:1.0"))
+		})
+
+		It("Should deny pods referencing a missing client", func(ctx context.Context) {
+			obj.Annotations[InjectAnnotation] = "missing"
+
+			Expect(defaulter.Default(ctx, obj)).NotTo(Succeed())
+		})
+
+		It("Should deny pods referencing a client that runs its own pod", func(ctx context.Context) {
+			wg.Spec.SidecarOnly = false
+			Expect(k8sClient.Update(ctx, wg)).To(Succeed())
+
+			Expect(defaulter.Default(ctx, obj)).To(MatchError(ContainSubstring("runs its own pod")))
+			Expect(obj.Spec.InitContainers).To(BeEmpty())
+		})
+
+		It("Should deny pods while the client's deployment is still running", func(ctx context.Context) {
+			By("creating a running deployment controlled by the client")
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      wg.Name,
+					Namespace: wg.Namespace,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": wg.Name}},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": wg.Name}},
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{
+							Name:  "wireguard",
+							Image: corecontroller.DefaultWireguardImage,
+						}}},
+					},
+				},
+			}
+			Expect(controllerutil.SetControllerReference(wg, deployment, k8sClient.Scheme())).To(Succeed())
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+			DeferCleanup(func(ctx context.Context) {
+				Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+			})
+			deployment.Status.Replicas = 1
+			Expect(k8sClient.Status().Update(ctx, deployment)).To(Succeed())
+
+			Expect(defaulter.Default(ctx, obj)).To(MatchError(ContainSubstring("still running")))
+		})
+
+		It("Should inject the sidecar when a pod is created", func(ctx context.Context) {
+			By("creating the pod through the API server")
+			Expect(k8sClient.Create(ctx, obj)).To(Succeed())
+			DeferCleanup(func(ctx context.Context) {
+				Expect(k8sClient.Delete(ctx, obj)).To(Succeed())
+			})
+
+			Expect(obj.Spec.InitContainers).To(ContainElement(HaveField("Name", SidecarName)))
+		})
+	})
+
+	Context("When a namespace is routed through a gateway", func() {
+		BeforeEach(func(ctx context.Context) {
+			By("creating a namespace selected by the gateway")
+			ns := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test-routed",
+					Labels: map[string]string{"routed": "true"},
+				},
+			}
+			Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, ns))).To(Succeed())
+
+			By("naming the gateway in the namespace")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+			ns.Annotations = map[string]string{GatewayAnnotation: "default/" + wg.Name}
+			Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+
+			By("turning the client into a gateway")
+			wg.Spec.SidecarOnly = false
+			wg.Spec.Gateway = &corev1alpha1.WireguardClientGateway{
+				NamespaceSelector: metav1.LabelSelector{
+					MatchLabels: map[string]string{"routed": "true"},
+				},
+				LocalCIDRs: []string{"10.42.0.0/16"},
+			}
+			Expect(k8sClient.Update(ctx, wg)).To(Succeed())
+
+			obj.Namespace = ns.Name
+			obj.Annotations = nil
+		})
+
+		It("Should route pods through the gateway", func(ctx context.Context) {
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+
+			Expect(obj.Spec.InitContainers).To(HaveLen(2))
+			init, sidecar := obj.Spec.InitContainers[0], obj.Spec.InitContainers[1]
+			Expect(init.Name).To(Equal(corecontroller.GatewayInitContainerName))
+			Expect(init.Env).To(ConsistOf(corev1.EnvVar{
+				Name:  "GATEWAY_SETTINGS",
+				Value: corecontroller.GatewaySettings(wg),
+			}))
+			Expect(sidecar.Name).To(Equal(corecontroller.GatewayContainerName))
+			Expect(sidecar.RestartPolicy).To(HaveValue(Equal(corev1.ContainerRestartPolicyAlways)))
+			Expect(obj.Spec.Volumes).To(HaveLen(2))
+
+			By("calling the Default method again")
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.InitContainers).To(HaveLen(2))
+		})
+
+		It("Should leave pods in other namespaces alone", func(ctx context.Context) {
+			obj.Namespace = "default"
+
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.InitContainers).To(BeEmpty())
+		})
+
+		It("Should ignore gateways the namespace didn't name", func(ctx context.Context) {
+			other := &corev1alpha1.WireguardClient{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-other-gateway", Namespace: "default"},
+				Spec:       *wg.Spec.DeepCopy(),
+			}
+			Expect(k8sClient.Create(ctx, other)).To(Succeed())
+			DeferCleanup(func(ctx context.Context) {
+				Expect(k8sClient.Delete(ctx, other)).To(Succeed())
+			})
+
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.InitContainers[0].Env).To(ConsistOf(corev1.EnvVar{
+				Name:  "GATEWAY_SETTINGS",
+				Value: corecontroller.GatewaySettings(wg),
+			}))
+		})
+
+		It("Should leave pods alone when the namespace names no gateway", func(ctx context.Context) {
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: obj.Namespace}, ns)).To(Succeed())
+			ns.Annotations = nil
+			Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.InitContainers).To(BeEmpty())
+		})
+
+		It("Should deny pods when the gateway doesn't select the namespace", func(ctx context.Context) {
+			wg.Spec.Gateway.NamespaceSelector = metav1.LabelSelector{
+				MatchLabels: map[string]string{"routed": "elsewhere"},
+			}
+			Expect(k8sClient.Update(ctx, wg)).To(Succeed())
+
+			Expect(defaulter.Default(ctx, obj)).To(MatchError(ContainSubstring("does not select")))
+		})
+
+		It("Should deny pods when the named gateway is missing", func(ctx context.Context) {
+			ns := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: obj.Namespace}, ns)).To(Succeed())
+			ns.Annotations = map[string]string{GatewayAnnotation: "default/missing"}
+			Expect(k8sClient.Update(ctx, ns)).To(Succeed())
+
+			Expect(defaulter.Default(ctx, obj)).NotTo(Succeed())
+		})
+
+		It("Should leave gateway pods alone", func(ctx context.Context) {
+			obj.Labels = map[string]string{corecontroller.WireguardClientUIDLabel: string(wg.UID)}
+
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.InitContainers).To(BeEmpty())
+		})
+	})
+})
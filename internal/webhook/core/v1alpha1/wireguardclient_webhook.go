@@ -0,0 +1,201 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"time"
+
+	// Embed the tz database, so timezones validate without one in the image
+	_ "time/tzdata"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+)
+
+// DefaultTZ is the timezone of clients that don't specify one
+const DefaultTZ = "Etc/UTC"
+
+// log is for logging in this package.
+var wireguardclientlog = logf.Log.WithName("wireguardclient-resource")
+
+// SetupWireguardClientWebhookWithManager registers the webhook for WireguardClient in the manager.
+func SetupWireguardClientWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&corev1alpha1.WireguardClient{}).
+		WithValidator(&WireguardClientCustomValidator{}).
+		WithDefaulter(&WireguardClientCustomDefaulter{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-core-thecluster-io-v1alpha1-wireguardclient,mutating=true,failurePolicy=fail,sideEffects=None,groups=core.thecluster.io,resources=wireguardclients,verbs=create;update,versions=v1alpha1,name=mwireguardclient-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// WireguardClientCustomDefaulter sets default values on the WireguardClient
+// resource when it is created or updated.
+type WireguardClientCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &WireguardClientCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind WireguardClient.
+func (d *WireguardClientCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	wg, ok := obj.(*corev1alpha1.WireguardClient)
+	if !ok {
+		return fmt.Errorf("expected an WireguardClient object but got %T", obj)
+	}
+	wireguardclientlog.Info("Defaulting for WireguardClient", "name", wg.GetName())
+
+	if wg.Spec.TZ == "" {
+		wg.Spec.TZ = DefaultTZ
+	}
+
+	// The image logs QR codes by default, only opt out for new clients so
+	// that updating an existing client doesn't change its behavior
+	if req, err := admission.RequestFromContext(ctx); err == nil && req.Operation == admissionv1.Create {
+		if wg.Spec.LogConfs == nil {
+			wg.Spec.LogConfs = ptr.To(false)
+		}
+	}
+
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-core-thecluster-io-v1alpha1-wireguardclient,mutating=false,failurePolicy=fail,sideEffects=None,groups=core.thecluster.io,resources=wireguardclients,verbs=create;update,versions=v1alpha1,name=vwireguardclient-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// WireguardClientCustomValidator validates the WireguardClient resource when it is created or updated.
+type WireguardClientCustomValidator struct{}
+
+var _ webhook.CustomValidator = &WireguardClientCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type WireguardClient.
+func (v *WireguardClientCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	wg, ok := obj.(*corev1alpha1.WireguardClient)
+	if !ok {
+		return nil, fmt.Errorf("expected a WireguardClient object but got %T", obj)
+	}
+	wireguardclientlog.Info("Validation for WireguardClient upon creation", "name", wg.GetName())
+
+	return nil, validate(wg)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type WireguardClient.
+func (v *WireguardClientCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	wg, ok := newObj.(*corev1alpha1.WireguardClient)
+	if !ok {
+		return nil, fmt.Errorf("expected a WireguardClient object for the newObj but got %T", newObj)
+	}
+	old, ok := oldObj.(*corev1alpha1.WireguardClient)
+	if !ok {
+		return nil, fmt.Errorf("expected a WireguardClient object for the oldObj but got %T", oldObj)
+	}
+	wireguardclientlog.Info("Validation for WireguardClient upon update", "name", wg.GetName())
+
+	// Clients created before the webhook may be invalid, they must still
+	// accept metadata updates such as finalizer removal
+	if equality.Semantic.DeepEqual(old.Spec, wg.Spec) {
+		return nil, nil
+	}
+
+	return nil, validate(wg)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type WireguardClient.
+func (v *WireguardClientCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validate(wg *corev1alpha1.WireguardClient) error {
+	errs := ValidateSpec(&wg.Spec, field.NewPath("spec"))
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		corev1alpha1.GroupVersion.WithKind("WireguardClient").GroupKind(),
+		wg.Name, errs,
+	)
+}
+
+// ValidateSpec validates the fields of spec the CRD schema can't
+func ValidateSpec(spec *corev1alpha1.WireguardClientSpec, path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+
+	if spec.PUID < 0 {
+		errs = append(errs, field.Invalid(path.Child("puid"), spec.PUID, "must be greater than or equal to 0"))
+	}
+	if spec.PGID < 0 {
+		errs = append(errs, field.Invalid(path.Child("pgid"), spec.PGID, "must be greater than or equal to 0"))
+	}
+
+	// LoadLocation accepts "Local", which isn't a timezone the container knows
+	if spec.TZ != "" {
+		if _, err := time.LoadLocation(spec.TZ); err != nil || spec.TZ == "Local" {
+			errs = append(errs, field.Invalid(path.Child("tz"), spec.TZ, "must be a tz database time zone"))
+		}
+	}
+
+	for i, ip := range spec.AllowedIPs {
+		if _, err := netip.ParsePrefix(ip); err != nil {
+			errs = append(errs, field.Invalid(path.Child("allowedIps").Index(i), ip, "must be in CIDR notation"))
+		}
+	}
+
+	configs := path.Child("configs")
+	if len(spec.Configs) == 0 {
+		errs = append(errs, field.Required(configs, "at least one config is required"))
+	}
+
+	names := map[string]bool{}
+	for i, c := range spec.Configs {
+		p := configs.Index(i)
+		if names[c.Name] {
+			errs = append(errs, field.Duplicate(p.Child("name"), c.Name))
+		}
+		names[c.Name] = true
+
+		if slices.Contains(corev1alpha1.ReservedVolumeNames, c.Name) {
+			errs = append(errs, field.Invalid(p.Child("name"), c.Name, "is reserved by the operator"))
+		}
+
+		switch {
+		case c.ValueFrom == nil && c.Inline == nil:
+			errs = append(errs, field.Required(p, "exactly one of valueFrom or inline must be set"))
+		case c.ValueFrom != nil && c.Inline != nil:
+			errs = append(errs, field.Forbidden(p, "exactly one of valueFrom or inline must be set"))
+		case c.ValueFrom != nil:
+			source, msg := p.Child("valueFrom"), "exactly one of secretKeyRef or configMapKeyRef must be set"
+			if c.ValueFrom.SecretKeyRef == nil && c.ValueFrom.ConfigMapKeyRef == nil {
+				errs = append(errs, field.Required(source, msg))
+			} else if c.ValueFrom.SecretKeyRef != nil && c.ValueFrom.ConfigMapKeyRef != nil {
+				errs = append(errs, field.Forbidden(source, msg))
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+)
+
+var _ = Describe("WireguardClient Webhook", func() {
+	var (
+		obj       *corev1alpha1.WireguardClient
+		oldObj    *corev1alpha1.WireguardClient
+		validator WireguardClientCustomValidator
+		defaulter WireguardClientCustomDefaulter
+	)
+
+	secretConfig := func(name string) corev1alpha1.WireguardClientConfig {
+		return corev1alpha1.WireguardClientConfig{
+			Name: name,
+			ValueFrom: &corev1alpha1.WireguardClientConfigSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: name},
+					Key:                  "wg0.conf",
+				},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		obj = &corev1alpha1.WireguardClient{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-webhook",
+				Namespace: "default",
+			},
+			Spec: corev1alpha1.WireguardClientSpec{
+				PUID:       1000,
+				PGID:       1000,
+				TZ:         "America/Chicago",
+				AllowedIPs: []string{"0.0.0.0/0", "::/0"},
+				Configs:    []corev1alpha1.WireguardClientConfig{secretConfig("wg0")},
+			},
+		}
+		oldObj = obj.DeepCopy()
+		validator = WireguardClientCustomValidator{}
+		defaulter = WireguardClientCustomDefaulter{}
+	})
+
+	Context("When creating WireguardClient under Defaulting Webhook", func() {
+		It("Should default the timezone", func(ctx context.Context) {
+			obj.Spec.TZ = ""
+
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.TZ).To(Equal(DefaultTZ))
+		})
+
+		It("Should disable config logging for new clients", func(ctx context.Context) {
+			ctx = admission.NewContextWithRequest(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Create},
+			})
+
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.LogConfs).To(Equal(ptr.To(false)))
+		})
+
+		It("Should not change config logging for existing clients", func(ctx context.Context) {
+			ctx = admission.NewContextWithRequest(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Update},
+			})
+
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.LogConfs).To(BeNil())
+		})
+	})
+
+	Context("When creating or updating WireguardClient under Validating Webhook", func() {
+		It("Should admit a valid client", func(ctx context.Context) {
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny creation without configs", func(ctx context.Context) {
+			obj.Spec.Configs = nil
+
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.configs")))
+		})
+
+		It("Should deny duplicate config names", func(ctx context.Context) {
+			obj.Spec.Configs = append(obj.Spec.Configs, secretConfig("wg0"))
+
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring(`spec.configs[1].name: Duplicate value: "wg0"`)))
+		})
+
+		It("Should deny configs without a value source", func(ctx context.Context) {
+			obj.Spec.Configs[0].ValueFrom = &corev1alpha1.WireguardClientConfigSource{}
+
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.configs[0].valueFrom")))
+		})
+
+		It("Should deny invalid allowed IPs", func(ctx context.Context) {
+			obj.Spec.AllowedIPs = []string{"10.0.0.0/8", "10.13.13.1"}
+
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.allowedIps[1]")))
+		})
+
+		It("Should deny unknown timezones", func(ctx context.Context) {
+			obj.Spec.TZ = "America/Springfield"
+
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.tz")))
+		})
+
+		It("Should deny negative user and group IDs", func(ctx context.Context) {
+			obj.Spec.PUID = -1
+			obj.Spec.PGID = -1
+
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(And(
+				ContainSubstring("spec.puid"),
+				ContainSubstring("spec.pgid"),
+			)))
+		})
+
+		It("Should admit metadata updates to existing invalid clients", func(ctx context.Context) {
+			oldObj.Spec.TZ = "America/Springfield"
+			obj = oldObj.DeepCopy()
+			obj.Finalizers = nil
+
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny invalid spec updates", func(ctx context.Context) {
+			obj.Spec.Configs = append(obj.Spec.Configs, secretConfig("wireguard-inbound"))
+
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().To(MatchError(ContainSubstring("reserved")))
+		})
+
+		It("Should be served by the API server", func(ctx context.Context) {
+			obj.Spec.TZ = "Not/AZone"
+
+			err := k8sClient.Create(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, obj))).To(Succeed())
+		})
+	})
+})
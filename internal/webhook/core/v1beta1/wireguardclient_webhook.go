@@ -0,0 +1,31 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	corev1beta1 "github.com/unmango/thecluster-operator/api/core/v1beta1"
+)
+
+// SetupWireguardClientWebhookWithManager registers the conversion webhook for WireguardClient in the manager.
+// The v1alpha1 defaulting and validating webhooks also admit v1beta1 requests, since the API server
+// converts requests to a version the webhook matches.
+func SetupWireguardClientWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&corev1beta1.WireguardClient{}).
+		Complete()
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1alpha1 "github.com/unmango/thecluster-operator/api/core/v1alpha1"
+	corev1beta1 "github.com/unmango/thecluster-operator/api/core/v1beta1"
+)
+
+var _ = Describe("WireguardClient Webhook", func() {
+	var obj *corev1alpha1.WireguardClient
+
+	BeforeEach(func() {
+		obj = &corev1alpha1.WireguardClient{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-conversion",
+				Namespace: "default",
+			},
+			Spec: corev1alpha1.WireguardClientSpec{
+				PUID:       1000,
+				PGID:       1001,
+				TZ:         "America/Chicago",
+				AllowedIPs: []string{"0.0.0.0/0"},
+				LogConfs:   ptr.To(false),
+				ReadOnly:   ptr.To(true),
+				Configs: []corev1alpha1.WireguardClientConfig{
+					{
+						Name: "wg0",
+						ValueFrom: &corev1alpha1.WireguardClientConfigSource{
+							SecretKeyRef: &corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "wg0"},
+								Key:                  "wg0.conf",
+							},
+						},
+					},
+					{
+						Name: "wg1",
+						Inline: &corev1alpha1.WireguardInlineConfig{
+							Interface: corev1alpha1.WireguardInterface{
+								Address:    []string{"10.13.13.2/32"},
+								KeyPairRef: &corev1.LocalObjectReference{Name: "wg1"},
+							},
+							Peers: []corev1alpha1.WireguardInlinePeer{{
+								PublicKey:            "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=",
+								AllowedIPs:           []string{"0.0.0.0/0"},
+								GeneratePresharedKey: true,
+							}},
+						},
+					},
+				},
+				Image:            "example.com/wireguard:latest",
+				ImagePullPolicy:  corev1.PullAlways,
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+				Proxy:            &corev1alpha1.WireguardClientProxy{SOCKSPort: 1080, HTTPPort: 8080},
+				Inbound: []corev1alpha1.WireguardClientInbound{{
+					Name:     "web",
+					Port:     8443,
+					Protocol: corev1.ProtocolTCP,
+					Service:  corev1alpha1.WireguardClientInboundService{Name: "web", Port: 443},
+				}},
+			},
+			Status: corev1alpha1.WireguardClientStatus{
+				ObservedGeneration: 2,
+				ReadyPods:          []string{"test-conversion-abc"},
+			},
+		}
+	})
+
+	Context("When converting WireguardClient between versions", func() {
+		It("Should separate the tunnel from the runtime", func() {
+			hub := &corev1beta1.WireguardClient{}
+			Expect(obj.ConvertTo(hub)).To(Succeed())
+
+			Expect(hub.Name).To(Equal(obj.Name))
+			Expect(hub.Spec.Tunnel.AllowedIPs).To(Equal(obj.Spec.AllowedIPs))
+			Expect(hub.Spec.Tunnel.Configs).To(HaveLen(2))
+			Expect(hub.Spec.Tunnel.Configs[1].Inline.Peers[0].GeneratePresharedKey).To(BeTrue())
+			Expect(hub.Spec.Runtime).To(Equal(corev1beta1.WireguardClientRuntime{
+				PUID:             1000,
+				PGID:             1001,
+				TZ:               "America/Chicago",
+				LogConfs:         ptr.To(false),
+				ReadOnly:         ptr.To(true),
+				Image:            "example.com/wireguard:latest",
+				ImagePullPolicy:  corev1.PullAlways,
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+			}))
+			Expect(hub.Spec.Inbound[0].Service.Port).To(Equal(int32(443)))
+			Expect(hub.Status.ReadyPods).To(ConsistOf("test-conversion-abc"))
+		})
+
+		It("Should round trip v1alpha1 through the hub", func() {
+			hub := &corev1beta1.WireguardClient{}
+			Expect(obj.ConvertTo(hub)).To(Succeed())
+
+			actual := &corev1alpha1.WireguardClient{}
+			Expect(actual.ConvertFrom(hub)).To(Succeed())
+			Expect(actual).To(Equal(obj))
+		})
+
+		It("Should round trip the hub through v1alpha1", func() {
+			hub := &corev1beta1.WireguardClient{}
+			Expect(obj.ConvertTo(hub)).To(Succeed())
+
+			spoke := &corev1alpha1.WireguardClient{}
+			Expect(spoke.ConvertFrom(hub)).To(Succeed())
+
+			actual := &corev1beta1.WireguardClient{}
+			Expect(spoke.ConvertTo(actual)).To(Succeed())
+			Expect(actual).To(Equal(hub))
+		})
+
+		It("Should serve v1alpha1 clients as v1beta1", func(ctx context.Context) {
+			Expect(k8sClient.Create(ctx, obj)).To(Succeed())
+			DeferCleanup(func(ctx context.Context) {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, obj))).To(Succeed())
+			})
+
+			hub := &corev1beta1.WireguardClient{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), hub)).To(Succeed())
+			Expect(hub.Spec.Runtime.PUID).To(Equal(int64(1000)))
+			Expect(hub.Spec.Tunnel.Configs).To(HaveLen(2))
+		})
+	})
+})
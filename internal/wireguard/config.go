@@ -0,0 +1,103 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wireguard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Interface is the [Interface] section of a wg-quick config
+type Interface struct {
+	PrivateKey string
+	Address    []string
+	DNS        []string
+	MTU        int
+	ListenPort int
+}
+
+// Peer is a [Peer] section of a wg-quick config
+type Peer struct {
+	PublicKey           string
+	PresharedKey        string
+	Endpoint            string
+	AllowedIPs          []string
+	PersistentKeepalive int
+}
+
+// Config is a wg-quick configuration file
+type Config struct {
+	Interface Interface
+	Peers     []Peer
+}
+
+// String renders c in the wg-quick INI format
+func (c *Config) String() string {
+	b := &strings.Builder{}
+
+	b.WriteString("[Interface]\n")
+	writeKey(b, "PrivateKey", c.Interface.PrivateKey)
+	writeList(b, "Address", c.Interface.Address)
+	writeList(b, "DNS", c.Interface.DNS)
+	writeInt(b, "MTU", c.Interface.MTU)
+	writeInt(b, "ListenPort", c.Interface.ListenPort)
+
+	for _, p := range c.Peers {
+		b.WriteString("\n")
+		b.WriteString(p.String())
+	}
+
+	return b.String()
+}
+
+// String renders p as a [Peer] section in the wg-quick INI format
+func (p *Peer) String() string {
+	b := &strings.Builder{}
+
+	b.WriteString("[Peer]\n")
+	writeKey(b, "PublicKey", p.PublicKey)
+	writeKey(b, "PresharedKey", p.PresharedKey)
+	writeKey(b, "Endpoint", p.Endpoint)
+	writeList(b, "AllowedIPs", p.AllowedIPs)
+	writeInt(b, "PersistentKeepalive", p.PersistentKeepalive)
+
+	return b.String()
+}
+
+// writeKey writes a single directive. Values containing a line break would
+// add directives of their own, such as PostUp, and are never written;
+// Validate reports them.
+func writeKey(b *strings.Builder, key, value string) {
+	if value != "" && !hasLineBreak(value) {
+		_, _ = fmt.Fprintf(b, "%s = %s\n", key, value)
+	}
+}
+
+func writeList(b *strings.Builder, key string, values []string) {
+	writeKey(b, key, strings.Join(values, ", "))
+}
+
+func writeInt(b *strings.Builder, key string, value int) {
+	if value > 0 {
+		writeKey(b, key, strconv.Itoa(value))
+	}
+}
+
+func hasLineBreak(value string) bool {
+	return strings.ContainsAny(value, "\r\n")
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wireguard_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+var _ = Describe("Config", func() {
+	It("should render a wg-quick config", func() {
+		config := &wireguard.Config{
+			Interface: wireguard.Interface{
+				PrivateKey: "private-key",
+				Address:    []string{"10.0.0.2/32", "fd00::2/128"},
+				DNS:        []string{"10.0.0.1"},
+				MTU:        1420,
+			},
+			Peers: []wireguard.Peer{{
+				PublicKey:           "public-key",
+				Endpoint:            "192.0.2.1:51820",
+				AllowedIPs:          []string{"0.0.0.0/0", "::/0"},
+				PersistentKeepalive: 25,
+			}},
+		}
+
+		Expect(config.String()).To(Equal(`[Interface]
+PrivateKey = private-key
+Address = 10.0.0.2/32, fd00::2/128
+DNS = 10.0.0.1
+MTU = 1420
+
+[Peer]
+PublicKey = public-key
+Endpoint = 192.0.2.1:51820
+AllowedIPs = 0.0.0.0/0, ::/0
+PersistentKeepalive = 25
+`))
+	})
+
+	It("should not write values containing line breaks", func() {
+		config := &wireguard.Config{
+			Interface: wireguard.Interface{
+				PrivateKey: "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=",
+				Address:    []string{"10.0.0.2/32"},
+			},
+			Peers: []wireguard.Peer{{
+				PublicKey:  "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=",
+				Endpoint:   "192.0.2.1:51820\nPostUp = touch /tmp/pwned",
+				AllowedIPs: []string{"0.0.0.0/0"},
+			}},
+		}
+
+		Expect(config.String()).NotTo(ContainSubstring("PostUp"))
+		Expect(config.Validate()).To(MatchError(ContainSubstring("line break")))
+	})
+})
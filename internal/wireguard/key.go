@@ -0,0 +1,86 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wireguard
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// KeyLen is the length in bytes of a WireGuard key
+const KeyLen = 32
+
+// Key is a Curve25519 private or public key, or a preshared key
+type Key [KeyLen]byte
+
+// GenerateKey generates a random key suitable for use as a preshared key
+func GenerateKey() (Key, error) {
+	var k Key
+	if _, err := rand.Read(k[:]); err != nil {
+		return Key{}, err
+	}
+
+	return k, nil
+}
+
+// GeneratePrivateKey generates a clamped Curve25519 private key, like `wg genkey`
+func GeneratePrivateKey() (Key, error) {
+	k, err := GenerateKey()
+	if err != nil {
+		return Key{}, err
+	}
+
+	k[0] &= 248
+	k[31] = (k[31] & 127) | 64
+
+	return k, nil
+}
+
+// ParseKey parses a base64 encoded key
+func ParseKey(s string) (Key, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return Key{}, fmt.Errorf("invalid key: %w", err)
+	}
+	if len(b) != KeyLen {
+		return Key{}, fmt.Errorf("invalid key: expected %d bytes, got %d", KeyLen, len(b))
+	}
+
+	var k Key
+	copy(k[:], b)
+	return k, nil
+}
+
+// PublicKey returns the public key for the private key k
+func (k Key) PublicKey() Key {
+	priv, err := ecdh.X25519().NewPrivateKey(k[:])
+	if err != nil {
+		// Only possible when the key is not KeyLen bytes
+		panic(err)
+	}
+
+	var pub Key
+	copy(pub[:], priv.PublicKey().Bytes())
+	return pub
+}
+
+// String returns the base64 encoding of k, as used in wg-quick configs
+func (k Key) String() string {
+	return base64.StdEncoding.EncodeToString(k[:])
+}
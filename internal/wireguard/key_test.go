@@ -0,0 +1,66 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wireguard_test
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+var _ = Describe("Key", func() {
+	It("should derive the public key", func() {
+		// RFC 7748 section 6.1
+		priv, err := hex.DecodeString("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+		Expect(err).NotTo(HaveOccurred())
+		pub, err := hex.DecodeString("8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a")
+		Expect(err).NotTo(HaveOccurred())
+
+		key, err := wireguard.ParseKey(base64.StdEncoding.EncodeToString(priv))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(key.PublicKey().String()).To(Equal(base64.StdEncoding.EncodeToString(pub)))
+	})
+
+	It("should generate clamped private keys", func() {
+		key, err := wireguard.GeneratePrivateKey()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(key[0] & 7).To(BeZero())
+		Expect(key[31] & 128).To(BeZero())
+		Expect(key[31] & 64).NotTo(BeZero())
+	})
+
+	It("should round trip through its string form", func() {
+		key, err := wireguard.GenerateKey()
+		Expect(err).NotTo(HaveOccurred())
+
+		parsed, err := wireguard.ParseKey(key.String())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(Equal(key))
+	})
+
+	It("should reject keys of the wrong length", func() {
+		_, err := wireguard.ParseKey(base64.StdEncoding.EncodeToString([]byte("too short")))
+
+		Expect(err).To(MatchError(ContainSubstring("expected 32 bytes")))
+	})
+})
@@ -0,0 +1,178 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wireguard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse parses a wg-quick config. Keys that only affect wg-quick itself,
+// such as PostUp or Table, are ignored.
+func Parse(data string) (*Config, error) {
+	c := &Config{}
+	section := ""
+
+	s := bufio.NewScanner(strings.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			switch section {
+			case "interface":
+			case "peer":
+				c.Peers = append(c.Peers, Peer{})
+			default:
+				return nil, fmt.Errorf("line %d: unknown section %q", n, line)
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		var err error
+		switch section {
+		case "interface":
+			err = parseInterface(&c.Interface, key, value)
+		case "peer":
+			err = parsePeer(&c.Peers[len(c.Peers)-1], key, value)
+		default:
+			err = errors.New("key outside of a section")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Validate checks that c has the keys and peers required to bring up a tunnel
+func (c *Config) Validate() error {
+	if _, err := ParseKey(c.Interface.PrivateKey); err != nil {
+		return fmt.Errorf("interface private key: %w", err)
+	}
+	if len(c.Interface.Address) == 0 {
+		return errors.New("interface has no address")
+	}
+	if err := checkLines("interface address", c.Interface.Address...); err != nil {
+		return err
+	}
+	if err := checkLines("interface DNS", c.Interface.DNS...); err != nil {
+		return err
+	}
+	if len(c.Peers) == 0 {
+		return errors.New("no peers")
+	}
+
+	for i, p := range c.Peers {
+		if _, err := ParseKey(p.PublicKey); err != nil {
+			return fmt.Errorf("peer %d public key: %w", i, err)
+		}
+		if p.PresharedKey != "" {
+			if _, err := ParseKey(p.PresharedKey); err != nil {
+				return fmt.Errorf("peer %d preshared key: %w", i, err)
+			}
+		}
+		if len(p.AllowedIPs) == 0 {
+			return fmt.Errorf("peer %d has no allowed IPs", i)
+		}
+		if err := checkLines(fmt.Sprintf("peer %d endpoint", i), p.Endpoint); err != nil {
+			return err
+		}
+		if err := checkLines(fmt.Sprintf("peer %d allowed IPs", i), p.AllowedIPs...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkLines reports values that would break out of their line when rendered
+func checkLines(name string, values ...string) error {
+	for _, v := range values {
+		if hasLineBreak(v) {
+			return fmt.Errorf("%s contains a line break", name)
+		}
+	}
+
+	return nil
+}
+
+func parseInterface(i *Interface, key, value string) (err error) {
+	switch key {
+	case "privatekey":
+		i.PrivateKey = value
+	case "address":
+		i.Address = append(i.Address, parseList(value)...)
+	case "dns":
+		i.DNS = append(i.DNS, parseList(value)...)
+	case "mtu":
+		i.MTU, err = strconv.Atoi(value)
+	case "listenport":
+		i.ListenPort, err = strconv.Atoi(value)
+	}
+
+	return err
+}
+
+func parsePeer(p *Peer, key, value string) (err error) {
+	switch key {
+	case "publickey":
+		p.PublicKey = value
+	case "presharedkey":
+		p.PresharedKey = value
+	case "endpoint":
+		p.Endpoint = value
+	case "allowedips":
+		p.AllowedIPs = append(p.AllowedIPs, parseList(value)...)
+	case "persistentkeepalive":
+		if value != "off" {
+			p.PersistentKeepalive, err = strconv.Atoi(value)
+		}
+	}
+
+	return err
+}
+
+func parseList(value string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wireguard_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/unmango/thecluster-operator/internal/wireguard"
+)
+
+var _ = Describe("Parse", func() {
+	const (
+		privateKey = "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk="
+		publicKey  = "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="
+	)
+
+	It("should parse a wg-quick config", func() {
+		config, err := wireguard.Parse(`
+# Downloaded config
+[Interface]
+PrivateKey = ` + privateKey + `
+Address = 10.2.0.2/32
+DNS = 10.2.0.1
+PostUp = echo up
+
+[Peer]
+# NL#1
+PublicKey = ` + publicKey + `
+AllowedIPs = 0.0.0.0/0, ::/0
+Endpoint = 192.0.2.1:51820
+PersistentKeepalive = 25
+`)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(Equal(&wireguard.Config{
+			Interface: wireguard.Interface{
+				PrivateKey: privateKey,
+				Address:    []string{"10.2.0.2/32"},
+				DNS:        []string{"10.2.0.1"},
+			},
+			Peers: []wireguard.Peer{{
+				PublicKey:           publicKey,
+				Endpoint:            "192.0.2.1:51820",
+				AllowedIPs:          []string{"0.0.0.0/0", "::/0"},
+				PersistentKeepalive: 25,
+			}},
+		}))
+		Expect(config.Validate()).To(Succeed())
+	})
+
+	It("should round trip a rendered config", func() {
+		config := &wireguard.Config{
+			Interface: wireguard.Interface{
+				PrivateKey: privateKey,
+				Address:    []string{"10.0.0.2/32", "fd00::2/128"},
+				MTU:        1420,
+			},
+			Peers: []wireguard.Peer{{
+				PublicKey:  publicKey,
+				AllowedIPs: []string{"10.0.0.0/24"},
+			}},
+		}
+
+		Expect(wireguard.Parse(config.String())).To(Equal(config))
+	})
+
+	It("should reject unknown sections", func() {
+		_, err := wireguard.Parse("[Interfaces]\n")
+
+		Expect(err).To(MatchError(ContainSubstring("line 1")))
+	})
+
+	It("should reject malformed lines", func() {
+		_, err := wireguard.Parse("[Interface]\nPrivateKey\n")
+
+		Expect(err).To(MatchError(ContainSubstring("line 2")))
+	})
+
+	DescribeTable("Validate",
+		func(config string, message string) {
+			c, err := wireguard.Parse(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(c.Validate()).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("invalid private key",
+			"[Interface]\nPrivateKey = nope\n", "private key"),
+		Entry("missing address",
+			"[Interface]\nPrivateKey = "+privateKey+"\n", "no address"),
+		Entry("missing peers",
+			"[Interface]\nPrivateKey = "+privateKey+"\nAddress = 10.0.0.2/32\n", "no peers"),
+		Entry("invalid public key",
+			"[Interface]\nPrivateKey = "+privateKey+"\nAddress = 10.0.0.2/32\n[Peer]\nPublicKey = nope\n", "public key"),
+		Entry("missing allowed IPs",
+			"[Interface]\nPrivateKey = "+privateKey+"\nAddress = 10.0.0.2/32\n[Peer]\nPublicKey = "+publicKey+"\n", "allowed IPs"),
+	)
+})